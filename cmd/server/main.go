@@ -1,67 +1,302 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/config"
 	"github.com/ihladush/bitcoin/internal/handlers"
+	"github.com/ihladush/bitcoin/internal/metrics"
+	"github.com/ihladush/bitcoin/internal/notify"
 	"github.com/ihladush/bitcoin/internal/repository"
+	"github.com/ihladush/bitcoin/internal/requestid"
 	"github.com/ihladush/bitcoin/internal/services"
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ApplyFlags(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	labelTemplate, err := services.ParseLabelTemplate(cfg.LabelTemplate)
+	if err != nil {
+		log.Fatalf("Invalid configuration: invalid DEFAULT_LABEL_TEMPLATE: %v", err)
+	}
+	initialSyncMode, err := services.ParseInitialSyncMode(cfg.InitialSyncMode)
+	if err != nil {
+		log.Fatalf("Invalid configuration: invalid INITIAL_SYNC_MODE: %v", err)
+	}
+
+	// Check the certificate before anything else starts, so a bad deployment fails fast
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		if tlsConfig, err = loadTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, time.Now()); err != nil {
+			log.Fatalf("Invalid TLS configuration: %v", err)
+		}
+	}
+
 	// Initialize database
-	repo, err := repository.NewSQLiteRepository("bitcoin_tracker.db")
+	dbPath := cfg.DBPath
+	repoOpts := []repository.Option{
+		repository.WithNetwork(string(cfg.Network)),
+		repository.WithBalanceCache(cfg.BalanceCache),
+		repository.WithFinalDepth(cfg.FinalDepth),
+		repository.WithPreservedHistory(cfg.PreserveHistoryOnReadd),
+		repository.WithPageLimits(cfg.PageLimit, cfg.MaxPageLimit),
+	}
+	if cfg.DBReadPath != "" {
+		repoOpts = append(repoOpts, repository.WithReadDSN(cfg.DBReadPath))
+	}
+	repo, err := repository.NewSQLiteRepository(dbPath, repoOpts...)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer repo.Close()
+	if cfg.DBReadPath != "" {
+		log.Printf("📖 Serving dashboard reads from %s", cfg.DBReadPath)
+	}
+
+	// Verify database integrity
+	if cfg.IntegrityCheck != "off" {
+		checkIntegrity(repo, cfg.IntegrityCheck == "fail")
+	}
+
+	// Initialize balance history storage
+	snapshotDBPath := cfg.SnapshotDBPath
+	if snapshotDBPath == "" {
+		snapshotDBPath = dbPath
+	}
+	snapshots, err := repository.NewSQLiteSnapshotStore(snapshotDBPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize snapshot store: %v", err)
+	}
+	defer snapshots.Close()
 
-	// Initialize Bitcoin client
-	client := clients.NewBlockchairClient()
+	// Initialize Bitcoin client and fee source
+	var client clients.BitcoinClient
+	var fees clients.FeeEstimator
+	switch cfg.Provider {
+	case "static":
+		static, err := clients.NewStaticClient(cfg.StaticFixture)
+		if err != nil {
+			log.Fatalf("Failed to initialize static provider: %v", err)
+		}
+		client, fees = static, static
+		log.Printf("📦 Using static provider fixture %s", cfg.StaticFixture)
+	default:
+		clientOpts := []clients.BlockchairOption{
+			clients.WithBaseURL(cfg.BlockchairBaseURL),
+			clients.WithConnectionPool(cfg.ConnectionPool),
+			clients.WithAPIKey(cfg.BlockchairAPIKey),
+		}
+		if cfg.LogLevel == "debug" {
+			clientOpts = append(clientOpts, clients.WithRequestObserver(clients.LogObserver{}))
+		}
+		if cfg.CacheMode != clients.CacheModeOff {
+			cache, err := clients.NewResponseCache(cfg.CacheDir, cfg.CacheTTL, cfg.CacheMode)
+			if err != nil {
+				log.Fatalf("Failed to initialize provider cache: %v", err)
+			}
+			clientOpts = append(clientOpts, clients.WithResponseCache(cache))
+			log.Printf("💾 Provider cache enabled (mode=%s, dir=%s)", cfg.CacheMode, cfg.CacheDir)
+		}
+		client = clients.NewBlockchairClient(clientOpts...)
+		fees = clients.NewMempoolFeeClient(cfg.FeeEstimatesURL)
+	}
+
+	// Sync alerts go to a webhook when one is configured, otherwise to the log
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if cfg.AlertWebhookURL != "" {
+		notifier = notify.NewWebhookNotifier(cfg.AlertWebhookURL)
+	}
+
+	// Confirmation notifications are only sent to their own webhook
+	var confirmationNotifier notify.Notifier
+	if cfg.ConfirmationWebhookURL != "" {
+		confirmationNotifier = notify.NewWebhookNotifier(cfg.ConfirmationWebhookURL)
+		log.Printf("🔔 Confirmation notifications enabled at %d confirmations", cfg.ConfirmationTarget)
+	}
+	notificationBatchSize := 0
+	if cfg.NotificationDelivery == "batch" {
+		notificationBatchSize = cfg.NotificationBatchSize
+	}
+
+	// Per-address balance gauges are opt-in because every series is a separate metric
+	var balanceGauges *metrics.AddressBalances
+	if cfg.BalanceMetrics {
+		balanceGauges = metrics.NewAddressBalances(cfg.BalanceMetricsMaxSeries, cfg.BalanceMetricsAllowlist)
+	}
+
+	// Tracing is a no-op unless an OTLP collector is configured
+	var tracer trace.Tracer
+	var tracerProvider *sdktrace.TracerProvider
+	if cfg.OTLPEndpoint != "" {
+		tracerProvider, err = tracing.NewProvider(context.Background(), cfg.OTLPEndpoint, cfg.ServiceName)
+		if err != nil {
+			log.Fatalf("Failed to initialize tracing: %v", err)
+		}
+		tracer = tracerProvider.Tracer(tracing.InstrumentationName)
+		log.Printf("🔭 Exporting traces to %s", cfg.OTLPEndpoint)
+	}
+
+	// Cancelling syncCtx on shutdown stops the background sync worker and the work requests leave running
+	syncCtx, stopSync := context.WithCancel(context.Background())
 
 	// Initialize service
-	service := services.NewBitcoinService(repo, client)
+	service := services.NewBitcoinService(repo, client,
+		services.WithLifecycle(syncCtx),
+		services.WithNetwork(cfg.Network),
+		services.WithAllowedAddressTypes(cfg.AllowedAddressTypes...),
+		services.WithInactiveCleanup(cfg.CleanupInactiveAfter),
+		services.WithTransactionArchive(cfg.ArchiveTransactionsAfter),
+		services.WithSyncLimits(cfg.SyncConcurrency, cfg.SyncRateLimit),
+		services.WithSyncBudget(cfg.SyncRunTimeout, cfg.SyncFailureBudget),
+		services.WithSyncBatchSize(cfg.SyncBatchSize),
+		services.WithInsertBatchSize(cfg.InsertBatchSize),
+		services.WithSyncLogRetention(cfg.SyncLogRetention),
+		services.WithSyncCooldown(cfg.SyncCooldown),
+		services.WithLabelTemplate(labelTemplate),
+		services.WithMaxExportRows(cfg.MaxExportRows),
+		services.WithSnapshotStore(snapshots),
+		services.WithMaxHistory(cfg.MaxTransactionsPerAddress),
+		services.WithFinalDepth(cfg.FinalDepth),
+		services.WithStatusThresholds(cfg.StatusThresholds),
+		services.WithImmatureCoinbaseExcluded(cfg.ExcludeImmatureCoinbase),
+		services.WithInitialSyncMode(initialSyncMode),
+		services.WithFeeEstimator(fees),
+		services.WithFailureAlerts(cfg.SyncFailureAlertThreshold, notifier),
+		services.WithConfirmationAlerts(cfg.ConfirmationTarget, confirmationNotifier),
+		services.WithNotificationBatching(notificationBatchSize),
+		services.WithBalanceGauges(balanceGauges),
+		services.WithTracer(tracer),
+	)
 
 	// Initialize handlers
-	handler := handlers.NewBitcoinHandler(service)
+	drain := handlers.NewDrain()
+	handler := handlers.NewBitcoinHandler(service,
+		handlers.WithStatusThresholds(cfg.StatusThresholds),
+		handlers.WithDrain(drain),
+		handlers.WithAdminToken(cfg.AdminToken),
+	)
 
 	// Setup routes
-	router := setupRoutes(handler)
+	router := setupRoutes(handler, drain, cfg, tracer)
+	if balanceGauges != nil {
+		router.Handle("/metrics", balanceGauges).Methods("GET")
+		log.Printf("📈 Per-address balance gauges at GET /metrics (up to %d series)", cfg.BalanceMetricsMaxSeries)
+	}
 
-	// Start background sync worker
-	go startBackgroundSync(service)
+	if cfg.ReadOnly {
+		log.Println("🔒 Read-only mode: mutating API requests are rejected")
+	}
+
+	// Start background sync worker; syncDone closes once it has stopped
+	syncDone := make(chan struct{})
+	go func() {
+		defer close(syncDone)
+		startBackgroundSync(syncCtx, service, cfg.SyncInterval, cfg.SyncOnStartup)
+	}()
 
 	// Start server
 	server := &http.Server{
-		Addr:         ":8080",
-		Handler:      router,
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      corsHandler(router, cfg.CORSMaxAge, cfg.RequestIDHeader),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		TLSConfig:    tlsConfig,
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Println("🚀 Bitcoin Tracker API starting on port 8080")
+		if tlsConfig != nil {
+			log.Printf("🚀 Bitcoin Tracker API starting on port %d (HTTPS, HTTP/2)", cfg.Port)
+		} else {
+			log.Printf("🚀 Bitcoin Tracker API starting on port %d", cfg.Port)
+		}
 		log.Println("📋 API Documentation:")
-		log.Println("   GET    /health                        - Health check")
+		log.Println("   GET    /health                        - Health check (degraded after repeated sync failures)")
+		log.Println("   GET    /readyz                        - Readiness probe (fails once draining)")
 		log.Println("   GET    /addresses                     - List all tracked addresses")
 		log.Println("   POST   /addresses                     - Add new address")
 		log.Println("   GET    /addresses/{address}           - Get address details")
-		log.Println("   DELETE /addresses/{address}           - Remove address")
+		log.Println("   PUT    /addresses/{address}           - Update address label, owned flag or sync priority")
+		log.Println("   DELETE /addresses/{address}           - Remove address (?soft=true to archive)")
+		log.Println("   POST   /addresses/{address}/verify/challenge - Issue a message to sign for ownership")
+		log.Println("   POST   /addresses/{address}/verify    - Prove ownership with a signed challenge")
+		log.Println("   POST   /addresses/bulk                - Add several addresses")
+		log.Println("   POST   /addresses/delete              - Remove several addresses")
 		log.Println("   GET    /addresses/{address}/balance   - Get address balance")
 		log.Println("   GET    /addresses/{address}/transactions - Get address transactions")
-		log.Println("   POST   /addresses/{address}/sync      - Sync specific address")
+		log.Println("   GET    /addresses/{address}/transactions/latest - Most recent transaction (204 if none)")
+		log.Println("   GET    /addresses/{address}/sync-history - Timeline of syncs and their outcomes")
+		log.Println("   GET    /addresses/{address}/types     - Count transactions by type")
+		log.Println("   GET    /addresses/{address}/volume    - Transaction volume by day, week or month")
+		log.Println("   POST   /addresses/{address}/transactions/import - Store raw provider transaction JSON")
+		log.Println("   GET    /addresses/{address}/history   - Get balance history")
+		log.Println("   GET    /addresses/{address}/watch     - Long-poll for a balance change")
+		log.Println("   GET    /transactions                  - Query transactions across addresses (JSON or CSV)")
+		log.Println("   POST   /addresses/{address}/sync      - Sync specific address (?dry_run=true to preview)")
+		log.Println("   POST   /addresses/{address}/pause     - Pause syncing an address")
+		log.Println("   POST   /addresses/{address}/resume    - Resume syncing a paused address")
 		log.Println("   POST   /sync                          - Sync all addresses")
+		log.Println("   POST   /sync/batch                    - Sync a list of addresses")
+		log.Println("   GET    /compare?a=...&b=...           - Compare two addresses side by side")
+		log.Println("   GET    /search?q=...                  - Find a transaction by hash or addresses by address or label")
+		log.Println("   GET    /clusters                      - Group addresses that spent together (likely one wallet)")
+		log.Println("   POST   /batch                         - Run several operations in one request")
+		log.Println("   GET    /portfolio                     - Total balance across addresses (?owned_only=true)")
+		log.Println("   GET    /portfolio/history             - Total balance over time by day, week or month")
+		log.Println("   GET    /tags/{tag}/addresses          - List addresses carrying a tag")
+		log.Println("   POST   /tags/{tag}/addresses          - Tag several addresses")
+		log.Println("   POST   /tags/{tag}/addresses/delete   - Remove a tag from several addresses")
+		log.Println("   GET    /fees                          - Recommended fee rates (sat/vB)")
+		log.Println("   GET    /transactions/{hash}/eta       - Estimate when an unconfirmed transaction confirms")
+		log.Println("   GET    /chain                         - Provider chain tip and stored data lag")
+		log.Println("   GET    /capabilities                  - Optional features the active provider supports")
+		log.Println("   POST   /admin/cleanup                 - Archive empty, inactive addresses")
+		log.Println("   POST   /admin/archive-transactions    - Move old, deeply confirmed transactions to the archive")
+		log.Println("   POST   /admin/recalculate             - Start recomputing balances and repairing drift from the provider")
+		log.Println("   GET    /admin/recalculate             - Status of the latest balance recalculation")
+		log.Println("   POST   /admin/backfill-types          - Detect and store the type of every stored address")
+		log.Println("   POST   /admin/addresses/{address}/restore - Restore an archived address")
+		log.Println("   POST   /admin/addresses/{address}/reset-sync - Clear or backdate last_synced")
+		log.Println("   POST   /admin/drain                   - Fail readiness and wait for in-flight requests (ADMIN_TOKEN)")
 		
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// The certificate is already in TLSConfig; net/http negotiates HTTP/2 over TLS by itself
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server startup failed: %v", err)
 		}
 	}()
@@ -71,72 +306,390 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("🛑 Shutting down server...")
+
+	// Stop accepting requests and let in-flight ones finish, interrupting a running background sync and
+	// the work requests left running meanwhile. The sync checkpoint lets the next run resume, and the
+	// deferred database closes only run once all of them are done.
+	stopSync()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("❌ Server shutdown did not complete: %v", err)
+	}
+	select {
+	case <-syncDone:
+	case <-ctx.Done():
+		log.Println("❌ Background sync did not stop in time")
+	}
+	// No request can start background work once Shutdown has returned
+	backgroundDone := make(chan struct{})
+	go func() {
+		defer close(backgroundDone)
+		service.Wait()
+	}()
+	select {
+	case <-backgroundDone:
+	case <-ctx.Done():
+		log.Println("❌ Background work started by requests did not stop in time")
+	}
+
+	if tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			log.Printf("❌ Trace export did not complete: %v", err)
+		}
+		cancel()
+	}
+	log.Println("👋 Server stopped")
+}
+
+// shutdownTimeout bounds how long shutdown waits for in-flight requests and the background sync
+const shutdownTimeout = 15 * time.Second
+
+// loadTLSConfig loads the server certificate and key, failing with a clear error when they cannot be
+// read, do not match or the certificate is not currently valid. Clients may negotiate HTTP/2 or HTTP/1.1.
+func loadTLSConfig(certFile, keyFile string, now time.Time) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate %s with key %s: %w", certFile, keyFile, err)
+	}
+	if leaf := cert.Leaf; leaf != nil {
+		if now.After(leaf.NotAfter) {
+			return nil, fmt.Errorf("certificate %s expired at %s", certFile, leaf.NotAfter.Format(time.RFC3339))
+		}
+		if now.Before(leaf.NotBefore) {
+			return nil, fmt.Errorf("certificate %s is not valid before %s", certFile, leaf.NotBefore.Format(time.RFC3339))
+		}
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// checkIntegrity runs the startup database check, exiting on problems when fatal is set
+func checkIntegrity(repo *repository.SQLiteRepository, fatal bool) {
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		log.Fatalf("Failed to check database integrity: %v", err)
+	}
+
+	if report.Healthy {
+		log.Println("✅ Database integrity check passed")
+		return
+	}
+
+	for _, problem := range report.Problems {
+		log.Printf("⚠️  Database integrity problem: %s", problem)
+	}
+	if fatal {
+		log.Fatalf("Database integrity check failed with %d problems", len(report.Problems))
+	}
 }
 
+// watchRoute names the long-polling route that is exempt from the request deadline
+const watchRoute = "watch"
+
 // setupRoutes configures all API routes
-func setupRoutes(handler *handlers.BitcoinHandler) *mux.Router {
+func setupRoutes(handler *handlers.BitcoinHandler, drain *handlers.Drain, cfg *config.Config, tracer trace.Tracer) *mux.Router {
 	router := mux.NewRouter()
 
 	// Health check
 	router.HandleFunc("/health", handler.HealthCheck).Methods("GET")
+	router.HandleFunc("/readyz", handler.Readyz).Methods("GET")
 
 	// Address management
 	router.HandleFunc("/addresses", handler.GetAllAddresses).Methods("GET")
 	router.HandleFunc("/addresses", handler.AddAddress).Methods("POST")
+	router.HandleFunc("/addresses/bulk", handler.AddAddresses).Methods("POST")
+	router.HandleFunc("/addresses/delete", handler.RemoveAddresses).Methods("POST")
 	router.HandleFunc("/addresses/{address}", handler.GetAddress).Methods("GET")
+	router.HandleFunc("/addresses/{address}", handler.UpdateAddress).Methods("PUT")
 	router.HandleFunc("/addresses/{address}", handler.RemoveAddress).Methods("DELETE")
 
 	// Balance and transactions
 	router.HandleFunc("/addresses/{address}/balance", handler.GetBalance).Methods("GET")
 	router.HandleFunc("/addresses/{address}/transactions", handler.GetTransactions).Methods("GET")
+	router.HandleFunc("/addresses/{address}/transactions/latest", handler.GetLatestTransaction).Methods("GET")
+	router.HandleFunc("/addresses/{address}/sync-history", handler.GetSyncHistory).Methods("GET")
+	router.HandleFunc("/addresses/{address}/types", handler.GetTransactionTypes).Methods("GET")
+	router.HandleFunc("/addresses/{address}/volume", handler.GetVolume).Methods("GET")
+	router.HandleFunc("/addresses/{address}/history", handler.GetBalanceHistory).Methods("GET")
+	router.HandleFunc("/addresses/{address}/watch", handler.WatchBalance).Methods("GET").Name(watchRoute)
+	router.HandleFunc("/transactions", handler.GetAllTransactions).Methods("GET")
 
 	// Synchronization
 	router.HandleFunc("/addresses/{address}/sync", handler.SyncAddress).Methods("POST")
+	router.HandleFunc("/addresses/{address}/pause", handler.PauseSync).Methods("POST")
+	router.HandleFunc("/addresses/{address}/resume", handler.ResumeSync).Methods("POST")
+	router.HandleFunc("/addresses/{address}/transactions/import", handler.ImportTransactions).Methods("POST")
+	router.HandleFunc("/addresses/{address}/verify/challenge", handler.CreateOwnershipChallenge).Methods("POST")
+	router.HandleFunc("/addresses/{address}/verify", handler.VerifyOwnership).Methods("POST")
 	router.HandleFunc("/sync", handler.SyncAllAddresses).Methods("POST")
+	router.HandleFunc("/sync/batch", handler.SyncAddresses).Methods("POST")
+
+	// Analytics
+	router.HandleFunc("/compare", handler.CompareAddresses).Methods("GET")
+	router.HandleFunc("/search", handler.Search).Methods("GET")
+	router.HandleFunc("/clusters", handler.GetClusters).Methods("GET")
 
-	// Add CORS middleware
-	router.Use(corsMiddleware)
+	// Batch operations
+	router.HandleFunc("/batch", handler.Batch).Methods("POST")
+
+	// Portfolio
+	router.HandleFunc("/portfolio", handler.GetPortfolio).Methods("GET")
+	router.HandleFunc("/portfolio/history", handler.GetPortfolioHistory).Methods("GET")
+
+	// Tags
+	router.HandleFunc("/tags/{tag}/addresses", handler.GetTaggedAddresses).Methods("GET")
+	router.HandleFunc("/tags/{tag}/addresses", handler.TagAddresses).Methods("POST")
+	router.HandleFunc("/tags/{tag}/addresses/delete", handler.UntagAddresses).Methods("POST")
+
+	// Network
+	router.HandleFunc("/fees", handler.GetFeeEstimates).Methods("GET")
+	router.HandleFunc("/transactions/{hash}/eta", handler.EstimateConfirmation).Methods("GET")
+	router.HandleFunc("/chain", handler.GetChainStatus).Methods("GET")
+	router.HandleFunc("/capabilities", handler.GetCapabilities).Methods("GET")
+
+	// Administration
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/cleanup", handler.Cleanup).Methods("POST")
+	admin.HandleFunc("/archive-transactions", handler.ArchiveTransactions).Methods("POST")
+	admin.HandleFunc("/recalculate", handler.RecalculateBalances).Methods("POST")
+	admin.HandleFunc("/recalculate", handler.GetRecalculation).Methods("GET")
+	admin.HandleFunc("/backfill-types", handler.BackfillTypes).Methods("POST")
+	admin.HandleFunc("/addresses/{address}/restore", handler.RestoreAddress).Methods("POST")
+	admin.HandleFunc("/addresses/{address}/reset-sync", handler.ResetSync).Methods("POST")
+	admin.Handle("/drain", handler.AdminAuth(http.HandlerFunc(handler.DrainInstance))).Methods("POST").Name(handlers.DrainRoute)
+
+	// CORS is handled by corsHandler around the router so preflight requests reach it
+	router.Use(requestIDMiddleware(cfg.RequestIDHeader))
+	router.Use(drain.Track)
+	if tracer != nil {
+		router.Use(tracingMiddleware(tracer))
+	}
 	router.Use(loggingMiddleware)
+	router.Use(handler.AddressParam)
+	if cfg.ReadOnly {
+		router.Use(handler.ReadOnly)
+	}
+	if cfg.RequestTimeout > 0 {
+		router.Use(deadlineMiddleware(cfg.RequestTimeout))
+	}
 
 	return router
 }
 
-// startBackgroundSync runs periodic synchronization
-func startBackgroundSync(service *services.BitcoinService) {
-	ticker := time.NewTicker(5 * time.Minute) // Sync every 5 minutes
-	defer ticker.Stop()
+// syncJitter returns a random delay of up to a tenth of interval, so that
+// several instances started together do not hit the provider in lockstep
+func syncJitter(interval time.Duration) time.Duration {
+	return rand.N(interval/10 + 1)
+}
 
-	for range ticker.C {
-		log.Println("🔄 Starting background sync...")
-		if err := service.SyncAllAddresses(); err != nil {
-			log.Printf("❌ Background sync failed: %v", err)
-		} else {
-			log.Println("✅ Background sync completed")
+// startBackgroundSync runs a sync every interval, plus jitter, until ctx ends, starting with an immediate
+// run when syncOnStartup is set
+func startBackgroundSync(ctx context.Context, service *services.BitcoinService, interval time.Duration, syncOnStartup bool) {
+	if syncOnStartup {
+		runBackgroundSync(ctx, service, "Startup sync")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Background sync stopped")
+			return
+		case <-time.After(interval + syncJitter(interval)):
 		}
+		runBackgroundSync(ctx, service, "Background sync")
+	}
+}
+
+// runBackgroundSync syncs every address and applies the cleanup and archive policies, logging the outcome.
+// Provider calls go through the service's shared concurrency and rate limits like any other sync.
+func runBackgroundSync(ctx context.Context, service *services.BitcoinService, name string) {
+	log.Printf("🔄 %s starting...", name)
+	started := time.Now()
+	result, err := service.SyncAllAddresses(ctx)
+	if result != nil && result.ResumedFrom != nil {
+		log.Printf("⏯️  Resumed sync run started at %s", result.ResumedFrom.Format(time.RFC3339))
+	}
+	if err != nil {
+		log.Printf("❌ %s failed: %v", name, err)
+		if result != nil && len(result.Skipped) > 0 {
+			log.Printf("⏭️  Addresses not attempted: %s", strings.Join(result.Skipped, ", "))
+		}
+	} else if result != nil {
+		log.Printf("✅ %s completed in %s: %d synced, %d failed",
+			name, time.Since(started).Round(time.Millisecond), len(result.Synced), len(result.Failed))
+	}
+
+	// Maintenance can wait for the next run once shutdown has begun
+	if ctx.Err() != nil {
+		return
+	}
+
+	if archived, err := service.AutoCleanup(ctx); err != nil {
+		log.Printf("❌ Address cleanup failed: %v", err)
+	} else if len(archived) > 0 {
+		log.Printf("🧹 Archived %d inactive addresses", len(archived))
+	}
+
+	if moved, err := service.AutoArchive(ctx); err != nil {
+		log.Printf("❌ Transaction archiving failed: %v", err)
+	} else if moved > 0 {
+		log.Printf("🗄️  Archived %d old transactions", moved)
 	}
 }
 
-// corsMiddleware adds CORS headers
-func corsMiddleware(next http.Handler) http.Handler {
+// corsMethods are the methods routes can be registered for, in the order they are advertised
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+// corsAllowedHeaders lists the request headers the API reads, with the configured request ID header
+func corsAllowedHeaders(requestIDHeader string) string {
+	return strings.Join([]string{
+		"Content-Type", "Accept", "Authorization", handlers.RawResponseHeader, requestIDHeader, "traceparent", "tracestate",
+	}, ", ")
+}
+
+// corsHandler adds CORS headers and answers preflight requests with the methods registered for the
+// requested path. It wraps the router instead of being router middleware because no route registers
+// OPTIONS, so the router would answer preflights with 405 before any middleware ran.
+func corsHandler(router *mux.Router, maxAge time.Duration, requestIDHeader string) http.Handler {
+	allowedHeaders := corsAllowedHeaders(requestIDHeader)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodOptions {
+			router.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		methods := registeredMethods(router, r)
+		if len(methods) == 0 {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(append(methods, http.MethodOptions), ", "))
+		w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+		if maxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusOK)
 	})
 }
 
+// registeredMethods returns the methods the router serves for the path of r
+func registeredMethods(router *mux.Router, r *http.Request) []string {
+	var methods []string
+	for _, method := range corsMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// deadlineExempt names the routes that manage their own timeout: the watch long-poll and the drain wait
+var deadlineExempt = map[string]bool{watchRoute: true, handlers.DrainRoute: true}
+
+// deadlineMiddleware cancels the request context after timeout so slow downstream work is abandoned.
+// Routes that manage their own timeout are left alone.
+func deadlineMiddleware(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil && deadlineExempt[route.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// tracingMiddleware records a server span per request, named after the matched route and joining
+// the caller's trace when a traceparent header is sent
+func tracingMiddleware(tracer trace.Tracer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+				),
+			)
+			if requestID := requestid.FromContext(r.Context()); requestID != "" {
+				span.SetAttributes(attribute.String("http.request_id", requestID))
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+			var err error
+			if recorder.status >= http.StatusInternalServerError {
+				err = errors.New(http.StatusText(recorder.status))
+			}
+			tracing.End(span, err)
+		})
+	}
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestIDMiddleware gives every request an ID, reusing a well-formed one sent by the caller in header,
+// and echoes it in the response. The ID travels in the request context so every layer can log it.
+func requestIDMiddleware(header string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+		})
+	}
+}
+
 // loggingMiddleware logs HTTP requests
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
+		log.Printf("%s%s %s %v", requestid.Prefix(r.Context()), r.Method, r.URL.Path, time.Since(start))
 	})
 }
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/config"
+	"github.com/ihladush/bitcoin/internal/handlers"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/repository"
+	"github.com/ihladush/bitcoin/internal/requestid"
+	"github.com/ihladush/bitcoin/internal/services"
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// trackedAddress is an address of the static fixture
+const trackedAddress = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+// newTestServer builds the full router, middleware included, over a temporary database and the static
+// provider fixture, with trackedAddress already tracked
+func newTestServer(t *testing.T, cfg *config.Config) (*mux.Router, *handlers.Drain) {
+	t.Helper()
+	return newTestServerWithClient(t, cfg, newStaticClient(t))
+}
+
+// newStaticClient loads the static provider fixture
+func newStaticClient(t *testing.T) *clients.StaticClient {
+	t.Helper()
+
+	client, err := clients.NewStaticClient("../../internal/clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+	return client
+}
+
+// newTestServerWithClient is newTestServer over the given provider client
+func newTestServerWithClient(t *testing.T, cfg *config.Config, client clients.BitcoinClient) (*mux.Router, *handlers.Drain) {
+	t.Helper()
+
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = requestid.DefaultHeader
+	}
+
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	drain := handlers.NewDrain()
+	handler := handlers.NewBitcoinHandler(services.NewBitcoinService(repo, client),
+		handlers.WithDrain(drain),
+		handlers.WithAdminToken("secret"),
+	)
+	router := setupRoutes(handler, drain, cfg, nil)
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+trackedAddress+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	return router, drain
+}
+
+// serve sends a request to router and returns the recorded response
+func serve(router http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDrainOutlivesRequestTimeout(t *testing.T) {
+	router, drain := newTestServer(t, &config.Config{RequestTimeout: 20 * time.Millisecond})
+
+	// A request that ignores its deadline stays in flight until released
+	started, release := make(chan struct{}), make(chan struct{})
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	slowDone := make(chan struct{})
+	go func() {
+		serve(router, "GET", "/slow", "")
+		close(slowDone)
+	}()
+	<-started
+
+	// A watch long-poll would otherwise hold the drain for its full timeout
+	watched := make(chan *httptest.ResponseRecorder)
+	go func() { watched <- serve(router, "GET", "/addresses/"+trackedAddress+"/watch?timeout=5m", "") }()
+	for drain.InFlight() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The drain waits well past the request deadline and still answers
+	rec := serve(router, "POST", "/admin/drain?timeout=100ms", "", "Authorization", "Bearer secret")
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"drained":false`) {
+		t.Errorf("Expected 202 while the slow request is in flight, got %d: %s", rec.Code, rec.Body)
+	}
+
+	select {
+	case rec := <-watched:
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Expected the watch to be answered 304 when draining starts, got %d: %s", rec.Code, rec.Body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Draining did not wake the watch long-poll")
+	}
+
+	close(release)
+	<-slowDone
+	rec = serve(router, "POST", "/admin/drain?timeout=1s", "", "Authorization", "Bearer secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"drained":true`) {
+		t.Errorf("Expected 200 once nothing is in flight, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// stallingClient serves the static fixture until stall is set, then holds transaction lookups until the request is cancelled
+type stallingClient struct {
+	*clients.StaticClient
+	stall atomic.Bool
+}
+
+func (c *stallingClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	if c.stall.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.StaticClient.GetTransactions(ctx, address, limit)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	client := &stallingClient{StaticClient: newStaticClient(t)}
+	router, _ := newTestServerWithClient(t, &config.Config{RequestTimeout: 50 * time.Millisecond}, client)
+	client.stall.Store(true)
+
+	// A provider call that outlives REQUEST_TIMEOUT is abandoned and answered 504
+	rec := serve(router, "POST", "/addresses/"+trackedAddress+"/sync", "")
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504 once the request deadline passes, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// The watch long-poll runs to its own timeout instead
+	start := time.Now()
+	rec = serve(router, "GET", "/addresses/"+trackedAddress+"/watch?timeout=200ms", "")
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected the watch to time out with 304, got %d: %s", rec.Code, rec.Body)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the watch to outlive the request deadline, returned after %s", elapsed)
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(tracing.InstrumentationName)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/addresses/{address}", func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("Expected the handler's context to carry the server span")
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	router.Use(tracingMiddleware(tracer))
+
+	serve(router, "GET", "/addresses/"+trackedAddress, "", "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected one server span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /addresses/{address}" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Expected a server span named after the route, got %s (%s)", span.Name(), span.SpanKind())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to join the caller's trace, got trace %s parent %s", span.SpanContext().TraceID(), span.Parent().SpanID())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected a 500 to mark the span failed, got %+v", span.Status())
+	}
+}
+
+func TestCORSPreflightAllowsRequestHeaders(t *testing.T) {
+	cfg := &config.Config{RequestIDHeader: "X-Correlation-ID"}
+	router, _ := newTestServer(t, cfg)
+	handler := corsHandler(router, 0, cfg.RequestIDHeader)
+
+	for _, header := range []string{"Content-Type", "Accept", "Authorization", "X-Raw-Response", "X-Correlation-ID", "traceparent"} {
+		rec := serve(handler, "OPTIONS", "/addresses", "",
+			"Origin", "https://dashboard.example",
+			"Access-Control-Request-Method", "GET",
+			"Access-Control-Request-Headers", strings.ToLower(header),
+		)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected preflight for %s to answer 200, got %d: %s", header, rec.Code, rec.Body)
+		}
+
+		allowed := strings.Split(rec.Header().Get("Access-Control-Allow-Headers"), ", ")
+		if !slices.ContainsFunc(allowed, func(h string) bool { return strings.EqualFold(h, header) }) {
+			t.Errorf("Expected preflight to allow %s, got %q", header, allowed)
+		}
+	}
+}
+
+func TestCORSHandler(t *testing.T) {
+	router, _ := newTestServer(t, &config.Config{})
+	handler := corsHandler(router, 10*time.Minute, requestid.DefaultHeader)
+
+	testCases := []struct {
+		path        string
+		wantStatus  int
+		wantMethods string
+	}{
+		{"/addresses", http.StatusOK, "GET, POST, OPTIONS"},
+		{"/addresses/" + trackedAddress, http.StatusOK, "GET, PUT, DELETE, OPTIONS"},
+		{"/addresses/" + trackedAddress + "/balance", http.StatusOK, "GET, OPTIONS"},
+		// Paths nothing is registered for are not approved
+		{"/nowhere", http.StatusNotFound, ""},
+	}
+	for _, tc := range testCases {
+		rec := serve(handler, "OPTIONS", tc.path, "", "Origin", "https://dashboard.example", "Access-Control-Request-Method", "GET")
+		if rec.Code != tc.wantStatus || rec.Header().Get("Access-Control-Allow-Methods") != tc.wantMethods {
+			t.Errorf("Preflight for %s = %d allowing %q; want %d allowing %q",
+				tc.path, rec.Code, rec.Header().Get("Access-Control-Allow-Methods"), tc.wantStatus, tc.wantMethods)
+		}
+		wantMaxAge := ""
+		if tc.wantMethods != "" {
+			wantMaxAge = "600"
+		}
+		if got := rec.Header().Get("Access-Control-Max-Age"); got != wantMaxAge {
+			t.Errorf("Preflight for %s sent Access-Control-Max-Age %q; want %q", tc.path, got, wantMaxAge)
+		}
+	}
+
+	// Any origin may call the API, and requests without one are served the same way
+	for _, origin := range []string{"https://dashboard.example", "http://localhost:3000", ""} {
+		rec := serve(handler, "GET", "/addresses/"+trackedAddress, "", "Origin", origin)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("GET from origin %q = %d with Access-Control-Allow-Origin %q; want 200 and *",
+				origin, rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+
+	// A zero max age leaves caching to the browser
+	rec := serve(corsHandler(router, 0, requestid.DefaultHeader), "OPTIONS", "/addresses", "", "Origin", "https://dashboard.example")
+	if _, ok := rec.Header()["Access-Control-Max-Age"]; ok || rec.Code != http.StatusOK {
+		t.Errorf("Expected a 200 preflight without Access-Control-Max-Age, got %d with %q", rec.Code, rec.Header().Get("Access-Control-Max-Age"))
+	}
+}
+
+func TestStartBackgroundSync(t *testing.T) {
+	for _, syncOnStartup := range []bool{true, false} {
+		repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("NewSQLiteRepository failed: %v", err)
+		}
+		defer repo.Close()
+		if _, err := repo.AddAddress(models.Address{Address: trackedAddress, SyncEnabled: true}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+		service := services.NewBitcoinService(repo, newStaticClient(t))
+		synced := func() bool {
+			address, err := repo.GetAddress(trackedAddress)
+			if err != nil {
+				t.Fatalf("GetAddress failed: %v", err)
+			}
+			return address.LastSynced != nil
+		}
+
+		// The interval is long enough that only a startup sync can run during the test
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			startBackgroundSync(ctx, service, time.Hour, syncOnStartup)
+			close(stopped)
+		}()
+
+		deadline := time.Now().Add(200 * time.Millisecond)
+		if syncOnStartup {
+			deadline = time.Now().Add(5 * time.Second)
+		}
+		for !synced() && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Background sync did not stop when its context ended")
+		}
+
+		if got := synced(); got != syncOnStartup {
+			t.Errorf("syncOnStartup=%v: startup sync ran = %v", syncOnStartup, got)
+		}
+	}
+}
+
+// writeKeyPair writes a self-signed certificate valid from notBefore to notAfter and its key to dir,
+// returning their paths
+func writeKeyPair(t *testing.T, dir, name string, notBefore, notAfter time.Time) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	validCert, validKey := writeKeyPair(t, dir, "valid", now.Add(-time.Hour), now.Add(time.Hour))
+	_, otherKey := writeKeyPair(t, dir, "other", now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCert, expiredKey := writeKeyPair(t, dir, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	futureCert, futureKey := writeKeyPair(t, dir, "future", now.Add(time.Hour), now.Add(2*time.Hour))
+
+	tlsConfig, err := loadTLSConfig(validCert, validKey, now)
+	if err != nil {
+		t.Fatalf("loadTLSConfig failed: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 || strings.Join(tlsConfig.NextProtos, ",") != "h2,http/1.1" {
+		t.Errorf("Expected the certificate and HTTP/2 negotiation, got %d certificates and protocols %v",
+			len(tlsConfig.Certificates), tlsConfig.NextProtos)
+	}
+
+	testCases := []struct {
+		name          string
+		cert, key     string
+		wantErrSubstr string
+	}{
+		{"mismatched key", validCert, otherKey, "failed to load certificate"},
+		{"missing file", filepath.Join(dir, "missing.crt"), validKey, "failed to load certificate"},
+		{"expired", expiredCert, expiredKey, "expired at"},
+		{"not yet valid", futureCert, futureKey, "is not valid before"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadTLSConfig(tc.cert, tc.key, now)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Errorf("Expected an error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Package address provides Bitcoin address inspection helpers shared across the application
+package address
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type identifies the script type an address pays to
+type Type string
+
+// Supported address types
+const (
+	TypeP2PKH   Type = "p2pkh"
+	TypeP2SH    Type = "p2sh"
+	TypeP2WPKH  Type = "p2wpkh"
+	TypeP2WSH   Type = "p2wsh"
+	TypeP2TR    Type = "p2tr"
+	TypeUnknown Type = "unknown"
+)
+
+// knownTypes lists every concrete type DetectType can return
+var knownTypes = []Type{TypeP2PKH, TypeP2SH, TypeP2WPKH, TypeP2WSH, TypeP2TR}
+
+// DetectType determines the address type from its prefix and length, on any network
+func DetectType(addr string) Type {
+	_, t := classify(addr)
+	return t
+}
+
+// ParseType converts a configuration value into a known address type
+func ParseType(value string) (Type, error) {
+	t := Type(strings.ToLower(strings.TrimSpace(value)))
+	for _, known := range knownTypes {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown address type: %q", value)
+}
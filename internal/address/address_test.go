@@ -0,0 +1,126 @@
+package address
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectType(t *testing.T) {
+	testCases := []struct {
+		address string
+		want    Type
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", TypeP2PKH},
+		{"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", TypeP2SH},
+		{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", TypeP2WPKH},
+		{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", TypeP2WSH},
+		{"bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", TypeP2TR},
+		{"invalid", TypeUnknown},
+	}
+
+	for _, tc := range testCases {
+		if got := DetectType(tc.address); got != tc.want {
+			t.Errorf("DetectType(%s) = %s; want %s", tc.address, got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		address string
+		network Network
+		want    Type
+		wantErr error
+	}{
+		{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", Mainnet, TypeP2WPKH, nil}, // Bech32
+		{"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", Mainnet, TypeP2SH, nil},           // P2SH
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Mainnet, TypeP2PKH, nil},          // P2PKH
+		{"invalid", Mainnet, TypeUnknown, ErrInvalid},                            // Too short
+		{"", Mainnet, TypeUnknown, ErrInvalid},                                   // Empty
+		{"2N1234567890abcdef", Mainnet, TypeUnknown, ErrInvalid},                 // Wrong prefix
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0", Mainnet, TypeUnknown, ErrInvalid}, // Not base58
+		{"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Testnet, TypeP2WPKH, nil},
+		{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", Testnet, TypeP2PKH, nil},
+		{"2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", Testnet, TypeP2SH, nil},
+		{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", Regtest, TypeP2PKH, nil}, // Regtest shares legacy prefixes
+		{"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Mainnet, TypeUnknown, ErrWrongNetwork},
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Testnet, TypeUnknown, ErrWrongNetwork},
+	}
+
+	for _, tc := range testCases {
+		got, err := Validate(tc.address, tc.network)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("Validate(%s, %s) error = %v; want %v", tc.address, tc.network, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("Validate(%s, %s) = %s; want %s", tc.address, tc.network, got, tc.want)
+		}
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	testCases := []struct {
+		name    string
+		address string
+		network Network
+		want    Type
+	}{
+		{"P2PKH", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Mainnet, TypeP2PKH},
+		{"P2PKH bad checksum", "1A1zP1eP5QGefi2DMPTfTL5SLmv7Divfff", Mainnet, TypeUnknown},
+		{"P2SH", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", Mainnet, TypeP2SH},
+		{"P2SH bad checksum", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDce", Mainnet, TypeUnknown},
+		{"P2WPKH", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", Mainnet, TypeP2WPKH},
+		{"P2WPKH bad checksum", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs4", Mainnet, TypeUnknown},
+		{"P2WPKH with bech32m checksum", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", Mainnet, TypeUnknown},
+		{"P2WSH", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", Mainnet, TypeP2WSH},
+		{"P2WSH bad checksum", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv4", Mainnet, TypeUnknown},
+		{"P2TR", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", Mainnet, TypeP2TR},
+		{"P2TR bad checksum", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3298", Mainnet, TypeUnknown},
+		{"P2TR with bech32 checksum", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", Mainnet, TypeUnknown},
+		{"testnet P2PKH", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", Testnet, TypeP2PKH},
+		{"testnet P2PKH bad checksum", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfm", Testnet, TypeUnknown},
+		{"testnet P2SH", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", Testnet, TypeP2SH},
+		{"testnet P2SH bad checksum", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vd", Testnet, TypeUnknown},
+		{"testnet P2WPKH", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Testnet, TypeP2WPKH},
+		{"testnet P2WPKH bad checksum", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsy", Testnet, TypeUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Validate(tc.address, tc.network)
+			if got != tc.want {
+				t.Errorf("Validate(%s) = %s; want %s", tc.address, got, tc.want)
+			}
+			if tc.want == TypeUnknown && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate(%s) error = %v; want ErrInvalid", tc.address, err)
+			}
+			if tc.want != TypeUnknown && err != nil {
+				t.Errorf("Validate(%s) error = %v", tc.address, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		address string
+		want    string
+	}{
+		{"BC1Q0SG9RDST255GTLDSMCF8RK0764AVQY2H2KSQS5", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"},
+		{"TB1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KXPJZSX", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"},
+		{" bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5 ", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"},
+		{"bc1Q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "bc1Q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"}, // Mixed case is left for validation to reject
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},                 // Base58 is case-sensitive
+	}
+
+	for _, tc := range testCases {
+		if got := Normalize(tc.address); got != tc.want {
+			t.Errorf("Normalize(%q) = %q; want %q", tc.address, got, tc.want)
+		}
+	}
+
+	if _, err := Validate(Normalize("bc1Q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"), Mainnet); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected mixed-case bech32 to be invalid, got %v", err)
+	}
+}
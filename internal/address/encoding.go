@@ -0,0 +1,121 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// decodeBase58Check decodes a Base58Check string and returns the payload, version byte included,
+// after verifying its 4-byte checksum
+func decodeBase58Check(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	decoded := append(make([]byte, zeros), n.Bytes()...)
+	if len(decoded) < 5 {
+		return nil, errors.New("base58 payload too short")
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(checksum, second[:4]) {
+		return nil, errors.New("base58 checksum mismatch")
+	}
+
+	return payload, nil
+}
+
+// bech32Charset maps 5-bit values to bech32 characters
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Checksum constants for witness version 0 (bech32, BIP173) and later versions (bech32m, BIP350)
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bech32Polymod computes the bech32 checksum polynomial over 5-bit values
+func bech32Polymod(values []byte) uint32 {
+	generators := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generators {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// decodeWitnessProgram decodes a lowercase segwit address and returns its witness program
+// after verifying the checksum variant required by its witness version
+func decodeWitnessProgram(addr string) ([]byte, error) {
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 || len(addr)-sep-1 < 7 {
+		return nil, errors.New("malformed bech32 string")
+	}
+	hrp, data := addr[:sep], addr[sep+1:]
+
+	values := make([]byte, len(data))
+	for i := range data {
+		v := strings.IndexByte(bech32Charset, data[i])
+		if v < 0 {
+			return nil, fmt.Errorf("invalid bech32 character %q", data[i])
+		}
+		values[i] = byte(v)
+	}
+
+	expanded := make([]byte, 0, 2*len(hrp)+1+len(values))
+	for i := range hrp {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := range hrp {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	expanded = append(expanded, values...)
+
+	version := values[0]
+	want := uint32(bech32Const)
+	if version > 0 {
+		want = bech32mConst
+	}
+	if bech32Polymod(expanded) != want {
+		return nil, errors.New("bech32 checksum mismatch")
+	}
+
+	// Regroup the 5-bit values between the version and the checksum into bytes
+	var program []byte
+	acc, bitCount := 0, 0
+	for _, v := range values[1 : len(values)-6] {
+		acc = acc<<5 | int(v)
+		bitCount += 5
+		if bitCount >= 8 {
+			bitCount -= 8
+			program = append(program, byte(acc>>bitCount))
+		}
+	}
+	if bitCount >= 5 || acc&(1<<bitCount-1) != 0 {
+		return nil, errors.New("invalid bech32 padding")
+	}
+
+	return program, nil
+}
@@ -0,0 +1,132 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ErrSignatureMismatch is returned when a well-formed signature was not made by the address's key
+var ErrSignatureMismatch = errors.New("signature does not match address")
+
+// messageMagic prefixes every message signed with the standard Bitcoin message signing scheme
+const messageMagic = "Bitcoin Signed Message:\n"
+
+// VerifyMessage checks a base64 compact signature of message, as produced by Bitcoin Core's
+// signmessage or a wallet's "sign message", against a P2PKH or P2WPKH address.
+// A signature made by a different key returns ErrSignatureMismatch.
+func VerifyMessage(addr, message, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != 65 {
+		return errors.New("signature must be 65 bytes of base64")
+	}
+
+	// The header encodes the recovery id and the key/address kind (BIP137): 27-30 uncompressed P2PKH,
+	// 31-34 compressed P2PKH, 35-38 P2SH-P2WPKH, 39-42 P2WPKH; the kinds beyond 31 all use compressed keys
+	header := sig[0]
+	if header < 27 || header > 42 {
+		return fmt.Errorf("invalid signature header byte %d", header)
+	}
+	recID := (header - 27) & 3
+	compressed := header >= 31
+	nestedSegwit := header >= 35 && header <= 38
+	nativeSegwit := header >= 39
+
+	// Signers produce low-S signatures; the high-S twin of a signature is a malleated copy
+	var s btcec.ModNScalar
+	if s.SetByteSlice(sig[33:]) || s.IsOverHalfOrder() {
+		return errors.New("invalid signature: s is not in the lower half of the curve order")
+	}
+
+	// RecoverCompact only knows the P2PKH headers, so pass the kind as one of those
+	compact := append([]byte{27 + recID}, sig[1:]...)
+	if compressed {
+		compact[0] += 4
+	}
+	key, _, err := ecdsa.RecoverCompact(compact, messageHash(message))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	serialized := key.SerializeUncompressed()
+	if compressed {
+		serialized = key.SerializeCompressed()
+	}
+	keyHash := hash160(serialized)
+
+	var want []byte
+	switch DetectType(addr) {
+	case TypeP2PKH:
+		if nestedSegwit || nativeSegwit {
+			return fmt.Errorf("signature header byte %d is for a segwit address, not %s", header, TypeP2PKH)
+		}
+		want, err = decodeBase58Check(addr)
+		if err == nil {
+			want = want[1:]
+		}
+	case TypeP2WPKH:
+		// Wallets that predate BIP137 sign segwit addresses with the compressed P2PKH header
+		if !compressed {
+			return errors.New("segwit addresses require a signature from a compressed key")
+		}
+		if nestedSegwit {
+			return fmt.Errorf("signature header byte %d is for a P2SH-wrapped address, not %s", header, TypeP2WPKH)
+		}
+		want, err = decodeWitnessProgram(addr)
+	default:
+		return fmt.Errorf("message verification is only supported for %s and %s addresses", TypeP2PKH, TypeP2WPKH)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	if !bytes.Equal(want, keyHash) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// messageHash returns the double SHA-256 of the magic-prefixed message that signers commit to
+func messageHash(message string) []byte {
+	var buf bytes.Buffer
+	writeCompactSize(&buf, uint64(len(messageMagic)))
+	buf.WriteString(messageMagic)
+	writeCompactSize(&buf, uint64(len(message)))
+	buf.WriteString(message)
+
+	first := sha256.Sum256(buf.Bytes())
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// writeCompactSize writes n as a Bitcoin variable-length integer
+func writeCompactSize(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(n)))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(0xff)
+		buf.Write(binary.LittleEndian.AppendUint64(nil, n))
+	}
+}
+
+// hash160 returns RIPEMD-160(SHA-256(data)), the hash committed to by P2PKH and P2WPKH addresses
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sum[:])
+	return h.Sum(nil)
+}
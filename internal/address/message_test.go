@@ -0,0 +1,162 @@
+package address
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+func TestHash160(t *testing.T) {
+	// The compressed public key of private key 1, committed to by bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4
+	key, err := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	if got, want := hex.EncodeToString(hash160(key)), "751e76e8199196d454941c45d1b3a323f1433bd6"; got != want {
+		t.Errorf("hash160() = %s; want %s", got, want)
+	}
+}
+
+// privateKey returns the private key with scalar d
+func privateKey(d byte) *btcec.PrivateKey {
+	key, _ := btcec.PrivKeyFromBytes([]byte{d})
+	return key
+}
+
+// signMessage produces a compact signature of message with key, using the header offset for the
+// address kind (0 uncompressed P2PKH, 4 compressed P2PKH, 8 P2SH-P2WPKH, 12 P2WPKH)
+func signMessage(t *testing.T, key *btcec.PrivateKey, message string, kind byte) string {
+	t.Helper()
+
+	sig := ecdsa.SignCompact(key, messageHash(message), kind != 0)
+	if kind != 0 {
+		// SignCompact already set the compressed P2PKH header
+		sig[0] += kind - 4
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// highS returns the malleated twin of a compact signature: s replaced by N - s, with the recovery
+// id flipped so it still recovers the same key
+func highS(t *testing.T, signature string) string {
+	t.Helper()
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	var s btcec.ModNScalar
+	s.SetByteSlice(sig[33:])
+	s.Negate()
+	s.PutBytesUnchecked(sig[33:])
+	sig[0] ^= 1
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestVerifyMessage(t *testing.T) {
+	// Private key 1: its public key is the generator, whose addresses are well known
+	one := privateKey(1)
+	const (
+		uncompressedP2PKH = "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm"
+		compressedP2PKH   = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+		p2wpkh            = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	)
+	message := "I own this address"
+	errKind := errors.New("signature kind does not match address")
+
+	// Published example signature made with an independent implementation
+	const (
+		exampleAddress   = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+		exampleMessage   = "This is an example of a signed message."
+		exampleSignature = "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk="
+	)
+
+	testCases := []struct {
+		name      string
+		address   string
+		signature string
+		message   string
+		wantErr   error
+	}{
+		{"published example", exampleAddress, exampleSignature, exampleMessage, nil},
+		{"uncompressed P2PKH", uncompressedP2PKH, signMessage(t, one, message, 0), message, nil},
+		{"compressed P2PKH", compressedP2PKH, signMessage(t, one, message, 4), message, nil},
+		{"P2WPKH", p2wpkh, signMessage(t, one, message, 12), message, nil},
+		{"P2WPKH with P2PKH header", p2wpkh, signMessage(t, one, message, 4), message, nil},
+		{"different message", compressedP2PKH, signMessage(t, one, message, 4), "I own another address", ErrSignatureMismatch},
+		{"different key", compressedP2PKH, signMessage(t, privateKey(2), message, 4), message, ErrSignatureMismatch},
+		{"different key P2WPKH", p2wpkh, signMessage(t, privateKey(2), message, 12), message, ErrSignatureMismatch},
+		{"different key uncompressed", uncompressedP2PKH, signMessage(t, privateKey(2), message, 0), message, ErrSignatureMismatch},
+		{"P2PKH with P2WPKH header", compressedP2PKH, signMessage(t, one, message, 12), message, errKind},
+		{"P2PKH with P2SH-P2WPKH header", compressedP2PKH, signMessage(t, one, message, 8), message, errKind},
+		{"P2WPKH with P2SH-P2WPKH header", p2wpkh, signMessage(t, one, message, 8), message, errKind},
+		{"P2WPKH with uncompressed header", p2wpkh, signMessage(t, one, message, 0), message, errKind},
+		{"compression mismatch", uncompressedP2PKH, signMessage(t, one, message, 4), message, ErrSignatureMismatch},
+		{"bad checksum", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMJ", signMessage(t, one, message, 4), message, ErrInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyMessage(tc.address, tc.message, tc.signature)
+			if tc.wantErr == errKind {
+				// A header for another address kind is rejected before comparing keys
+				if err == nil || errors.Is(err, ErrSignatureMismatch) {
+					t.Errorf("VerifyMessage() = %v; want a signature kind error", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("VerifyMessage() = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// compactSignature encodes a compact signature from its header and hex r and s values
+func compactSignature(t *testing.T, header byte, r, s string) string {
+	t.Helper()
+
+	rs, err := hex.DecodeString(r + s)
+	if err != nil || len(rs) != 64 {
+		t.Fatalf("invalid r and s: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(append([]byte{header}, rs...))
+}
+
+func TestVerifyMessageRejectsMalformedSignatures(t *testing.T) {
+	const (
+		one   = "0000000000000000000000000000000000000000000000000000000000000001"
+		order = "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"
+		// 5 is not the x coordinate of any curve point
+		offCurve = "0000000000000000000000000000000000000000000000000000000000000005"
+	)
+
+	testCases := map[string]string{
+		"not base64":      "not a signature!",
+		"too short":       base64.StdEncoding.EncodeToString(make([]byte, 64)),
+		"too long":        base64.StdEncoding.EncodeToString(make([]byte, 66)),
+		"bad header":      base64.StdEncoding.EncodeToString(append([]byte{50}, make([]byte, 64)...)),
+		"zero r and s":    base64.StdEncoding.EncodeToString(append([]byte{31}, make([]byte, 64)...)),
+		"r out of range":  compactSignature(t, 31, order, one),
+		"s out of range":  compactSignature(t, 31, one, order),
+		"r off the curve": compactSignature(t, 31, offCurve, one),
+		"high S":          highS(t, signMessage(t, privateKey(1), "hello", 4)),
+		"taproot address": "",
+	}
+
+	for name, signature := range testCases {
+		addr := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+		if name == "taproot address" {
+			addr = "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297"
+			signature = signMessage(t, privateKey(1), "hello", 12)
+		}
+		err := VerifyMessage(addr, "hello", signature)
+		if err == nil || errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("%s: VerifyMessage() = %v; want a format error", name, err)
+		}
+	}
+}
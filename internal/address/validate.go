@@ -0,0 +1,181 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Network identifies the Bitcoin network an address belongs to
+type Network string
+
+// Supported networks
+const (
+	Mainnet Network = "mainnet"
+	Testnet Network = "testnet"
+	Regtest Network = "regtest"
+)
+
+// Validation errors returned by Validate
+var (
+	ErrInvalid      = errors.New("invalid Bitcoin address")
+	ErrWrongNetwork = errors.New("address belongs to a different network")
+)
+
+// ParseNetwork converts a configuration value into a Network
+func ParseNetwork(value string) (Network, error) {
+	switch n := Network(strings.ToLower(strings.TrimSpace(value))); n {
+	case Mainnet, Testnet, Regtest:
+		return n, nil
+	default:
+		return "", fmt.Errorf("unknown network: %q", value)
+	}
+}
+
+// segwitPrefixes maps bech32 human-readable parts to their network
+var segwitPrefixes = []struct {
+	hrp     string
+	network Network
+}{
+	{"bcrt", Regtest},
+	{"bc", Mainnet},
+	{"tb", Testnet},
+}
+
+// base58Alphabet is the character set of legacy addresses
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Validate checks that addr is well-formed for network and returns its type.
+// Errors wrap ErrInvalid or ErrWrongNetwork.
+func Validate(addr string, network Network) (Type, error) {
+	addrNetwork, addrType := classify(addr)
+	if addrType == TypeUnknown {
+		return TypeUnknown, fmt.Errorf("%w: %q", ErrInvalid, addr)
+	}
+	if err := verifyEncoding(addr, addrNetwork, addrType); err != nil {
+		return TypeUnknown, fmt.Errorf("%w: %q: %v", ErrInvalid, addr, err)
+	}
+
+	// Regtest reuses the testnet version bytes for legacy addresses
+	legacy := addrType == TypeP2PKH || addrType == TypeP2SH
+	if addrNetwork != network && !(legacy && addrNetwork == Testnet && network == Regtest) {
+		return TypeUnknown, fmt.Errorf("%w: %s is a %s address, expected %s", ErrWrongNetwork, addr, addrNetwork, network)
+	}
+
+	return addrType, nil
+}
+
+// legacyVersions maps the version byte of a Base58Check address to the network and type it encodes
+var legacyVersions = map[byte]struct {
+	network  Network
+	addrType Type
+}{
+	0x00: {Mainnet, TypeP2PKH},
+	0x05: {Mainnet, TypeP2SH},
+	0x6f: {Testnet, TypeP2PKH},
+	0xc4: {Testnet, TypeP2SH},
+}
+
+// legacyPayloadLength is the length of a Base58Check address payload: a version byte and a 20-byte hash
+const legacyPayloadLength = 21
+
+// verifyEncoding decodes an address classified by its prefix and checks its checksum, catching typos
+// that keep the prefix and length intact. Segwit addresses must use the checksum variant of their
+// witness version: bech32 for v0 and bech32m for taproot.
+func verifyEncoding(addr string, network Network, addrType Type) error {
+	if addrType != TypeP2PKH && addrType != TypeP2SH {
+		_, err := decodeWitnessProgram(addr)
+		return err
+	}
+
+	payload, err := decodeBase58Check(addr)
+	if err != nil {
+		return err
+	}
+	version, ok := legacyVersions[payload[0]]
+	if len(payload) != legacyPayloadLength || !ok || version.network != network || version.addrType != addrType {
+		return errors.New("unexpected base58 payload")
+	}
+	return nil
+}
+
+// maxAddressLength is the longest string any address format can produce (the bech32 limit)
+const maxAddressLength = 90
+
+// Plausible reports whether s is short enough and uses only characters that can appear in an address.
+// It is a cheap sanity check for untrusted input, not validation: use Validate for that.
+func Plausible(s string) bool {
+	if s == "" || len(s) > maxAddressLength {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize returns the canonical form used to store and look up addresses.
+// BIP173 allows bech32 addresses to be written entirely in uppercase, so those are lowercased;
+// mixed-case input is returned unchanged so validation rejects it.
+func Normalize(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if addr != strings.ToUpper(addr) {
+		return addr
+	}
+
+	lower := strings.ToLower(addr)
+	for _, prefix := range segwitPrefixes {
+		if strings.HasPrefix(lower, prefix.hrp+"1") {
+			return lower
+		}
+	}
+	return addr
+}
+
+// classify determines the network and type of an address from its prefix, length and character set
+func classify(addr string) (Network, Type) {
+	for _, prefix := range segwitPrefixes {
+		if strings.HasPrefix(addr, prefix.hrp+"1") {
+			return prefix.network, classifySegwit(addr[len(prefix.hrp)+1:])
+		}
+	}
+
+	if len(addr) < 26 || len(addr) > 35 || strings.Trim(addr, base58Alphabet) != "" {
+		return "", TypeUnknown
+	}
+
+	switch addr[0] {
+	case '1':
+		return Mainnet, TypeP2PKH
+	case '3':
+		return Mainnet, TypeP2SH
+	case 'm', 'n':
+		return Testnet, TypeP2PKH
+	case '2':
+		return Testnet, TypeP2SH
+	}
+
+	return "", TypeUnknown
+}
+
+// classifySegwit determines the type from the bech32 data part following the separator
+func classifySegwit(data string) Type {
+	if strings.Trim(data, bech32Charset) != "" {
+		return TypeUnknown
+	}
+
+	// Witness v0 programs are 20 bytes (P2WPKH) or 32 bytes (P2WSH); v1 is taproot
+	switch {
+	case strings.HasPrefix(data, "q") && len(data) == 39:
+		return TypeP2WPKH
+	case strings.HasPrefix(data, "q") && len(data) == 59:
+		return TypeP2WSH
+	case strings.HasPrefix(data, "p") && len(data) == 59:
+		return TypeP2TR
+	}
+
+	return TypeUnknown
+}
@@ -2,18 +2,45 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ihladush/bitcoin/internal/address"
 	"github.com/ihladush/bitcoin/internal/models"
 )
 
 // BlockchairClient interacts with Blockchair API
 type BlockchairClient struct {
 	baseURL    string
+	network    address.Network
 	httpClient *http.Client
+	observer   RequestObserver
+	cache      *ResponseCache
+	// apiKey is sent as the key query parameter of every request when set
+	apiKey string
+	// maxAttempts bounds how many times a request is sent when the provider answers 429 or a
+	// transient 5xx, and baseDelay is the wait before the first retry, doubled for each one after
+	maxAttempts int
+	baseDelay   time.Duration
+	// tip is the best block confirmations are counted from
+	tip chainTipCache
+}
+
+// chainTipTTL is how long a fetched chain tip is reused to count confirmations
+const chainTipTTL = 30 * time.Second
+
+// chainTipCache holds the most recently fetched chain tip
+type chainTipCache struct {
+	mu        sync.Mutex
+	tip       *models.ChainTip
+	fetchedAt time.Time
 }
 
 // BlockchairAddressResponse represents the response from Blockchair address API
@@ -30,60 +57,299 @@ type BlockchairAddressData struct {
 		Spent                 int64  `json:"spent"`
 		OutputCount           int    `json:"output_count"`
 		UnspentOutputCount    int    `json:"unspent_output_count"`
-		FirstSeenReceiving    string `json:"first_seen_receiving"`
-		LastSeenReceiving     string `json:"last_seen_receiving"`
-		FirstSeenSpending     string `json:"first_seen_spending"`
-		LastSeenSpending      string `json:"last_seen_spending"`
+		FirstSeenReceiving    *blockchairTime `json:"first_seen_receiving"`
+		LastSeenReceiving     *blockchairTime `json:"last_seen_receiving"`
+		FirstSeenSpending     *blockchairTime `json:"first_seen_spending"`
+		LastSeenSpending      *blockchairTime `json:"last_seen_spending"`
 		TransactionCount      int    `json:"transaction_count"`
 	} `json:"address"`
+	// Transactions are the most recent ones, mempool transactions first
+	Transactions []BlockchairTransaction `json:"transactions"`
 }
 
-// BlockchairTransactionsResponse represents the response from Blockchair transactions API
+// BlockchairTransactionsResponse represents the response from the Blockchair address dashboard
+// requested with transaction_details=true
 type BlockchairTransactionsResponse struct {
-	Data struct {
+	Data map[string]struct {
 		Transactions []BlockchairTransaction `json:"transactions"`
 	} `json:"data"`
 }
 
-// BlockchairTransaction represents a transaction from Blockchair API
+// BlockchairTransaction represents a transaction from Blockchair API.
+// Mempool transactions are reported with a block_id of -1.
 type BlockchairTransaction struct {
-	BlockID         int64     `json:"block_id"`
-	Hash            string    `json:"hash"`
-	Time            time.Time `json:"time"`
-	BalanceChange   int64     `json:"balance_change"`
-	InputTotalValue int64     `json:"input_total_value"`
-	OutputTotalValue int64    `json:"output_total_value"`
+	BlockID       int64          `json:"block_id"`
+	Hash          string         `json:"hash"`
+	Time          blockchairTime `json:"time"`
+	BalanceChange int64          `json:"balance_change"`
+}
+
+// blockchairTimeLayout is the UTC timestamp format used throughout the Blockchair API
+const blockchairTimeLayout = "2006-01-02 15:04:05"
+
+// blockchairTime decodes Blockchair timestamps
+type blockchairTime struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Blockchair timestamp, which carries no zone and is always UTC
+func (t *blockchairTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to decode time: %w", err)
+	}
+
+	parsed, err := time.Parse(blockchairTimeLayout, value)
+	if err != nil {
+		return fmt.Errorf("failed to parse time %q: %w", value, err)
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+// ptr returns the timestamp as an optional time, nil when Blockchair reported none
+func (t *blockchairTime) ptr() *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
 }
 
 // BitcoinClient interface defines the contract for Bitcoin blockchain clients
 type BitcoinClient interface {
-	GetBalance(address string) (*models.Balance, error)
-	GetTransactions(address string, limit int) ([]models.Transaction, error)
-	IsValidAddress(address string) bool
+	GetBalance(ctx context.Context, address string) (*models.Balance, error)
+	GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error)
+	IsValidAddress(ctx context.Context, address string) bool
+	// Capabilities reports which optional features the provider supports, so unsupported
+	// endpoints can answer 501 and frontends can hide them
+	Capabilities() models.ClientCapabilities
+}
+
+// AddressStatsProvider is implemented by clients that report provider-side address statistics.
+// GetAddressStats returns nil when the provider has no statistics for the address.
+type AddressStatsProvider interface {
+	GetAddressStats(ctx context.Context, address string) (*models.AddressStats, error)
+}
+
+// TransactionImporter is implemented by clients that can map transactions captured from their
+// provider's raw JSON, so stored data can be replayed without live provider access
+type TransactionImporter interface {
+	ParseTransactions(address string, raw []byte) ([]models.Transaction, error)
+}
+
+// ErrImportUnsupported is returned when the provider cannot map raw transaction JSON
+var ErrImportUnsupported = errors.New("provider does not support transaction import")
+
+// ChainTipProvider is implemented by clients that report the current best block.
+// GetChainTip returns nil when the provider has no chain information.
+type ChainTipProvider interface {
+	GetChainTip(ctx context.Context) (*models.ChainTip, error)
+}
+
+// BlockchairStatsResponse represents the blockchain stats response from Blockchair API
+type BlockchairStatsResponse struct {
+	Data struct {
+		BestBlockHeight int            `json:"best_block_height"`
+		BestBlockHash   string         `json:"best_block_hash"`
+		BestBlockTime   blockchairTime `json:"best_block_time"`
+	} `json:"data"`
+}
+
+// CoinbaseDetector is implemented by clients that can tell which transactions are coinbase (mining reward)
+// transactions when their transaction listing does not say so
+type CoinbaseDetector interface {
+	DetectCoinbase(ctx context.Context, hashes []string) (map[string]bool, error)
+}
+
+// BlockchairTransactionDetailsResponse represents the transactions dashboard response from Blockchair API
+type BlockchairTransactionDetailsResponse struct {
+	Data map[string]struct {
+		Transaction struct {
+			BlockID    int64 `json:"block_id"`
+			IsCoinbase bool  `json:"is_coinbase"`
+			Fee        int64 `json:"fee"`
+			Weight     int   `json:"weight"`
+		} `json:"transaction"`
+	} `json:"data"`
+}
+
+// TransactionFeeProvider is implemented by clients that report the fee paid by a transaction.
+// GetTransactionFee returns nil when the provider does not know the transaction.
+type TransactionFeeProvider interface {
+	GetTransactionFee(ctx context.Context, hash string) (*models.TransactionFee, error)
+}
+
+// ErrFeeLookupUnsupported is returned when the provider cannot report transaction fees
+var ErrFeeLookupUnsupported = errors.New("provider does not report transaction fees")
+
+// blockchairMaxDashboardHashes is the most transactions the transactions dashboard accepts per request
+const blockchairMaxDashboardHashes = 10
+
+// ConnectionPool tunes how many idle connections the client keeps open for reuse
+type ConnectionPool struct {
+	// MaxIdleConns bounds idle connections across all hosts; zero means no limit
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds idle connections kept to a single host
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections left idle for this long; zero keeps them open
+	IdleConnTimeout time.Duration
+}
+
+// DefaultConnectionPool keeps enough idle connections for concurrent syncs against one host
+var DefaultConnectionPool = ConnectionPool{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// newTransport returns an HTTP transport with the default dial and TLS settings and the given pool
+func newTransport(pool ConnectionPool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = pool.MaxIdleConns
+	transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = pool.IdleConnTimeout
+	return transport
+}
+
+// BlockchairOption configures a BlockchairClient
+type BlockchairOption func(*BlockchairClient)
+
+// WithConnectionPool replaces the default connection pool settings
+func WithConnectionPool(pool ConnectionPool) BlockchairOption {
+	return func(c *BlockchairClient) {
+		c.httpClient.Transport = newTransport(pool)
+	}
+}
+
+// WithRequestObserver reports every upstream request to observer
+func WithRequestObserver(observer RequestObserver) BlockchairOption {
+	return func(c *BlockchairClient) {
+		c.observer = observer
+	}
+}
+
+// WithAPIKey sends key with every request, lifting the free tier's rate limits for paid accounts.
+// An empty key leaves requests unauthenticated.
+func WithAPIKey(key string) BlockchairOption {
+	return func(c *BlockchairClient) {
+		c.apiKey = key
+	}
+}
+
+// Blockchair API roots for the networks it serves
+const (
+	BlockchairMainnetURL = "https://api.blockchair.com/bitcoin"
+	BlockchairTestnetURL = "https://api.blockchair.com/bitcoin/testnet"
+)
+
+// WithBaseURL points the client at another Blockchair API root, such as BlockchairTestnetURL or a
+// local mock. The client serves testnet when the URL's path ends in /testnet and mainnet otherwise.
+func WithBaseURL(baseURL string) BlockchairOption {
+	return func(c *BlockchairClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+		c.network = BlockchairNetwork(c.baseURL)
+	}
+}
+
+// BlockchairNetwork returns the network served by a Blockchair API root
+func BlockchairNetwork(baseURL string) address.Network {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err == nil && strings.HasSuffix(u.Path, "/testnet") {
+		return address.Testnet
+	}
+	return address.Mainnet
 }
 
 // NewBlockchairClient creates a new Blockchair client
-func NewBlockchairClient() *BlockchairClient {
-	return &BlockchairClient{
-		baseURL: "https://api.blockchair.com/bitcoin",
+func NewBlockchairClient(opts ...BlockchairOption) *BlockchairClient {
+	c := &BlockchairClient{
+		baseURL:     BlockchairMainnetURL,
+		network:     address.Mainnet,
+		maxAttempts: DefaultMaxAttempts,
+		baseDelay:   DefaultBaseDelay,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(DefaultConnectionPool),
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// Wrap last so the observer sees requests on whatever transport the options installed
+	if c.observer != nil {
+		c.httpClient.Transport = &observingTransport{base: c.httpClient.Transport, observer: c.observer}
+	}
+	// The cache goes outside the observer, so only requests that reach the provider are reported
+	if c.cache != nil {
+		c.httpClient.Transport = c.cache.Transport(c.httpClient.Transport)
+	}
+	return c
 }
 
 // GetBalance retrieves the current balance for a Bitcoin address
-func (c *BlockchairClient) GetBalance(address string) (*models.Balance, error) {
-	url := fmt.Sprintf("%s/dashboards/address/%s", c.baseURL, address)
-	
-	resp, err := c.httpClient.Get(url)
+func (c *BlockchairClient) GetBalance(ctx context.Context, address string) (*models.Balance, error) {
+	addressData, err := c.getAddressData(ctx, address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch balance: %w", err)
 	}
+	if addressData == nil {
+		return unusedAddressBalance(address), nil
+	}
+
+	// Blockchair's balance includes the mempool; the pending part is the net change of its mempool transactions
+	var pending int64
+	for _, tx := range addressData.Transactions {
+		if tx.BlockID <= 0 {
+			pending += tx.BalanceChange
+		}
+	}
+
+	return newBalance(address, addressData.Address.Balance-pending, pending), nil
+}
+
+// GetAddressStats retrieves the provider's statistics for an address
+func (c *BlockchairClient) GetAddressStats(ctx context.Context, address string) (*models.AddressStats, error) {
+	addressData, err := c.getAddressData(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch address stats: %w", err)
+	}
+	if addressData == nil {
+		return nil, nil
+	}
+
+	info := addressData.Address
+	return &models.AddressStats{
+		TransactionCount:   info.TransactionCount,
+		OutputCount:        info.OutputCount,
+		UnspentOutputCount: info.UnspentOutputCount,
+		FirstSeenReceiving: info.FirstSeenReceiving.ptr(),
+		LastSeenReceiving:  info.LastSeenReceiving.ptr(),
+		FirstSeenSpending:  info.FirstSeenSpending.ptr(),
+		LastSeenSpending:   info.LastSeenSpending.ptr(),
+		ProviderFiatValue:  &info.BalanceUsd,
+		UpdatedAt:          time.Now(),
+	}, nil
+}
+
+// getAddressData fetches the address dashboard with its recent transactions, returning nil for
+// addresses the provider has never seen
+func (c *BlockchairClient) getAddressData(ctx context.Context, address string) (*BlockchairAddressData, error) {
+	url := fmt.Sprintf("%s/dashboards/address/%s?transaction_details=true", c.baseURL, address)
+	
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
+	// Never-used addresses are valid; some providers report them as not found
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var addressResp BlockchairAddressResponse
@@ -91,54 +357,137 @@ func (c *BlockchairClient) GetBalance(address string) (*models.Balance, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// An empty data map means the address has no history, not that the request failed
 	addressData, exists := addressResp.Data[address]
 	if !exists {
-		return nil, fmt.Errorf("address data not found in response")
+		return nil, nil
 	}
 
-	// Convert satoshis to BTC
-	balanceBTC := float64(addressData.Address.Balance) / 100000000
+	return &addressData, nil
+}
 
-	return &models.Balance{
-		Address:            address,
-		ConfirmedBalance:   addressData.Address.Balance,
-		UnconfirmedBalance: 0, // Blockchair doesn't separate confirmed/unconfirmed in this endpoint
-		TotalBalance:       addressData.Address.Balance,
-		BalanceBTC:         balanceBTC,
-	}, nil
+// get issues a GET request bound to ctx, so cancelling the caller's work aborts the upstream request.
+// Rate limits and transient server errors are retried up to maxAttempts times in total; the last
+// response is returned as is once attempts run out.
+func (c *BlockchairClient) get(ctx context.Context, url string) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.apiKey != "" {
+			query := req.URL.Query()
+			query.Set("key", c.apiKey)
+			req.URL.RawQuery = query.Encode()
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, redactError(err)
+		}
+		if attempt >= c.maxAttempts || !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay, ok := retryDelay(resp, attempt, c.baseDelay)
+		if !ok {
+			return resp, nil
+		}
+		discard(resp)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// unusedAddressBalance is the balance of a valid address that has never received funds
+func unusedAddressBalance(address string) *models.Balance {
+	return newBalance(address, 0, 0)
 }
 
 // GetTransactions retrieves recent transactions for a Bitcoin address
-func (c *BlockchairClient) GetTransactions(address string, limit int) ([]models.Transaction, error) {
-	url := fmt.Sprintf("%s/dashboards/address/%s?limit=%d", c.baseURL, address, limit)
+func (c *BlockchairClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	url := fmt.Sprintf("%s/dashboards/address/%s?limit=%d&transaction_details=true", c.baseURL, address, limit)
 	
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Never-used addresses are valid and simply have no transactions
+	if resp.StatusCode == http.StatusNotFound {
+		return []models.Transaction{}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var transResp BlockchairTransactionsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&transResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	txs := transResp.Data[address].Transactions
 
-	var transactions []models.Transaction
-	for _, tx := range transResp.Data.Transactions {
-		// Determine transaction type based on balance change
-		txType := "received"
-		if tx.BalanceChange < 0 {
-			txType = "sent"
+	// Confirmations are counted from the best block, which only mined transactions need
+	bestHeight := 0
+	for _, tx := range txs {
+		if tx.BlockID > 0 {
+			if bestHeight, err = c.bestHeight(ctx); err != nil {
+				return nil, fmt.Errorf("failed to fetch best block height: %w", err)
+			}
+			break
 		}
+	}
+
+	return mapBlockchairTransactions(address, txs, bestHeight), nil
+}
+
+// bestHeight returns the height of the best block, reusing the last chain tip for chainTipTTL
+func (c *BlockchairClient) bestHeight(ctx context.Context) (int, error) {
+	c.tip.mu.Lock()
+	defer c.tip.mu.Unlock()
+
+	if c.tip.tip != nil && time.Since(c.tip.fetchedAt) < chainTipTTL {
+		return c.tip.tip.Height, nil
+	}
+
+	tip, err := c.fetchChainTip(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.tip.tip, c.tip.fetchedAt = tip, time.Now()
+	return tip.Height, nil
+}
 
-		// Calculate confirmations (simplified - we assume recent blocks)
-		confirmations := 6 // Default to 6 confirmations for simplicity
-		if tx.BlockID == 0 {
+// ParseTransactions maps a raw Blockchair transactions array, as found in the address dashboard, for address
+func (c *BlockchairClient) ParseTransactions(address string, raw []byte) ([]models.Transaction, error) {
+	var txs []BlockchairTransaction
+	if err := json.Unmarshal(raw, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode Blockchair transactions: %w", err)
+	}
+	return mapBlockchairTransactions(address, txs, 0), nil
+}
+
+// assumedConfirmations is reported for mined transactions when the best block height is unknown,
+// as for imported transactions
+const assumedConfirmations = 6
+
+// mapBlockchairTransactions converts Blockchair dashboard transactions into the client contract for
+// address, counting confirmations up to bestHeight, or assuming assumedConfirmations when it is zero
+func mapBlockchairTransactions(address string, txs []BlockchairTransaction, bestHeight int) []models.Transaction {
+	transactions := []models.Transaction{}
+	for _, tx := range txs {
+		confirmations := assumedConfirmations
+		blockHeight := int(tx.BlockID)
+		switch {
+		case tx.BlockID <= 0:
 			confirmations = 0 // Unconfirmed transaction
+			blockHeight = 0
+		case bestHeight > 0:
+			// A cached tip can trail a transaction mined since; it is confirmed at least once
+			confirmations = max(bestHeight-blockHeight+1, 1)
 		}
 
 		transaction := models.Transaction{
@@ -146,38 +495,139 @@ func (c *BlockchairClient) GetTransactions(address string, limit int) ([]models.
 			Address:       address,
 			Amount:        tx.BalanceChange,
 			Confirmations: confirmations,
-			BlockHeight:   int(tx.BlockID),
-			Timestamp:     tx.Time,
-			Type:          txType,
+			BlockHeight:   blockHeight,
+			Timestamp:     tx.Time.Time,
+			Type:          transactionType(tx.BalanceChange),
 		}
 
 		transactions = append(transactions, transaction)
 	}
 
-	return transactions, nil
+	return transactions
+}
+
+// GetChainTip retrieves the current best block from the blockchain stats. Transactions fetched
+// afterwards count their confirmations from it.
+func (c *BlockchairClient) GetChainTip(ctx context.Context) (*models.ChainTip, error) {
+	tip, err := c.fetchChainTip(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tip.mu.Lock()
+	c.tip.tip, c.tip.fetchedAt = tip, time.Now()
+	c.tip.mu.Unlock()
+	return tip, nil
 }
 
-// IsValidAddress checks if a Bitcoin address is valid (basic check)
-func (c *BlockchairClient) IsValidAddress(address string) bool {
-	// Basic validation - check length and prefixes
-	if len(address) < 26 || len(address) > 62 {
-		return false
+// fetchChainTip requests the blockchain stats
+func (c *BlockchairClient) fetchChainTip(ctx context.Context) (*models.ChainTip, error) {
+	resp, err := c.get(ctx, c.baseURL+"/stats")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
 	}
 
-	// Check for valid Bitcoin address prefixes
-	validPrefixes := []string{"1", "3", "bc1"}
-	for _, prefix := range validPrefixes {
-		if len(address) >= len(prefix) && address[:len(prefix)] == prefix {
-			return true
+	var statsResp BlockchairStatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&statsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &models.ChainTip{
+		Height: statsResp.Data.BestBlockHeight,
+		Hash:   statsResp.Data.BestBlockHash,
+		Time:   statsResp.Data.BestBlockTime.Time,
+	}, nil
+}
+
+// DetectCoinbase looks up the given transactions and reports which of them are coinbase transactions
+func (c *BlockchairClient) DetectCoinbase(ctx context.Context, hashes []string) (map[string]bool, error) {
+	coinbase := make(map[string]bool)
+	for start := 0; start < len(hashes); start += blockchairMaxDashboardHashes {
+		end := min(start+blockchairMaxDashboardHashes, len(hashes))
+		details, err := c.getTransactionDetails(ctx, hashes[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch transaction details: %w", err)
+		}
+
+		for hash, entry := range details.Data {
+			if entry.Transaction.IsCoinbase {
+				coinbase[hash] = true
+			}
 		}
 	}
 
-	return false
+	return coinbase, nil
+}
+
+// GetTransactionFee looks up the fee and virtual size of a transaction, confirmed or in the mempool
+func (c *BlockchairClient) GetTransactionFee(ctx context.Context, hash string) (*models.TransactionFee, error) {
+	details, err := c.getTransactionDetails(ctx, []string{hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction details: %w", err)
+	}
+
+	entry, ok := details.Data[hash]
+	if !ok {
+		return nil, nil
+	}
+
+	tx := entry.Transaction
+	return &models.TransactionFee{
+		Hash:      hash,
+		Fee:       tx.Fee,
+		VSize:     (tx.Weight + 3) / 4,
+		Confirmed: tx.BlockID > 0,
+	}, nil
+}
+
+// getTransactionDetails fetches the transactions dashboard for up to blockchairMaxDashboardHashes hashes
+func (c *BlockchairClient) getTransactionDetails(ctx context.Context, hashes []string) (*BlockchairTransactionDetailsResponse, error) {
+	url := fmt.Sprintf("%s/dashboards/transactions/%s", c.baseURL, strings.Join(hashes, ","))
+
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var detailsResp BlockchairTransactionDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&detailsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &detailsResp, nil
+}
+
+// IsValidAddress checks if a Bitcoin address is valid on the network this client serves
+func (c *BlockchairClient) IsValidAddress(ctx context.Context, addr string) bool {
+	_, err := address.Validate(addr, c.network)
+	return err == nil
+}
+
+// Capabilities reports that Blockchair backs every optional feature
+func (c *BlockchairClient) Capabilities() models.ClientCapabilities {
+	return models.ClientCapabilities{
+		AddressStats:      true,
+		TransactionImport: true,
+		ChainTip:          true,
+		CoinbaseDetection: true,
+		TransactionFees:   true,
+		MempoolBalance:    true,
+	}
 }
 
 // GetDetailedTransactions retrieves detailed transaction information for an address
-func (c *BlockchairClient) GetDetailedTransactions(address string) ([]models.Transaction, error) {
+func (c *BlockchairClient) GetDetailedTransactions(ctx context.Context, address string) ([]models.Transaction, error) {
 	// This would require a more complex API call that gets individual transaction details
 	// For now, we'll use the simpler dashboard endpoint
-	return c.GetTransactions(address, 50)
+	return c.GetTransactions(ctx, address, 50)
 }
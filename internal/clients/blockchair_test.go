@@ -1,26 +1,522 @@
 package clients
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
-func TestIsValidAddress(t *testing.T) {
-	client := NewBlockchairClient()
+func TestGetBalanceUnusedAddress(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
 
 	testCases := []struct {
-		address string
-		valid   bool
+		name    string
+		status  int
+		body    string
+		wantErr bool
 	}{
-		{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", true},  // Bech32
-		{"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", true},           // P2SH
-		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},           // P2PKH
-		{"invalid", false},                                      // Too short
-		{"", false},                                             // Empty
-		{"2N1234567890abcdef", false},                           // Wrong prefix
+		{"empty data", http.StatusOK, `{"data": {}}`, false},
+		{"not found", http.StatusNotFound, `{"data": null}`, false},
+		{"provider failure", http.StatusInternalServerError, `{}`, true},
 	}
 
 	for _, tc := range testCases {
-		result := client.IsValidAddress(tc.address)
-		if result != tc.valid {
-			t.Errorf("IsValidAddress(%s) = %v; want %v", tc.address, result, tc.valid)
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := NewBlockchairClient()
+			client.baseURL = server.URL
+			client.maxAttempts = 1
+
+			balance, err := client.GetBalance(context.Background(), address)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error for a provider failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetBalance failed: %v", err)
+			}
+			if balance.Address != address || balance.TotalBalance != 0 {
+				t.Errorf("Expected zero balance for %s, got %+v", address, balance)
+			}
+		})
+	}
+}
+
+func TestGetBalancePendingFunds(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("transaction_details") != "true" {
+			t.Errorf("Expected the dashboard to be requested with transaction details, got %s", r.URL)
+		}
+		fmt.Fprintf(w, `{"data": {%q: {
+			"address": {"balance": 180000},
+			"transactions": [
+				{"block_id": -1, "hash": "aa", "time": "2024-03-02 12:00:00", "balance_change": 30000},
+				{"block_id": -1, "hash": "bb", "time": "2024-03-02 11:00:00", "balance_change": -20000},
+				{"block_id": 830000, "hash": "cc", "time": "2024-02-20 08:30:00", "balance_change": 170000}
+			]
+		}}}`, address)
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	balance, err := client.GetBalance(context.Background(), address)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.UnconfirmedBalance != 10000 || balance.ConfirmedBalance != 170000 || balance.TotalBalance != 180000 {
+		t.Errorf("Expected pending funds in the unconfirmed balance, got %+v", balance)
+	}
+}
+
+func TestGetBalanceMempoolFixture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/blockchair/dashboard_details.json")
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient(WithBaseURL(server.URL))
+
+	// The captured balance of 200000 includes a 50000 mempool spend (block_id -1)
+	balance, err := client.GetBalance(context.Background(), conformanceAddress)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.ConfirmedBalance != 250000 || balance.UnconfirmedBalance != -50000 || balance.TotalBalance != 200000 {
+		t.Errorf("Expected 250000 confirmed and -50000 unconfirmed, got %+v", balance)
+	}
+	if err := CheckBalance(conformanceAddress, balance); err != nil {
+		t.Errorf("Balance violates the contract: %v", err)
+	}
+}
+
+func TestGetAddressStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/blockchair/dashboard_details.json")
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	stats, err := client.GetAddressStats(context.Background(), conformanceAddress)
+	if err != nil {
+		t.Fatalf("GetAddressStats failed: %v", err)
+	}
+	if stats.TransactionCount != 3 || stats.OutputCount != 2 || stats.UnspentOutputCount != 1 {
+		t.Errorf("Unexpected counts: %+v", stats)
+	}
+	if stats.FirstSeenReceiving == nil || stats.FirstSeenReceiving.Format(blockchairTimeLayout) != "2024-01-15 17:45:00" {
+		t.Errorf("Unexpected first_seen_receiving: %v", stats.FirstSeenReceiving)
+	}
+	if stats.ProviderFiatValue == nil || *stats.ProviderFiatValue != 65.12 {
+		t.Errorf("Unexpected provider_fiat_value: %v", stats.ProviderFiatValue)
+	}
+}
+
+func TestDetectCoinbase(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Write([]byte(`{"data": {
+			"coinbase-hash": {"transaction": {"is_coinbase": true}},
+			"regular-hash": {"transaction": {"is_coinbase": false}}
+		}}`))
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	hashes := []string{"coinbase-hash", "regular-hash"}
+	for i := 0; i < 10; i++ {
+		hashes = append(hashes, fmt.Sprintf("hash-%d", i))
+	}
+
+	coinbase, err := client.DetectCoinbase(context.Background(), hashes)
+	if err != nil {
+		t.Fatalf("DetectCoinbase failed: %v", err)
+	}
+	if !coinbase["coinbase-hash"] || coinbase["regular-hash"] || len(coinbase) != 1 {
+		t.Errorf("Unexpected coinbase flags: %v", coinbase)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected hashes to be looked up in 2 batches, got %d requests", len(requests))
+	}
+}
+
+func TestGetChainTip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/blockchair/stats.json")
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	tip, err := client.GetChainTip(context.Background())
+	if err != nil {
+		t.Fatalf("GetChainTip failed: %v", err)
+	}
+	if tip.Height != 830010 || tip.Hash == "" || tip.Time.Format(blockchairTimeLayout) != "2024-02-20 10:05:00" {
+		t.Errorf("Unexpected chain tip: %+v", tip)
+	}
+}
+
+func TestConnectionPoolReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	opened := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			opened++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	pool := ConnectionPool{MaxIdleConns: 4, MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Minute}
+	client := NewBlockchairClient(WithConnectionPool(pool))
+	client.baseURL = server.URL
+
+	transport := client.httpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 4 || transport.MaxIdleConnsPerHost != 2 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("Transport not configured from pool %+v", pool)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetBalance(context.Background(), "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"); err != nil {
+			t.Fatalf("GetBalance failed: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if opened != 1 {
+		t.Errorf("Expected sequential requests to reuse one connection, opened %d", opened)
+	}
+}
+
+func TestParseTransactions(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	raw := []byte(`[
+		{"block_id": -1, "hash": "aa", "time": "2024-03-01 12:00:00", "balance_change": -50000},
+		{"block_id": 830000, "hash": "bb", "time": "2024-02-28 08:30:00", "balance_change": 150000}
+	]`)
+
+	txs, err := NewBlockchairClient().ParseTransactions(address, raw)
+	if err != nil {
+		t.Fatalf("ParseTransactions failed: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(txs))
+	}
+	for _, tx := range txs {
+		if err := CheckTransaction(address, tx); err != nil {
+			t.Errorf("Imported transaction breaks the contract: %v", err)
+		}
+	}
+	if txs[0].Confirmations != 0 || txs[0].Type != TypeSent || txs[1].BlockHeight != 830000 || txs[1].Type != TypeReceived {
+		t.Errorf("Unexpected mapping: %+v", txs)
+	}
+
+	if _, err := NewBlockchairClient().ParseTransactions(address, []byte(`{"data": {}}`)); err == nil {
+		t.Error("Expected an error for JSON that is not a transactions array")
+	}
+}
+
+func TestGetTransactionsConfirmations(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	var statsRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stats":
+			atomic.AddInt32(&statsRequests, 1)
+			http.ServeFile(w, r, "testdata/blockchair/stats.json")
+		case "/dashboards/address/" + address:
+			http.ServeFile(w, r, "testdata/blockchair/dashboard_details.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	// The stats fixture's best block is 830010
+	want := map[int]int{-1: 0, 830000: 11, 825000: 5011}
+	for i := 0; i < 2; i++ {
+		transactions, err := client.GetTransactions(ctx, address, 100)
+		if err != nil {
+			t.Fatalf("GetTransactions failed: %v", err)
+		}
+		if len(transactions) != len(want) {
+			t.Fatalf("Expected %d transactions, got %d", len(want), len(transactions))
+		}
+		for _, tx := range transactions {
+			height := tx.BlockHeight
+			if height == 0 {
+				height = -1
+			}
+			if tx.Confirmations != want[height] {
+				t.Errorf("Transaction %s at height %d: expected %d confirmations, got %d", tx.Hash, tx.BlockHeight, want[height], tx.Confirmations)
+			}
+		}
+	}
+	if got := atomic.LoadInt32(&statsRequests); got != 1 {
+		t.Errorf("Expected the best block to be fetched once and reused, got %d stats requests", got)
+	}
+
+	// A tip older than a listed transaction still counts it as confirmed once
+	stale := mapBlockchairTransactions(address, []BlockchairTransaction{{BlockID: 830011, Hash: "aa", BalanceChange: 1000}}, 830010)
+	if stale[0].Confirmations != 1 {
+		t.Errorf("Expected 1 confirmation against a stale tip, got %d", stale[0].Confirmations)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-RateLimit-Limit", "1440")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	_, err := client.GetChainTip(context.Background())
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		t.Fatalf("Expected a *RateLimitError, got %T", err)
+	}
+	if limited.RetryAfter != 30*time.Second || limited.Limit == nil || *limited.Limit != 1440 || limited.Remaining == nil || *limited.Remaining != 0 {
+		t.Errorf("Unexpected rate limit details: %+v", limited)
+	}
+}
+
+func TestRequestCancelledWithContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := client.GetTransactions(ctx, "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", 10)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the request to end with its context, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Cancelled request still took %v", elapsed)
+	}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data": {"best_block_height": 840000, "best_block_hash": "00ab", "best_block_time": "2024-04-20 00:09:27"}}`))
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+	client.baseDelay = time.Millisecond
+
+	tip, err := client.GetChainTip(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got %v", err)
+	}
+	if tip.Height != 840000 || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("Expected height 840000 after 3 requests, got %d after %d", tip.Height, calls)
+	}
+
+	// With fewer attempts the same failures reach the caller
+	atomic.StoreInt32(&calls, 0)
+	client.maxAttempts = 2
+	if _, err := client.GetChainTip(context.Background()); err == nil {
+		t.Error("Expected an error once attempts run out")
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if _, err := client.GetChainTip(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the retry wait to end with the context, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Retry-After wait outlived the context: %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected no request after cancellation, got %d", got)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.URL.Query().Get("key"))
+		mu.Unlock()
+		if r.URL.Query().Get("transaction_details") != "true" {
+			t.Errorf("Existing query parameters lost: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer server.Close()
+
+	for _, key := range []string{"secret-key", ""} {
+		keys = nil
+		client := NewBlockchairClient(WithAPIKey(key))
+		client.baseURL = server.URL
+
+		ctx := context.Background()
+		if _, err := client.GetBalance(ctx, address); err != nil {
+			t.Fatalf("GetBalance failed: %v", err)
+		}
+		if _, err := client.GetTransactions(ctx, address, 10); err != nil {
+			t.Fatalf("GetTransactions failed: %v", err)
+		}
+		if _, err := client.GetDetailedTransactions(ctx, address); err != nil {
+			t.Fatalf("GetDetailedTransactions failed: %v", err)
+		}
+
+		if len(keys) != 3 {
+			t.Fatalf("Expected 3 requests, got %d", len(keys))
+		}
+		for _, got := range keys {
+			if got != key {
+				t.Errorf("Expected key %q on every request, got %q", key, got)
+			}
+		}
+	}
+
+	// A failed request must not leak the key into its error
+	server.Close()
+	client := NewBlockchairClient(WithAPIKey("secret-key"))
+	client.baseURL = server.URL
+	_, err := client.GetBalance(context.Background(), address)
+	if err == nil {
+		t.Fatal("Expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("API key leaked into error: %v", err)
+	}
+}
+
+func TestWithBaseURLTestnet(t *testing.T) {
+	testnetAddress := "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient(WithBaseURL(server.URL + "/bitcoin/testnet/"))
+	ctx := context.Background()
+
+	if !client.IsValidAddress(ctx, testnetAddress) {
+		t.Errorf("Expected testnet client to accept %s", testnetAddress)
+	}
+	for _, addr := range []string{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc"} {
+		if !client.IsValidAddress(ctx, addr) {
+			t.Errorf("Expected testnet client to accept %s", addr)
+		}
+	}
+	if client.IsValidAddress(ctx, "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5") {
+		t.Error("Expected testnet client to reject a mainnet address")
+	}
+
+	if _, err := client.GetBalance(ctx, testnetAddress); err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if want := "/bitcoin/testnet/dashboards/address/" + testnetAddress; requested != want {
+		t.Errorf("Expected request to %s, got %s", want, requested)
+	}
+
+	mainnet := NewBlockchairClient(WithBaseURL(server.URL))
+	if mainnet.IsValidAddress(ctx, testnetAddress) || !mainnet.IsValidAddress(ctx, "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5") {
+		t.Error("Expected a base URL without /testnet to serve mainnet")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Tue, 20 Feb 2024 10:01:30 GMT", 90 * time.Second},
+		{"Tue, 20 Feb 2024 09:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
 		}
 	}
 }
@@ -0,0 +1,200 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CacheMode controls how a ResponseCache uses its on-disk cache
+type CacheMode string
+
+// Supported cache modes
+const (
+	CacheModeOff    CacheMode = "off"
+	CacheModeRecord CacheMode = "record"
+	CacheModeReplay CacheMode = "replay"
+)
+
+// ParseCacheMode converts a configuration value into a CacheMode
+func ParseCacheMode(value string) (CacheMode, error) {
+	switch mode := CacheMode(value); mode {
+	case "":
+		return CacheModeOff, nil
+	case CacheModeOff, CacheModeRecord, CacheModeReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown cache mode: %q", value)
+	}
+}
+
+// ErrCacheMiss is returned in replay mode when no response was recorded for a request
+var ErrCacheMiss = errors.New("no cached provider response")
+
+// ResponseCache stores provider HTTP responses on disk, keyed by method and URL with credentials redacted,
+// so recordings can be replayed with another API key and never contain one.
+// In record mode fresh entries are served from disk and everything else is fetched and stored;
+// in replay mode only recorded entries are served, regardless of age, so no network is needed.
+type ResponseCache struct {
+	dir  string
+	ttl  time.Duration
+	mode CacheMode
+}
+
+// cacheEntry is the on-disk representation of a cached response
+type cacheEntry struct {
+	Key       string      `json:"key"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Status    int         `json:"status"`
+	Header    http.Header `json:"header"`
+	Body      []byte      `json:"body"`
+}
+
+// NewResponseCache creates a cache stored in dir
+func NewResponseCache(dir string, ttl time.Duration, mode CacheMode) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &ResponseCache{
+		dir:  dir,
+		ttl:  ttl,
+		mode: mode,
+	}, nil
+}
+
+// WithResponseCache serves the client's requests through cache
+func WithResponseCache(cache *ResponseCache) BlockchairOption {
+	return func(c *BlockchairClient) {
+		c.cache = cache
+	}
+}
+
+// Transport returns a RoundTripper that serves requests from the cache and sends the rest to base
+func (c *ResponseCache) Transport(base http.RoundTripper) http.RoundTripper {
+	return &cachingTransport{base: base, cache: c}
+}
+
+// cachingTransport serves GET requests from a ResponseCache
+type cachingTransport struct {
+	base  http.RoundTripper
+	cache *ResponseCache
+}
+
+// RoundTrip serves the request from disk when it was recorded recently enough, and otherwise sends it
+// and records the response. Only responses that would be the same on a retry are recorded: rate limits
+// and server errors are passed through.
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.Method + " " + redactURL(req.URL)
+	entry, err := t.cache.read(key)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil && (t.cache.mode == CacheModeReplay || time.Since(entry.FetchedAt) < t.cache.ttl) {
+		return entry.response(req), nil
+	}
+
+	if t.cache.mode == CacheModeReplay {
+		return nil, fmt.Errorf("%w for %s", ErrCacheMiss, key)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for cache: %w", err)
+	}
+	entry = &cacheEntry{Key: key, FetchedAt: time.Now(), Status: resp.StatusCode, Header: resp.Header, Body: body}
+	if err := t.cache.write(*entry); err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// response rebuilds the recorded response as the answer to req
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// path returns the cache file for a key
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read loads a cache entry, returning nil if none was recorded
+func (c *ResponseCache) read(key string) (*cacheEntry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// write stores a cache entry atomically so concurrent readers never see a partial file
+func (c *ResponseCache) write(entry cacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(entry.Key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingServer serves a body naming the request URI and counts the requests per URI
+type countingServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newCountingServer(t *testing.T, status int) *countingServer {
+	t.Helper()
+
+	s := &countingServer{calls: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.calls[r.URL.RequestURI()]++
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, "response for "+r.URL.RequestURI())
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// count returns how many times uri reached the server
+func (s *countingServer) count(uri string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[uri]
+}
+
+// fetch sends a GET for uri through client and returns the response body
+func fetch(t *testing.T, client *http.Client, url string) (string, error) {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestResponseCacheHitMissAndExpiry(t *testing.T) {
+	server := newCountingServer(t, http.StatusOK)
+	cache, err := NewResponseCache(t.TempDir(), time.Hour, CacheModeRecord)
+	if err != nil {
+		t.Fatalf("NewResponseCache failed: %v", err)
+	}
+	client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+
+	// Each URL, query included, is cached on its own
+	uris := []string{"/a", "/a?limit=2", "/b"}
+	for i := 0; i < 2; i++ {
+		for _, uri := range uris {
+			body, err := fetch(t, client, server.URL+uri)
+			if err != nil || body != "response for "+uri {
+				t.Fatalf("GET %s = %q (err %v); want its own response", uri, body, err)
+			}
+		}
+	}
+	for _, uri := range uris {
+		if got := server.count(uri); got != 1 {
+			t.Errorf("Expected 1 upstream request for %s, got %d", uri, got)
+		}
+	}
+
+	// Age the entry of /a past the TTL; only /a is fetched again
+	key := "GET " + server.URL + "/a"
+	entry, err := cache.read(key)
+	if err != nil || entry == nil {
+		t.Fatalf("Expected a recorded entry for %s, got %v (err %v)", key, entry, err)
+	}
+	entry.FetchedAt = time.Now().Add(-2 * time.Hour)
+	if err := cache.write(*entry); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	for _, uri := range uris {
+		if _, err := fetch(t, client, server.URL+uri); err != nil {
+			t.Fatalf("GET %s failed: %v", uri, err)
+		}
+	}
+	if got := server.count("/a"); got != 2 {
+		t.Errorf("Expected an expired entry to be fetched again, got %d upstream requests", got)
+	}
+	if got := server.count("/b"); got != 1 {
+		t.Errorf("Expected a fresh entry to be served from the cache, got %d upstream requests", got)
+	}
+}
+
+func TestResponseCacheSkipsServerErrors(t *testing.T) {
+	server := newCountingServer(t, http.StatusServiceUnavailable)
+	cache, err := NewResponseCache(t.TempDir(), time.Hour, CacheModeRecord)
+	if err != nil {
+		t.Fatalf("NewResponseCache failed: %v", err)
+	}
+	client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+
+	for i := 0; i < 2; i++ {
+		if _, err := fetch(t, client, server.URL+"/a"); err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+	}
+	if got := server.count("/a"); got != 2 {
+		t.Errorf("Expected a 503 never to be served from the cache, got %d upstream requests", got)
+	}
+}
+
+func TestResponseCacheRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/blockchair/stats.json")
+	}))
+	defer server.Close()
+
+	recording, err := NewResponseCache(dir, time.Hour, CacheModeRecord)
+	if err != nil {
+		t.Fatalf("NewResponseCache failed: %v", err)
+	}
+	recorder := NewBlockchairClient(WithBaseURL(server.URL), WithAPIKey("secret-key"), WithResponseCache(recording))
+	recorded, err := recorder.GetChainTip(context.Background())
+	if err != nil {
+		t.Fatalf("GetChainTip failed: %v", err)
+	}
+
+	// The API key is redacted from the cache key, so it is never written to disk
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one cache file, got %v (err %v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Errorf("Expected the API key to be redacted from the cache entry, got %s", data)
+	}
+
+	// Replay needs no network and matches recordings made with another key
+	server.Close()
+	replaying, err := NewResponseCache(dir, 0, CacheModeReplay)
+	if err != nil {
+		t.Fatalf("NewResponseCache failed: %v", err)
+	}
+	replayer := NewBlockchairClient(WithBaseURL(server.URL), WithAPIKey("other-key"), WithResponseCache(replaying))
+	replayed, err := replayer.GetChainTip(context.Background())
+	if err != nil {
+		t.Fatalf("GetChainTip failed in replay mode: %v", err)
+	}
+	if replayed.Height != recorded.Height || replayed.Hash != recorded.Hash {
+		t.Errorf("Expected the recorded chain tip %+v, got %+v", recorded, replayed)
+	}
+
+	if _, err := replayer.GetBalance(context.Background(), address); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss for an unrecorded request, got %v", err)
+	}
+}
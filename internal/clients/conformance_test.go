@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// conformanceAddress is present in every provider fixture
+const conformanceAddress = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+// testClientConformance asserts that a client maps its provider's data onto the client contract
+func testClientConformance(t *testing.T, client BitcoinClient) {
+	t.Helper()
+
+	if err := CheckCapabilities(client); err != nil {
+		t.Errorf("Capabilities violates the contract: %v", err)
+	}
+
+	balance, err := client.GetBalance(context.Background(), conformanceAddress)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if err := CheckBalance(conformanceAddress, balance); err != nil {
+		t.Errorf("GetBalance violates the contract: %v", err)
+	}
+
+	transactions, err := client.GetTransactions(context.Background(), conformanceAddress, 100)
+	if err != nil {
+		t.Fatalf("GetTransactions failed: %v", err)
+	}
+	if len(transactions) == 0 {
+		t.Fatal("Expected fixture transactions")
+	}
+
+	var sawMempool, sawSent, sawReceived bool
+	for _, tx := range transactions {
+		if err := CheckTransaction(conformanceAddress, tx); err != nil {
+			t.Errorf("GetTransactions violates the contract: %v", err)
+		}
+		sawMempool = sawMempool || tx.Confirmations == 0
+		sawSent = sawSent || tx.Type == TypeSent
+		sawReceived = sawReceived || tx.Type == TypeReceived
+	}
+	if !sawMempool || !sawSent || !sawReceived {
+		t.Errorf("Fixture should cover mempool, sent and received transactions, got %+v", transactions)
+	}
+
+	unused := "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	balance, err = client.GetBalance(context.Background(), unused)
+	if err != nil {
+		t.Fatalf("GetBalance for an unused address failed: %v", err)
+	}
+	if err := CheckBalance(unused, balance); err != nil || balance.TotalBalance != 0 {
+		t.Errorf("Expected a zero balance for an unused address, got %+v (%v)", balance, err)
+	}
+}
+
+func TestBlockchairClientConformance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stats" {
+			http.ServeFile(w, r, "testdata/blockchair/stats.json")
+			return
+		}
+		if r.URL.Path != "/dashboards/address/"+conformanceAddress {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"data": null}`))
+			return
+		}
+
+		data, err := os.ReadFile("testdata/blockchair/dashboard_details.json")
+		if err != nil {
+			t.Errorf("Failed to read fixture: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client := NewBlockchairClient()
+	client.baseURL = server.URL
+
+	testClientConformance(t, client)
+}
+
+func TestStaticClientConformance(t *testing.T) {
+	client, err := NewStaticClient("testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	testClientConformance(t, client)
+}
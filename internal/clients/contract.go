@@ -0,0 +1,128 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// The BitcoinClient contract. Every provider maps its upstream format onto these semantics,
+// so the rest of the application never needs to know which provider it talks to:
+//
+//   - Amounts are signed satoshis from the queried address's point of view: the net change the
+//     transaction makes to its balance, outputs paying it minus the inputs it spends. A payment
+//     that returns change to the same address is a single negative amount.
+//   - The type is derived from the amount's sign and never taken from the provider: positive and
+//     zero amounts are "received", negative amounts are "sent". Providers reporting a magnitude
+//     with a separate direction flag convert it with signedAmount.
+//   - Mempool transactions have zero confirmations and a block height of 0. Mined transactions
+//     have a positive block height and at least one confirmation.
+//   - Every transaction carries the queried address, its hash and a timestamp.
+//   - Transactions flagged as coinbase are mined receipts. Providers that cannot flag them in
+//     their listing may implement CoinbaseDetector instead.
+//   - Balances carry the queried address and split the total into confirmed and unconfirmed
+//     parts, with BalanceBTC equal to the total in BTC.
+//   - Capabilities only claims features the client implements the matching optional interface for.
+
+// Transaction types assigned from the amount sign
+const (
+	TypeReceived = "received"
+	TypeSent     = "sent"
+)
+
+// transactionType returns the type implied by a signed amount
+func transactionType(amount int64) string {
+	if amount < 0 {
+		return TypeSent
+	}
+	return TypeReceived
+}
+
+// signedAmount applies the sign convention to an amount reported as a magnitude plus a direction,
+// whatever sign the provider gave the magnitude
+func signedAmount(amount int64, sent bool) int64 {
+	if amount < 0 {
+		amount = -amount
+	}
+	if sent {
+		return -amount
+	}
+	return amount
+}
+
+// newBalance builds a balance that satisfies the contract from its confirmed and unconfirmed parts
+func newBalance(address string, confirmed, unconfirmed int64) *models.Balance {
+	total := confirmed + unconfirmed
+	return &models.Balance{
+		Address:            address,
+		ConfirmedBalance:   confirmed,
+		UnconfirmedBalance: unconfirmed,
+		TotalBalance:       total,
+		BalanceBTC:         models.SatoshisToBTC(total),
+	}
+}
+
+// CheckTransaction reports the first way tx violates the client contract for address
+func CheckTransaction(address string, tx models.Transaction) error {
+	switch {
+	case tx.Address != address:
+		return fmt.Errorf("transaction %s has address %q, want %q", tx.Hash, tx.Address, address)
+	case tx.Hash == "":
+		return errors.New("transaction has no hash")
+	case tx.Timestamp.IsZero():
+		return fmt.Errorf("transaction %s has no timestamp", tx.Hash)
+	case tx.Type != transactionType(tx.Amount):
+		return fmt.Errorf("transaction %s has type %q for amount %d", tx.Hash, tx.Type, tx.Amount)
+	case tx.BlockHeight < 0 || tx.Confirmations < 0:
+		return fmt.Errorf("transaction %s has negative block height or confirmations", tx.Hash)
+	case (tx.BlockHeight == 0) != (tx.Confirmations == 0):
+		return fmt.Errorf("transaction %s has block height %d with %d confirmations", tx.Hash, tx.BlockHeight, tx.Confirmations)
+	case tx.Coinbase && (tx.Amount <= 0 || tx.BlockHeight == 0):
+		return fmt.Errorf("coinbase transaction %s must be a confirmed receipt", tx.Hash)
+	}
+	return nil
+}
+
+// CheckBalance reports the first way b violates the client contract for address
+func CheckBalance(address string, b *models.Balance) error {
+	switch {
+	case b == nil:
+		return errors.New("balance is nil")
+	case b.Address != address:
+		return fmt.Errorf("balance has address %q, want %q", b.Address, address)
+	case b.TotalBalance != b.ConfirmedBalance+b.UnconfirmedBalance:
+		return fmt.Errorf("total balance %d is not confirmed %d plus unconfirmed %d", b.TotalBalance, b.ConfirmedBalance, b.UnconfirmedBalance)
+	case b.BalanceBTC != models.SatoshisToBTC(b.TotalBalance):
+		return fmt.Errorf("balance_btc %v does not match total balance %d", b.BalanceBTC, b.TotalBalance)
+	}
+	return nil
+}
+
+// CheckCapabilities reports the first capability client claims without implementing its interface
+func CheckCapabilities(client BitcoinClient) error {
+	caps := client.Capabilities()
+	checks := []struct {
+		name    string
+		claimed bool
+		backed  bool
+	}{
+		{"address_stats", caps.AddressStats, implements[AddressStatsProvider](client)},
+		{"transaction_import", caps.TransactionImport, implements[TransactionImporter](client)},
+		{"chain_tip", caps.ChainTip, implements[ChainTipProvider](client)},
+		{"coinbase_detection", caps.CoinbaseDetection, implements[CoinbaseDetector](client)},
+		{"transaction_fees", caps.TransactionFees, implements[TransactionFeeProvider](client)},
+	}
+	for _, check := range checks {
+		if check.claimed && !check.backed {
+			return fmt.Errorf("client claims %s without implementing it", check.name)
+		}
+	}
+	return nil
+}
+
+// implements reports whether client satisfies the interface T
+func implements[T any](client BitcoinClient) bool {
+	_, ok := client.(T)
+	return ok
+}
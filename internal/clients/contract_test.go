@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestTransactionSignConvention(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	blockTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		balanceChange int64
+		wantType      string
+	}{
+		{"positive balance change", 150000, TypeReceived},
+		{"negative balance change", -50000, TypeSent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := BlockchairTransaction{BlockID: 830000, Hash: "aa", Time: blockchairTime{blockTime}, BalanceChange: tc.balanceChange}
+			txs := mapBlockchairTransactions(address, []BlockchairTransaction{raw}, 830010)
+			if len(txs) != 1 {
+				t.Fatalf("Expected 1 transaction, got %d", len(txs))
+			}
+			tx := txs[0]
+			if tx.Amount != tc.balanceChange || tx.Type != tc.wantType {
+				t.Errorf("Got amount %d type %q; want %d %q", tx.Amount, tx.Type, tc.balanceChange, tc.wantType)
+			}
+			if err := CheckTransaction(address, tx); err != nil {
+				t.Errorf("Mapped transaction breaks the contract: %v", err)
+			}
+
+			// A provider mapping that flips the type must be caught
+			tx.Type = map[string]string{TypeReceived: TypeSent, TypeSent: TypeReceived}[tc.wantType]
+			if err := CheckTransaction(address, tx); err == nil {
+				t.Errorf("Expected type %q with amount %d to be rejected", tx.Type, tx.Amount)
+			}
+		})
+	}
+}
+
+func TestSignedAmount(t *testing.T) {
+	testCases := []struct {
+		amount int64
+		sent   bool
+		want   int64
+	}{
+		{50000, true, -50000},
+		{-50000, true, -50000},
+		{50000, false, 50000},
+		{-50000, false, 50000},
+		{0, true, 0},
+	}
+
+	for _, tc := range testCases {
+		if got := signedAmount(tc.amount, tc.sent); got != tc.want {
+			t.Errorf("signedAmount(%d, %v) = %d; want %d", tc.amount, tc.sent, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeFixtureTransactionsHonoursType(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	blockTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	txs := normalizeFixtureTransactions(address, []models.Transaction{
+		{Hash: "aa", Amount: 50000, BlockHeight: 830000, Confirmations: 6, Timestamp: blockTime, Type: TypeSent},
+		{Hash: "bb", Amount: 20000, BlockHeight: 830001, Confirmations: 6, Timestamp: blockTime},
+		{Hash: "cc", Amount: -10000, BlockHeight: 830002, Confirmations: 6, Timestamp: blockTime},
+	}, 0)
+
+	want := []struct {
+		amount int64
+		typ    string
+	}{{-50000, TypeSent}, {20000, TypeReceived}, {-10000, TypeSent}}
+	for i, tx := range txs {
+		if tx.Amount != want[i].amount || tx.Type != want[i].typ {
+			t.Errorf("Transaction %s: got amount %d type %q; want %d %q", tx.Hash, tx.Amount, tx.Type, want[i].amount, want[i].typ)
+		}
+		if err := CheckTransaction(address, tx); err != nil {
+			t.Errorf("Normalized transaction breaks the contract: %v", err)
+		}
+	}
+}
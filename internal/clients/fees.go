@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// FeeEstimator is implemented by sources of current fee rates
+type FeeEstimator interface {
+	GetFeeEstimates() (models.FeeEstimates, error)
+}
+
+// MempoolFeeClient reads recommended fees from a mempool.space compatible API
+type MempoolFeeClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// mempoolRecommendedFees represents the /fees/recommended response from mempool.space
+type mempoolRecommendedFees struct {
+	FastestFee  float64 `json:"fastestFee"`
+	HalfHourFee float64 `json:"halfHourFee"`
+	HourFee     float64 `json:"hourFee"`
+	EconomyFee  float64 `json:"economyFee"`
+	MinimumFee  float64 `json:"minimumFee"`
+}
+
+// NewMempoolFeeClient creates a fee client for the API at baseURL, e.g. https://mempool.space/api
+func NewMempoolFeeClient(baseURL string) *MempoolFeeClient {
+	return &MempoolFeeClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetFeeEstimates retrieves the currently recommended fee rates
+func (c *MempoolFeeClient) GetFeeEstimates() (models.FeeEstimates, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/v1/fees/recommended")
+	if err != nil {
+		return models.FeeEstimates{}, fmt.Errorf("failed to fetch fee estimates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.FeeEstimates{}, statusError(resp)
+	}
+
+	var fees mempoolRecommendedFees
+	if err := json.NewDecoder(resp.Body).Decode(&fees); err != nil {
+		return models.FeeEstimates{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return models.FeeEstimates{
+		FastestFee:  fees.FastestFee,
+		HalfHourFee: fees.HalfHourFee,
+		HourFee:     fees.HourFee,
+		EconomyFee:  fees.EconomyFee,
+		MinimumFee:  fees.MinimumFee,
+	}, nil
+}
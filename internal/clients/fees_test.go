@@ -0,0 +1,30 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestMempoolFeeClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/fees/recommended" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"fastestFee": 21, "halfHourFee": 18, "hourFee": 15, "economyFee": 8, "minimumFee": 4}`))
+	}))
+	defer server.Close()
+
+	fees, err := NewMempoolFeeClient(server.URL).GetFeeEstimates()
+	if err != nil {
+		t.Fatalf("GetFeeEstimates failed: %v", err)
+	}
+
+	want := models.FeeEstimates{FastestFee: 21, HalfHourFee: 18, HourFee: 15, EconomyFee: 8, MinimumFee: 4}
+	if fees != want {
+		t.Errorf("GetFeeEstimates = %+v; want %+v", fees, want)
+	}
+}
@@ -0,0 +1,137 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/requestid"
+)
+
+// ProviderRequest describes one upstream HTTP request made by a client
+type ProviderRequest struct {
+	Method   string
+	URL      string // with credentials redacted
+	Status   int    // zero when no response was received
+	Duration time.Duration
+	Bytes    int64 // response body bytes read
+	Err      error
+}
+
+// RequestObserver is notified once per upstream request, after its response body is closed.
+// ctx is the request's context, so observers can attach the request to a trace.
+type RequestObserver interface {
+	ObserveRequest(ctx context.Context, req ProviderRequest)
+}
+
+// LogObserver logs every upstream request, prefixed with the ID of the API request that caused it
+type LogObserver struct{}
+
+// ObserveRequest logs the request's outcome
+func (LogObserver) ObserveRequest(ctx context.Context, req ProviderRequest) {
+	prefix := requestid.Prefix(ctx)
+	if req.Err != nil {
+		log.Printf("%sprovider %s %s failed after %s: %v", prefix, req.Method, req.URL, req.Duration, req.Err)
+		return
+	}
+	log.Printf("%sprovider %s %s -> %d in %s (%d bytes)", prefix, req.Method, req.URL, req.Status, req.Duration, req.Bytes)
+}
+
+// sensitiveParams are query parameters whose values must never be logged
+var sensitiveParams = []string{"key", "api_key", "apikey", "token", "access_token"}
+
+// redactURL returns u as a string with credentials in the query or user info replaced
+func redactURL(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User("REDACTED")
+	}
+
+	query := redacted.Query()
+	changed := false
+	for name := range query {
+		for _, sensitive := range sensitiveParams {
+			if strings.EqualFold(name, sensitive) {
+				query.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+
+	return redacted.String()
+}
+
+// redactError removes credentials from the URL a failed request reports in its error, so the
+// error can be logged
+func redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: redactURL(u), Err: urlErr.Err}
+}
+
+// observingTransport reports every round trip to an observer
+type observingTransport struct {
+	base     http.RoundTripper
+	observer RequestObserver
+}
+
+// RoundTrip performs the request and reports it once the response body is closed
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	observed := ProviderRequest{Method: req.Method, URL: redactURL(req.URL)}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		observed.Duration = time.Since(start)
+		observed.Err = err
+		t.observer.ObserveRequest(req.Context(), observed)
+		return nil, err
+	}
+
+	observed.Status = resp.StatusCode
+	resp.Body = &observedBody{ReadCloser: resp.Body, done: func(n int64) {
+		observed.Duration = time.Since(start)
+		observed.Bytes = n
+		t.observer.ObserveRequest(req.Context(), observed)
+	}}
+	return resp, nil
+}
+
+// observedBody counts the bytes read from a response body and reports them once on Close
+type observedBody struct {
+	io.ReadCloser
+	n      int64
+	done   func(n int64)
+	closed bool
+}
+
+// Read counts the bytes read
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// Close closes the body and reports the request the first time it is called
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.done(b.n)
+	}
+	return err
+}
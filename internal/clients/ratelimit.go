@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited is returned when the provider refuses a request because we exceeded its rate limit
+var ErrRateLimited = errors.New("provider rate limit exceeded")
+
+// RateLimitError describes a rate-limited provider response. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	// RetryAfter is how long the provider asked us to wait, zero when it did not say
+	RetryAfter time.Duration
+	// Limit and Remaining are the provider's quota figures, nil when it does not report them
+	Limit     *int
+	Remaining *int
+}
+
+// Error describes the rate limit and whatever the provider told us about it
+func (e *RateLimitError) Error() string {
+	var details []string
+	if e.RetryAfter > 0 {
+		details = append(details, fmt.Sprintf("retry after %s", e.RetryAfter))
+	}
+	if e.Remaining != nil {
+		details = append(details, fmt.Sprintf("%d requests remaining", *e.Remaining))
+	}
+	if e.Limit != nil {
+		details = append(details, fmt.Sprintf("limit %d", *e.Limit))
+	}
+
+	if len(details) == 0 {
+		return ErrRateLimited.Error()
+	}
+	return fmt.Sprintf("%s (%s)", ErrRateLimited, strings.Join(details, ", "))
+}
+
+// Is makes errors.Is(err, ErrRateLimited) hold for rate limit errors
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// statusError turns an unexpected response status into an error, returning a *RateLimitError
+// for 429 Too Many Requests
+func statusError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	return &RateLimitError{
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Limit:      headerInt(resp.Header, "X-RateLimit-Limit"),
+		Remaining:  headerInt(resp.Header, "X-RateLimit-Remaining"),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now).Round(time.Second)
+	}
+	return 0
+}
+
+// headerInt parses a non-negative integer header, returning nil when it is missing or malformed
+func headerInt(header http.Header, name string) *int {
+	value, err := strconv.Atoi(header.Get(name))
+	if err != nil || value < 0 {
+		return nil
+	}
+	return &value
+}
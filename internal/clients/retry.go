@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Retry defaults for provider requests answered with a transient error status
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 500 * time.Millisecond
+)
+
+// maxRetryAfter is the longest Retry-After hint worth waiting for; a provider asking for more gets
+// its rate limit reported to the caller instead of stalling the sync
+const maxRetryAfter = 10 * time.Second
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay is how long to wait before retrying after resp on the given attempt (1 for the first
+// retry). Rate limits honor the provider's Retry-After hint; everything else backs off exponentially
+// from baseDelay with up to one baseDelay of jitter. It reports false when the hint is too long to wait.
+func retryDelay(resp *http.Response, attempt int, baseDelay time.Duration) (time.Duration, bool) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if hint := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); hint > 0 {
+			return hint, hint <= maxRetryAfter
+		}
+	}
+
+	delay := baseDelay << (attempt - 1)
+	if baseDelay > 0 {
+		delay += rand.N(baseDelay)
+	}
+	return delay, true
+}
+
+// sleep waits for d, returning early with the context's error when ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// discard drains and closes a response body so its connection can be reused
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
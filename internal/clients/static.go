@@ -0,0 +1,195 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// StaticClient serves deterministic data from a JSON fixture file, with no network access
+type StaticClient struct {
+	fixture staticFixture
+}
+
+// staticFixture is the on-disk fixture format, keyed by address
+type staticFixture struct {
+	Addresses map[string]staticAddress `json:"addresses"`
+	Fees      *models.FeeEstimates     `json:"fees"`
+	Tip       *models.ChainTip         `json:"tip"`
+	// TransactionFees holds the fee and virtual size of fixture transactions, keyed by hash
+	TransactionFees map[string]models.TransactionFee `json:"transaction_fees"`
+}
+
+// staticAddress holds the fixture data for one address.
+// When Balance is omitted it is derived from the transactions.
+type staticAddress struct {
+	Balance      *models.Balance      `json:"balance"`
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+// NewStaticClient loads a fixture file and creates a client serving its contents
+func NewStaticClient(path string) (*StaticClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture staticFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture: %w", err)
+	}
+
+	return &StaticClient{fixture: fixture}, nil
+}
+
+// GetBalance returns the fixture balance; addresses missing from the fixture have a zero balance
+func (c *StaticClient) GetBalance(ctx context.Context, address string) (*models.Balance, error) {
+	entry := c.fixture.Addresses[address]
+
+	var confirmed, unconfirmed int64
+	if entry.Balance != nil {
+		confirmed = entry.Balance.ConfirmedBalance
+		unconfirmed = entry.Balance.UnconfirmedBalance
+	} else {
+		for _, tx := range entry.Transactions {
+			if tx.Confirmations >= 1 {
+				confirmed += tx.Amount
+			} else {
+				unconfirmed += tx.Amount
+			}
+		}
+	}
+
+	return newBalance(address, confirmed, unconfirmed), nil
+}
+
+// GetTransactions returns up to limit fixture transactions in fixture order
+func (c *StaticClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	return normalizeFixtureTransactions(address, c.fixture.Addresses[address].Transactions, limit), nil
+}
+
+// ParseTransactions maps a raw array of fixture transactions for address
+func (c *StaticClient) ParseTransactions(address string, raw []byte) ([]models.Transaction, error) {
+	var txs []models.Transaction
+	if err := json.Unmarshal(raw, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture transactions: %w", err)
+	}
+	return normalizeFixtureTransactions(address, txs, 0), nil
+}
+
+// normalizeFixtureTransactions maps up to limit hand-written fixture transactions onto the client contract
+func normalizeFixtureTransactions(address string, txs []models.Transaction, limit int) []models.Transaction {
+	var transactions []models.Transaction
+	for _, tx := range txs {
+		if limit > 0 && len(transactions) >= limit {
+			break
+		}
+		// Normalize hand-written fixtures onto the client contract
+		tx.Address = address
+		if tx.Type == TypeSent || tx.Type == TypeReceived {
+			tx.Amount = signedAmount(tx.Amount, tx.Type == TypeSent)
+		}
+		tx.Type = transactionType(tx.Amount)
+		if tx.BlockHeight == 0 {
+			tx.Confirmations = 0
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions
+}
+
+// GetTransactionFee returns the fixture fee of a transaction, confirmed when any fixture listing has it mined
+func (c *StaticClient) GetTransactionFee(ctx context.Context, hash string) (*models.TransactionFee, error) {
+	fee, ok := c.fixture.TransactionFees[hash]
+	if !ok {
+		return nil, nil
+	}
+
+	fee.Hash = hash
+	for _, entry := range c.fixture.Addresses {
+		for _, tx := range entry.Transactions {
+			if tx.Hash == hash && tx.BlockHeight > 0 {
+				fee.Confirmed = true
+			}
+		}
+	}
+	return &fee, nil
+}
+
+// GetAddressStats derives statistics from the fixture transactions; output counts are not modelled
+func (c *StaticClient) GetAddressStats(ctx context.Context, address string) (*models.AddressStats, error) {
+	entry, ok := c.fixture.Addresses[address]
+	if !ok {
+		return nil, nil
+	}
+
+	stats := &models.AddressStats{
+		TransactionCount: len(entry.Transactions),
+		UpdatedAt:        time.Now(),
+	}
+	for _, tx := range entry.Transactions {
+		timestamp := tx.Timestamp
+		if tx.Amount < 0 {
+			stats.FirstSeenSpending = earliest(stats.FirstSeenSpending, timestamp)
+			stats.LastSeenSpending = latest(stats.LastSeenSpending, timestamp)
+		} else {
+			stats.FirstSeenReceiving = earliest(stats.FirstSeenReceiving, timestamp)
+			stats.LastSeenReceiving = latest(stats.LastSeenReceiving, timestamp)
+		}
+	}
+
+	return stats, nil
+}
+
+// earliest returns the earlier of current and t
+func earliest(current *time.Time, t time.Time) *time.Time {
+	if current == nil || t.Before(*current) {
+		return &t
+	}
+	return current
+}
+
+// latest returns the later of current and t
+func latest(current *time.Time, t time.Time) *time.Time {
+	if current == nil || t.After(*current) {
+		return &t
+	}
+	return current
+}
+
+// GetChainTip returns the fixture chain tip, or nil when the fixture has none
+func (c *StaticClient) GetChainTip(ctx context.Context) (*models.ChainTip, error) {
+	return c.fixture.Tip, nil
+}
+
+// GetFeeEstimates returns the fixture fee rates
+func (c *StaticClient) GetFeeEstimates() (models.FeeEstimates, error) {
+	if c.fixture.Fees == nil {
+		return models.FeeEstimates{}, fmt.Errorf("fixture has no fee estimates")
+	}
+	return *c.fixture.Fees, nil
+}
+
+// Capabilities reports the features the fixture can back; coinbase transactions are marked in the
+// fixture itself rather than detected
+func (c *StaticClient) Capabilities() models.ClientCapabilities {
+	return models.ClientCapabilities{
+		AddressStats:      true,
+		TransactionImport: true,
+		ChainTip:          c.fixture.Tip != nil,
+		TransactionFees:   len(c.fixture.TransactionFees) > 0,
+		MempoolBalance:    true,
+	}
+}
+
+// IsValidAddress applies the same mainnet validation as the live providers
+func (c *StaticClient) IsValidAddress(ctx context.Context, addr string) bool {
+	_, err := address.Validate(addr, address.Mainnet)
+	return err == nil
+}
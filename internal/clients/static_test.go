@@ -0,0 +1,35 @@
+package clients
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStaticClient(t *testing.T) {
+	client, err := NewStaticClient("testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	balance, err := client.GetBalance(context.Background(), address)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.ConfirmedBalance != 150000 || balance.UnconfirmedBalance != -50000 {
+		t.Errorf("Expected balance derived from transactions, got %+v", balance)
+	}
+
+	transactions, err := client.GetTransactions(context.Background(), address, 1)
+	if err != nil {
+		t.Fatalf("GetTransactions failed: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Address != address {
+		t.Errorf("Expected 1 transaction for %s, got %+v", address, transactions)
+	}
+
+	if client.IsValidAddress(context.Background(), "invalid") {
+		t.Error("Expected invalid address to be rejected")
+	}
+}
@@ -0,0 +1,558 @@
+// Package config loads application settings from the environment
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/repository"
+	"github.com/ihladush/bitcoin/internal/requestid"
+)
+
+// Config holds all runtime settings for the application
+type Config struct {
+	// Network is the Bitcoin network tracked addresses must belong to
+	Network address.Network
+
+	// AllowedAddressTypes restricts which address types may be tracked; empty allows all
+	AllowedAddressTypes []address.Type
+
+	// CleanupInactiveAfter archives empty addresses inactive for this long; zero disables the policy
+	CleanupInactiveAfter time.Duration
+	// ArchiveTransactionsAfter moves deeply confirmed transactions older than this to the archive; zero disables it
+	ArchiveTransactionsAfter time.Duration
+
+	// Provider selects the blockchain data source: "blockchair" or "static"
+	Provider string
+	// StaticFixture is the JSON fixture served by the static provider
+	StaticFixture string
+	// BlockchairBaseURL is the Blockchair API root; it defaults to the API of Network
+	BlockchairBaseURL string
+	// BlockchairAPIKey authenticates Blockchair requests of paid accounts; empty uses the free tier
+	BlockchairAPIKey string
+
+	// IntegrityCheck controls the startup database check: "off", "warn" or "fail"
+	IntegrityCheck string
+
+	// Port is the TCP port the API listens on
+	Port int
+	// SyncInterval is the base delay between background sync runs
+	SyncInterval time.Duration
+
+	// DBPath is the SQLite database file; missing parent directories are created
+	DBPath string
+
+	// DBReadPath is an optional read-only copy of the database serving dashboard reads; empty reads from DBPath
+	DBReadPath string
+
+	// PageLimit and MaxPageLimit are the default and largest page size of transaction listings,
+	// enforced by the repository for every caller
+	PageLimit    int
+	MaxPageLimit int
+
+	// SnapshotDBPath is where balance snapshots are stored; empty uses the main database
+	SnapshotDBPath string
+
+	// SyncConcurrency and SyncRateLimit bound upstream calls shared by all sync paths;
+	// a rate limit of zero means unlimited
+	SyncConcurrency int
+	SyncRateLimit   float64
+
+	// SyncOnStartup runs a full sync in the background as soon as the server starts
+	SyncOnStartup bool
+
+	// InsertBatchSize is how many transactions sync writes per database transaction
+	InsertBatchSize int
+
+	// SyncCooldown is how long a finished address sync answers repeated manual sync requests for the same address
+	SyncCooldown time.Duration
+
+	// SyncLogRetention is how long per-address sync log entries are kept; zero keeps them forever
+	SyncLogRetention time.Duration
+
+	// SyncRunTimeout and SyncFailureBudget stop a full sync run early; zero disables each bound
+	SyncRunTimeout    time.Duration
+	SyncFailureBudget time.Duration
+
+	// SyncBatchSize caps how many addresses a full sync run syncs, picked by priority and staleness; zero syncs all
+	SyncBatchSize int
+
+	// ReadOnly rejects all mutating API requests
+	ReadOnly bool
+
+	// LogLevel is "info" or "debug"; debug also logs every upstream provider request
+	LogLevel string
+
+	// OTLPEndpoint is the OTLP/HTTP collector traces are exported to; empty disables tracing
+	OTLPEndpoint string
+	// ServiceName identifies this service in exported traces
+	ServiceName string
+	// RequestIDHeader is the header request IDs are read from and echoed in
+	RequestIDHeader string
+	// AdminToken is the bearer token required by POST /admin/drain; empty disables the endpoint
+	AdminToken string
+
+	// MaxExportRows caps the rows of a single CSV export; zero allows any size
+	MaxExportRows int
+
+	// RequestTimeout bounds the work done for a single API request; zero disables the deadline
+	RequestTimeout time.Duration
+	// CORSMaxAge is how long browsers may cache a preflight response; zero omits Access-Control-Max-Age
+	CORSMaxAge time.Duration
+
+	// TLSCertFile and TLSKeyFile are the PEM certificate and key the server terminates HTTPS with;
+	// both empty serves plain HTTP
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// MaxTransactionsPerAddress caps stored history per address; zero keeps everything
+	MaxTransactionsPerAddress int
+
+	// FinalDepth is the confirmation depth after which sync stops refreshing a transaction; zero refreshes everything
+	FinalDepth int
+
+	// LabelTemplate derives labels for addresses added without one; empty leaves them unlabelled.
+	// It is checked with services.ParseLabelTemplate when the service is set up.
+	LabelTemplate string
+
+	// InitialSyncMode selects whether adding an address waits for its first sync.
+	// It is checked with services.ParseInitialSyncMode when the service is set up.
+	InitialSyncMode string
+
+	// ExcludeImmatureCoinbase leaves coinbase rewards with fewer than 100 confirmations out of confirmed balances
+	ExcludeImmatureCoinbase bool
+
+	// StatusThresholds sets the confirmation depths for the confirmed and final transaction labels
+	StatusThresholds models.StatusThresholds
+
+	// ConnectionPool tunes HTTP connection reuse towards the blockchain data provider
+	ConnectionPool clients.ConnectionPool
+
+	// SyncFailureAlertThreshold is how many consecutive failed full sync runs mark sync degraded; zero disables it
+	SyncFailureAlertThreshold int
+	// AlertWebhookURL receives sync alerts as JSON; empty logs them instead
+	AlertWebhookURL string
+
+	// ConfirmationWebhookURL receives a notification when a stored transaction reaches ConfirmationTarget
+	// confirmations; empty disables these notifications
+	ConfirmationWebhookURL string
+	ConfirmationTarget     int
+	// NotificationDelivery is "event" to deliver each confirmation notification on its own or "batch" to
+	// deliver those of a sync run together, at most NotificationBatchSize per payload
+	NotificationDelivery  string
+	NotificationBatchSize int
+
+	// BalanceMetrics exposes per-address balance gauges, limited to BalanceMetricsMaxSeries addresses
+	// and, when set, to BalanceMetricsAllowlist
+	BalanceMetrics          bool
+	BalanceMetricsMaxSeries int
+	BalanceMetricsAllowlist []string
+
+	// PreserveHistoryOnReadd keeps the stored history of an archived address when it is added again
+	PreserveHistoryOnReadd bool
+
+	// BalanceCache serves balances stored on each sync instead of aggregating transactions on every read
+	BalanceCache bool
+
+	// FeeEstimatesURL is the mempool.space compatible API used for fee estimates with live providers
+	FeeEstimatesURL string
+
+	// CacheMode selects whether provider responses are recorded to or replayed from disk
+	CacheMode clients.CacheMode
+	CacheDir  string
+	CacheTTL  time.Duration
+}
+
+// Load reads the configuration from environment variables
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	network, err := address.ParseNetwork(getEnv("BTC_NETWORK", string(address.Mainnet)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BTC_NETWORK: %w", err)
+	}
+	cfg.Network = network
+
+	for _, value := range getEnvList("ALLOWED_ADDRESS_TYPES") {
+		t, err := address.ParseType(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ALLOWED_ADDRESS_TYPES: %w", err)
+		}
+		cfg.AllowedAddressTypes = append(cfg.AllowedAddressTypes, t)
+	}
+
+	if cfg.CleanupInactiveAfter, err = getEnvDuration("CLEANUP_INACTIVE_AFTER", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ArchiveTransactionsAfter, err = getEnvDuration("ARCHIVE_TRANSACTIONS_AFTER", 0); err != nil {
+		return nil, err
+	}
+
+	cfg.Provider = getEnv("BTC_PROVIDER", "blockchair")
+	cfg.StaticFixture = os.Getenv("STATIC_FIXTURE")
+	cfg.BlockchairAPIKey = os.Getenv("BLOCKCHAIR_API_KEY")
+	defaultBaseURL := clients.BlockchairMainnetURL
+	if cfg.Network == address.Testnet {
+		defaultBaseURL = clients.BlockchairTestnetURL
+	}
+	cfg.BlockchairBaseURL = getEnv("BLOCKCHAIR_BASE_URL", defaultBaseURL)
+	switch cfg.Provider {
+	case "blockchair":
+		// Blockchair has no regtest API, so regtest instances point it at a mock of either network
+		if served := clients.BlockchairNetwork(cfg.BlockchairBaseURL); cfg.Network != address.Regtest && served != cfg.Network {
+			return nil, fmt.Errorf("invalid BLOCKCHAIR_BASE_URL: serves %s but BTC_NETWORK is %s", served, cfg.Network)
+		}
+	case "static":
+		if cfg.StaticFixture == "" {
+			return nil, fmt.Errorf("STATIC_FIXTURE is required when BTC_PROVIDER=static")
+		}
+	default:
+		return nil, fmt.Errorf("invalid BTC_PROVIDER: %q", cfg.Provider)
+	}
+
+	cfg.ConnectionPool = clients.DefaultConnectionPool
+	if cfg.ConnectionPool.MaxIdleConns, err = getEnvInt("HTTP_MAX_IDLE_CONNS", cfg.ConnectionPool.MaxIdleConns); err != nil {
+		return nil, err
+	}
+	if cfg.ConnectionPool.MaxIdleConnsPerHost, err = getEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", cfg.ConnectionPool.MaxIdleConnsPerHost); err != nil {
+		return nil, err
+	}
+	if cfg.ConnectionPool.IdleConnTimeout, err = getEnvDuration("HTTP_IDLE_CONN_TIMEOUT", cfg.ConnectionPool.IdleConnTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.ConnectionPool.MaxIdleConns < 0 || cfg.ConnectionPool.MaxIdleConnsPerHost < 0 || cfg.ConnectionPool.IdleConnTimeout < 0 {
+		return nil, fmt.Errorf("invalid HTTP connection pool settings: values must not be negative")
+	}
+
+	if cfg.SyncFailureAlertThreshold, err = getEnvInt("SYNC_FAILURE_ALERT_THRESHOLD", 3); err != nil {
+		return nil, err
+	}
+	if cfg.SyncFailureAlertThreshold < 0 {
+		return nil, fmt.Errorf("invalid SYNC_FAILURE_ALERT_THRESHOLD: must not be negative")
+	}
+	cfg.AlertWebhookURL = os.Getenv("ALERT_WEBHOOK_URL")
+
+	cfg.ConfirmationWebhookURL = os.Getenv("CONFIRMATION_WEBHOOK_URL")
+	if cfg.ConfirmationTarget, err = getEnvInt("CONFIRMATION_TARGET", 6); err != nil {
+		return nil, err
+	}
+	if cfg.ConfirmationTarget < 1 {
+		return nil, fmt.Errorf("invalid CONFIRMATION_TARGET: must be at least 1")
+	}
+
+	switch cfg.NotificationDelivery = strings.ToLower(getEnv("NOTIFICATION_DELIVERY", "event")); cfg.NotificationDelivery {
+	case "event", "batch":
+	default:
+		return nil, fmt.Errorf("invalid NOTIFICATION_DELIVERY: %q (expected event or batch)", cfg.NotificationDelivery)
+	}
+	if cfg.NotificationBatchSize, err = getEnvInt("NOTIFICATION_BATCH_SIZE", 100); err != nil {
+		return nil, err
+	}
+	if cfg.NotificationBatchSize < 1 {
+		return nil, fmt.Errorf("invalid NOTIFICATION_BATCH_SIZE: must be at least 1")
+	}
+
+	if cfg.BalanceMetrics, err = getEnvBool("BALANCE_METRICS", false); err != nil {
+		return nil, err
+	}
+	if cfg.BalanceMetricsMaxSeries, err = getEnvInt("BALANCE_METRICS_MAX_SERIES", 100); err != nil {
+		return nil, err
+	}
+	if cfg.BalanceMetricsMaxSeries < 1 {
+		return nil, fmt.Errorf("invalid BALANCE_METRICS_MAX_SERIES: must be at least 1")
+	}
+	for _, value := range getEnvList("BALANCE_METRICS_ALLOWLIST") {
+		cfg.BalanceMetricsAllowlist = append(cfg.BalanceMetricsAllowlist, address.Normalize(value))
+	}
+
+	if cfg.PreserveHistoryOnReadd, err = getEnvBool("PRESERVE_HISTORY_ON_READD", true); err != nil {
+		return nil, err
+	}
+	if cfg.BalanceCache, err = getEnvBool("BALANCE_CACHE", true); err != nil {
+		return nil, err
+	}
+
+	cfg.FeeEstimatesURL = getEnv("FEE_ESTIMATES_URL", "https://mempool.space/api")
+
+	if cfg.CacheMode, err = clients.ParseCacheMode(os.Getenv("CACHE_MODE")); err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MODE: %w", err)
+	}
+	cfg.CacheDir = getEnv("CACHE_DIR", ".cache/provider")
+	if cfg.CacheTTL, err = getEnvDuration("CACHE_TTL", 10*time.Minute); err != nil {
+		return nil, err
+	}
+
+	// The BITCOIN_ names win over the older unprefixed ones
+	if cfg.Port, err = getEnvInt("BITCOIN_PORT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Port == 0 {
+		if cfg.Port, err = getEnvInt("PORT", 8080); err != nil {
+			return nil, err
+		}
+	}
+	cfg.DBPath = getEnv("BITCOIN_DB_PATH", getEnv("DB_PATH", "bitcoin_tracker.db"))
+	if cfg.SyncInterval, err = getEnvDuration("BITCOIN_SYNC_INTERVAL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateServer(); err != nil {
+		return nil, err
+	}
+	cfg.DBReadPath = os.Getenv("DB_READ_PATH")
+	cfg.SnapshotDBPath = os.Getenv("SNAPSHOT_DB_PATH")
+
+	if cfg.PageLimit, err = getEnvInt("PAGE_LIMIT", repository.DefaultPageLimit); err != nil {
+		return nil, err
+	}
+	if cfg.MaxPageLimit, err = getEnvInt("MAX_PAGE_LIMIT", repository.DefaultMaxPageLimit); err != nil {
+		return nil, err
+	}
+	if cfg.PageLimit < 1 || cfg.MaxPageLimit < cfg.PageLimit {
+		return nil, fmt.Errorf("invalid PAGE_LIMIT or MAX_PAGE_LIMIT: need 1 <= PAGE_LIMIT <= MAX_PAGE_LIMIT")
+	}
+
+	cfg.IntegrityCheck = getEnv("INTEGRITY_CHECK", "off")
+	switch cfg.IntegrityCheck {
+	case "off", "warn", "fail":
+	default:
+		return nil, fmt.Errorf("invalid INTEGRITY_CHECK: %q", cfg.IntegrityCheck)
+	}
+
+	if cfg.SyncConcurrency, err = getEnvInt("SYNC_CONCURRENCY", 2); err != nil {
+		return nil, err
+	}
+	if cfg.SyncConcurrency < 1 {
+		return nil, fmt.Errorf("invalid SYNC_CONCURRENCY: must be at least 1")
+	}
+	if cfg.SyncRateLimit, err = getEnvFloat("SYNC_RATE_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SyncRateLimit < 0 {
+		return nil, fmt.Errorf("invalid SYNC_RATE_LIMIT: must not be negative")
+	}
+
+	if cfg.SyncOnStartup, err = getEnvBool("SYNC_ON_STARTUP", false); err != nil {
+		return nil, err
+	}
+
+	if cfg.InsertBatchSize, err = getEnvInt("SYNC_INSERT_BATCH_SIZE", 500); err != nil {
+		return nil, err
+	}
+	if cfg.InsertBatchSize < 1 {
+		return nil, fmt.Errorf("invalid SYNC_INSERT_BATCH_SIZE: must be at least 1")
+	}
+
+	if cfg.SyncCooldown, err = getEnvDuration("SYNC_COOLDOWN", 10*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.SyncLogRetention, err = getEnvDuration("SYNC_LOG_RETENTION", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.SyncRunTimeout, err = getEnvDuration("SYNC_RUN_TIMEOUT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SyncFailureBudget, err = getEnvDuration("SYNC_FAILURE_BUDGET", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SyncBatchSize, err = getEnvInt("SYNC_BATCH_SIZE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SyncBatchSize < 0 {
+		return nil, fmt.Errorf("invalid SYNC_BATCH_SIZE: must not be negative")
+	}
+
+	if cfg.ReadOnly, err = getEnvBool("READ_ONLY", false); err != nil {
+		return nil, err
+	}
+
+	switch cfg.LogLevel = strings.ToLower(getEnv("LOG_LEVEL", "info")); cfg.LogLevel {
+	case "info", "debug":
+	default:
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %q (expected info or debug)", cfg.LogLevel)
+	}
+
+	cfg.OTLPEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	cfg.ServiceName = getEnv("OTEL_SERVICE_NAME", "bitcoin-tracker")
+	cfg.RequestIDHeader = getEnv("REQUEST_ID_HEADER", requestid.DefaultHeader)
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+
+	if cfg.MaxExportRows, err = getEnvInt("CSV_EXPORT_MAX_ROWS", 100000); err != nil {
+		return nil, err
+	}
+	if cfg.MaxExportRows < 0 {
+		return nil, fmt.Errorf("invalid CSV_EXPORT_MAX_ROWS: must not be negative")
+	}
+
+	if cfg.RequestTimeout, err = getEnvDuration("REQUEST_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.CORSMaxAge, err = getEnvDuration("CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return nil, err
+	}
+
+	cfg.TLSCertFile = os.Getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TLS_KEY_FILE")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("invalid TLS settings: TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if cfg.MaxTransactionsPerAddress, err = getEnvInt("MAX_TRANSACTIONS_PER_ADDRESS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.MaxTransactionsPerAddress < 0 {
+		return nil, fmt.Errorf("invalid MAX_TRANSACTIONS_PER_ADDRESS: must not be negative")
+	}
+
+	if cfg.FinalDepth, err = getEnvInt("FINAL_DEPTH", 0); err != nil {
+		return nil, err
+	}
+	if cfg.FinalDepth < 0 {
+		return nil, fmt.Errorf("invalid FINAL_DEPTH: must not be negative")
+	}
+	// Final transactions are no longer compared, so they could never be seen crossing the target
+	if cfg.ConfirmationWebhookURL != "" && cfg.FinalDepth > 0 && cfg.ConfirmationTarget >= cfg.FinalDepth {
+		return nil, fmt.Errorf("invalid CONFIRMATION_TARGET: must be below FINAL_DEPTH")
+	}
+
+	cfg.LabelTemplate = os.Getenv("DEFAULT_LABEL_TEMPLATE")
+	cfg.InitialSyncMode = os.Getenv("INITIAL_SYNC_MODE")
+
+	if cfg.ExcludeImmatureCoinbase, err = getEnvBool("EXCLUDE_IMMATURE_COINBASE", false); err != nil {
+		return nil, err
+	}
+
+	thresholds := models.DefaultStatusThresholds
+	if thresholds.Confirmed, err = getEnvInt("STATUS_CONFIRMED_THRESHOLD", thresholds.Confirmed); err != nil {
+		return nil, err
+	}
+	if thresholds.Final, err = getEnvInt("STATUS_FINAL_THRESHOLD", thresholds.Final); err != nil {
+		return nil, err
+	}
+	if thresholds.Confirmed < 1 || thresholds.Final <= thresholds.Confirmed {
+		return nil, fmt.Errorf("invalid status thresholds: need 1 <= STATUS_CONFIRMED_THRESHOLD < STATUS_FINAL_THRESHOLD")
+	}
+	cfg.StatusThresholds = thresholds
+
+	return cfg, nil
+}
+
+// ApplyFlags overrides the port, database path and sync interval with command-line flags, which
+// default to the values loaded from the environment
+func (c *Config) ApplyFlags(args []string) error {
+	flags := flag.NewFlagSet("bitcoin-tracker", flag.ContinueOnError)
+	flags.IntVar(&c.Port, "port", c.Port, "TCP port to listen on (BITCOIN_PORT)")
+	flags.StringVar(&c.DBPath, "db", c.DBPath, "SQLite database file (BITCOIN_DB_PATH)")
+	flags.DurationVar(&c.SyncInterval, "sync-interval", c.SyncInterval, "delay between background sync runs (BITCOIN_SYNC_INTERVAL)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	return c.validateServer()
+}
+
+// validateServer checks the settings that can come from either the environment or flags
+func (c *Config) validateServer() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", c.Port)
+	}
+	if c.DBPath == "" {
+		return fmt.Errorf("invalid database path: must not be empty")
+	}
+	if c.SyncInterval <= 0 {
+		return fmt.Errorf("invalid sync interval %s: must be positive", c.SyncInterval)
+	}
+	return nil
+}
+
+// getEnvFloat parses a floating point environment variable, returning fallback when unset
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return f, nil
+}
+
+// getEnvBool parses a boolean environment variable, returning fallback when unset
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return b, nil
+}
+
+// getEnvInt parses an integer environment variable, returning fallback when unset
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return n, nil
+}
+
+// getEnv returns an environment variable or fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration parses a duration environment variable, returning fallback when unset
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", key)
+	}
+
+	return d, nil
+}
+
+// getEnvList splits a comma-separated environment variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
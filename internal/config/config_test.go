@@ -0,0 +1,178 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// serverEnv lists the variables the port, database path and sync interval are read from
+var serverEnv = []string{"BITCOIN_PORT", "PORT", "BITCOIN_DB_PATH", "DB_PATH", "BITCOIN_SYNC_INTERVAL", "SYNC_INTERVAL"}
+
+// setServerEnv clears the server variables and then sets env for the duration of the test
+func setServerEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	for _, key := range serverEnv {
+		t.Setenv(key, "")
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func TestServerSettingsPrecedence(t *testing.T) {
+	testCases := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantPort     int
+		wantDBPath   string
+		wantInterval time.Duration
+	}{
+		{
+			name:     "defaults",
+			wantPort: 8080, wantDBPath: "bitcoin_tracker.db", wantInterval: 5 * time.Minute,
+		},
+		{
+			name:     "legacy names",
+			env:      map[string]string{"PORT": "8081", "DB_PATH": "legacy.db"},
+			wantPort: 8081, wantDBPath: "legacy.db", wantInterval: 5 * time.Minute,
+		},
+		{
+			name: "prefixed names win over legacy ones",
+			env: map[string]string{
+				"PORT": "8081", "DB_PATH": "legacy.db",
+				"BITCOIN_PORT": "8082", "BITCOIN_DB_PATH": "prefixed.db", "BITCOIN_SYNC_INTERVAL": "90s",
+			},
+			wantPort: 8082, wantDBPath: "prefixed.db", wantInterval: 90 * time.Second,
+		},
+		{
+			name:     "unprefixed sync interval is ignored",
+			env:      map[string]string{"SYNC_INTERVAL": "1m"},
+			wantPort: 8080, wantDBPath: "bitcoin_tracker.db", wantInterval: 5 * time.Minute,
+		},
+		{
+			name: "flags win over the environment",
+			env: map[string]string{
+				"PORT": "8081", "DB_PATH": "legacy.db",
+				"BITCOIN_PORT": "8082", "BITCOIN_DB_PATH": "prefixed.db", "BITCOIN_SYNC_INTERVAL": "90s",
+			},
+			args:     []string{"-port", "9090", "-db", "flag.db", "-sync-interval", "10m"},
+			wantPort: 9090, wantDBPath: "flag.db", wantInterval: 10 * time.Minute,
+		},
+		{
+			name:     "unset flags keep the environment",
+			env:      map[string]string{"BITCOIN_PORT": "8082", "DB_PATH": "legacy.db", "BITCOIN_SYNC_INTERVAL": "90s"},
+			args:     []string{"-port", "9090"},
+			wantPort: 9090, wantDBPath: "legacy.db", wantInterval: 90 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setServerEnv(t, tc.env)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if err := cfg.ApplyFlags(tc.args); err != nil {
+				t.Fatalf("ApplyFlags(%q) failed: %v", tc.args, err)
+			}
+
+			if cfg.Port != tc.wantPort || cfg.DBPath != tc.wantDBPath || cfg.SyncInterval != tc.wantInterval {
+				t.Errorf("Got port %d, database %q and sync interval %s; want %d, %q and %s",
+					cfg.Port, cfg.DBPath, cfg.SyncInterval, tc.wantPort, tc.wantDBPath, tc.wantInterval)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidServerSettings(t *testing.T) {
+	testCases := []map[string]string{
+		{"BITCOIN_PORT": "-1"},
+		{"PORT": "65536"},
+		{"BITCOIN_PORT": "http"},
+		{"BITCOIN_SYNC_INTERVAL": "0s"},
+		{"BITCOIN_SYNC_INTERVAL": "-1m"},
+		{"BITCOIN_SYNC_INTERVAL": "5"},
+	}
+
+	for _, env := range testCases {
+		setServerEnv(t, env)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected Load to reject %v", env)
+		}
+	}
+}
+
+func TestApplyFlagsRejectsInvalidServerSettings(t *testing.T) {
+	testCases := [][]string{
+		{"-port", "0"},
+		{"-port", "70000"},
+		{"-db", ""},
+		{"-sync-interval", "0s"},
+		{"-sync-interval", "-1m"},
+		{"-verbose"},
+		{"serve"},
+	}
+
+	for _, args := range testCases {
+		setServerEnv(t, nil)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if err := cfg.ApplyFlags(args); err == nil {
+			t.Errorf("Expected ApplyFlags(%q) to fail", args)
+		}
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	valid := Config{Port: 8080, DBPath: "bitcoin_tracker.db", SyncInterval: 5 * time.Minute}
+
+	testCases := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"valid", func(*Config) {}, false},
+		{"lowest port", func(c *Config) { c.Port = 1 }, false},
+		{"highest port", func(c *Config) { c.Port = 65535 }, false},
+		{"port zero", func(c *Config) { c.Port = 0 }, true},
+		{"negative port", func(c *Config) { c.Port = -1 }, true},
+		{"port too high", func(c *Config) { c.Port = 65536 }, true},
+		{"empty database path", func(c *Config) { c.DBPath = "" }, true},
+		{"zero sync interval", func(c *Config) { c.SyncInterval = 0 }, true},
+		{"negative sync interval", func(c *Config) { c.SyncInterval = -time.Second }, true},
+	}
+
+	for _, tc := range testCases {
+		cfg := valid
+		tc.modify(&cfg)
+		if err := cfg.validateServer(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: validateServer() = %v; want error %t", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestLoadRequiresTLSCertAndKeyTogether(t *testing.T) {
+	testCases := []struct {
+		cert, key string
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"server.crt", "server.key", false},
+		{"server.crt", "", true},
+		{"", "server.key", true},
+	}
+
+	for _, tc := range testCases {
+		setServerEnv(t, map[string]string{"TLS_CERT_FILE": tc.cert, "TLS_KEY_FILE": tc.key})
+		_, err := Load()
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("Load with TLS_CERT_FILE=%q and TLS_KEY_FILE=%q: error %v; want error %v", tc.cert, tc.key, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/services"
+)
+
+// Cleanup handles POST /admin/cleanup
+func (h *BitcoinHandler) Cleanup(w http.ResponseWriter, r *http.Request) {
+	var olderThan time.Duration
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			h.writeError(w, r, http.StatusBadRequest, "Invalid older_than duration")
+			return
+		}
+		olderThan = d
+	}
+
+	archived, err := h.service.CleanupInactiveAddresses(r.Context(), olderThan)
+	if err != nil {
+		if errors.Is(err, services.ErrCleanupDisabled) {
+			h.writeError(w, r, http.StatusBadRequest, "Cleanup is not configured; pass older_than or set CLEANUP_INACTIVE_AFTER")
+			return
+		}
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if archived == nil {
+		archived = []string{}
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.CleanupResult{
+		Archived: archived,
+		Count:    len(archived),
+	})
+}
+
+// ArchiveTransactions handles POST /admin/archive-transactions
+func (h *BitcoinHandler) ArchiveTransactions(w http.ResponseWriter, r *http.Request) {
+	var olderThan time.Duration
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			h.writeError(w, r, http.StatusBadRequest, "Invalid older_than duration")
+			return
+		}
+		olderThan = d
+	}
+
+	archived, err := h.service.ArchiveOldTransactions(r.Context(), olderThan)
+	if err != nil {
+		if errors.Is(err, services.ErrArchiveDisabled) {
+			h.writeError(w, r, http.StatusBadRequest, "Archiving is not configured; pass older_than or set ARCHIVE_TRANSACTIONS_AFTER")
+			return
+		}
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.TransactionArchiveResult{Archived: archived})
+}
+
+// RecalculateBalances handles POST /admin/recalculate
+func (h *BitcoinHandler) RecalculateBalances(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.StartRecalculation(r.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrRecalculationRunning) {
+			h.writeFailure(w, r, http.StatusConflict, err.Error(), job)
+			return
+		}
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	// The run outlives the request; its progress is at GET /admin/recalculate
+	w.Header().Set("Location", r.URL.Path)
+	h.writeSuccess(w, r, http.StatusAccepted, job)
+}
+
+// GetRecalculation handles GET /admin/recalculate
+func (h *BitcoinHandler) GetRecalculation(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.GetRecalculation()
+	if err != nil {
+		if errors.Is(err, services.ErrNoRecalculation) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, job)
+}
+
+// BackfillTypes handles POST /admin/backfill-types
+func (h *BitcoinHandler) BackfillTypes(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.BackfillAddressTypes(r.Context())
+	if err != nil {
+		// Report the addresses already processed; running again picks up the rest
+		status := http.StatusInternalServerError
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		h.writeFailure(w, r, status, err.Error(), result)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, result)
+}
+
+// RestoreAddress handles POST /admin/addresses/{address}/restore
+func (h *BitcoinHandler) RestoreAddress(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	if err := h.service.RestoreAddress(r.Context(), address); err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeMessage(w, r, http.StatusOK, "Address restored successfully")
+}
+
+// ResetSync handles POST /admin/addresses/{address}/reset-sync
+func (h *BitcoinHandler) ResetSync(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	syncedAt, err := parseTimeParam(r.URL.Query().Get("synced_at"))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid synced_at; use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	if syncedAt != nil && syncedAt.After(time.Now()) {
+		h.writeError(w, r, http.StatusBadRequest, "synced_at cannot be in the future")
+		return
+	}
+
+	if err := h.service.ResetLastSynced(r.Context(), address, syncedAt); err != nil {
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		} else {
+			h.writeServiceError(w, r, err)
+		}
+		return
+	}
+
+	h.writeMessage(w, r, http.StatusOK, "Address sync state reset successfully")
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// maxBatchOperations caps how many operations a single batch request may contain
+const maxBatchOperations = 100
+
+// batchMethod runs one batch operation with its raw parameters
+type batchMethod func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// batchMethods maps operation names to the service calls they perform
+var batchMethods = map[string]batchMethod{
+	"list_addresses": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return h.service.GetAllAddresses(ctx)
+	},
+	"get_address": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.BatchAddressParams
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.service.GetAddress(address.Normalize(p.Address))
+	},
+	"add_address": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.AddAddressRequest
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		p.Address = address.Normalize(p.Address)
+		if p.Address == "" {
+			return nil, errors.New("address is required")
+		}
+		return h.service.AddAddress(ctx, p)
+	},
+	"get_balance": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.BatchAddressParams
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.service.GetBalance(ctx, address.Normalize(p.Address))
+	},
+	"get_transactions": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.BatchTransactionsParams
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		transactions, err := h.service.GetTransactions(ctx, address.Normalize(p.Address), p.Limit, max(p.Offset, 0))
+		if err != nil {
+			return nil, err
+		}
+		return models.NewTransactionResponses(transactions, h.thresholds), nil
+	},
+	"sync_address": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.BatchAddressParams
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		addr := address.Normalize(p.Address)
+		if err := h.service.SyncAddress(ctx, addr); err != nil {
+			return nil, err
+		}
+		return h.service.GetBalance(ctx, addr)
+	},
+	"get_portfolio": func(h *BitcoinHandler, ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p models.BatchPortfolioParams
+		if err := decodeBatchParams(params, &p); err != nil {
+			return nil, err
+		}
+		return h.service.GetPortfolio(ctx, p.OwnedOnly)
+	},
+}
+
+// decodeBatchParams unmarshals operation parameters, treating missing parameters as empty
+func decodeBatchParams(params json.RawMessage, out interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, out); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// Batch handles POST /batch
+func (h *BitcoinHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var ops []models.BatchOperation
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body; expected an array of operations")
+		return
+	}
+
+	if len(ops) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "At least one operation is required")
+		return
+	}
+	if len(ops) > maxBatchOperations {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d operations can be sent per batch", maxBatchOperations))
+		return
+	}
+
+	// Run operations in order; each one succeeds or fails on its own
+	results := make([]models.APIResponse, len(ops))
+	for i, op := range ops {
+		method, ok := batchMethods[op.Method]
+		if !ok {
+			results[i] = models.ErrorResponse(fmt.Sprintf("unknown method %q; supported methods: %s", op.Method, batchMethodNames()))
+			continue
+		}
+
+		data, err := method(h, r.Context(), op.Params)
+		if err != nil {
+			results[i] = models.ErrorResponse(err.Error())
+			continue
+		}
+		results[i] = models.SuccessResponse(data)
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, results)
+}
+
+// batchMethodNames lists the supported batch methods in a stable order
+func batchMethodNames() string {
+	names := make([]string, 0, len(batchMethods))
+	for name := range batchMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestBatch(t *testing.T) {
+	const funded = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	router := newTestRouter(t)
+
+	body := `[
+		{"method": "add_address", "params": {"address": "` + funded + `"}},
+		{"method": "get_address", "params": {"address": "` + funded + `"}},
+		{"method": "get_balance", "params": {"address": "` + funded + `"}},
+		{"method": "get_transactions", "params": {"address": "` + funded + `", "limit": 1}},
+		{"method": "sync_address", "params": {"address": "` + funded + `"}},
+		{"method": "list_addresses"},
+		{"method": "get_portfolio", "params": {"owned_only": false}},
+		{"method": "get_address", "params": {"address": "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"}},
+		{"method": "add_address", "params": {}},
+		{"method": "get_balance", "params": "` + funded + `"},
+		{"method": "drop_tables"}
+	]`
+	rec := serve(router, "POST", "/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var response struct {
+		Data []models.APIResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	results := response.Data
+	if len(results) != 11 {
+		t.Fatalf("Expected a result per operation, got %d: %s", len(results), rec.Body)
+	}
+
+	// Each operation succeeds or fails on its own, in request order
+	for i, result := range results[:7] {
+		if !result.Success || result.Data == nil {
+			t.Errorf("Expected operation %d to succeed, got %+v", i, result)
+		}
+	}
+	for i, wantErr := range []string{"not found", "address is required", "invalid params", `unknown method "drop_tables"`} {
+		if result := results[7+i]; result.Success || !strings.Contains(result.Error, wantErr) {
+			t.Errorf("Expected operation %d to fail with %q, got %+v", 7+i, wantErr, result)
+		}
+	}
+
+	balance, _ := json.Marshal(results[2].Data)
+	if !strings.Contains(string(balance), `"total_balance"`) {
+		t.Errorf("Expected get_balance to return the balance, got %s", balance)
+	}
+	transactions, _ := json.Marshal(results[3].Data)
+	var page []json.RawMessage
+	if err := json.Unmarshal(transactions, &page); err != nil || len(page) != 1 {
+		t.Errorf("Expected get_transactions to honour its limit, got %s", transactions)
+	}
+}
+
+func TestBatchRejectsInvalidRequests(t *testing.T) {
+	router := newTestRouter(t)
+
+	tooMany := "[" + strings.Repeat(`{"method": "list_addresses"},`, maxBatchOperations) + `{"method": "list_addresses"}]`
+	for _, body := range []string{`[]`, `{"method": "list_addresses"}`, `not json`, tooMany} {
+		if rec := serve(router, "POST", "/batch", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.40s..., got %d: %s", body, rec.Code, rec.Body)
+		}
+	}
+
+	atLimit := "[" + strings.Repeat(`{"method": "list_addresses"},`, maxBatchOperations-1) + `{"method": "list_addresses"}]`
+	if rec := serve(router, "POST", "/batch", atLimit); rec.Code != http.StatusOK {
+		t.Errorf("Expected %d operations to be accepted, got %d: %s", maxBatchOperations, rec.Code, rec.Body)
+	}
+}
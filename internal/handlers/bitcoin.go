@@ -2,126 +2,580 @@
 package handlers
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ihladush/bitcoin/internal/address"
 	"github.com/ihladush/bitcoin/internal/models"
 	"github.com/ihladush/bitcoin/internal/services"
 )
 
 // BitcoinHandler handles HTTP requests for Bitcoin tracking
 type BitcoinHandler struct {
-	service *services.BitcoinService
+	service    *services.BitcoinService
+	thresholds models.StatusThresholds
+	drain      *Drain
+	adminToken string
+}
+
+// Option configures optional BitcoinHandler behavior
+type Option func(*BitcoinHandler)
+
+// WithStatusThresholds sets the confirmation depths used to label transaction status
+func WithStatusThresholds(thresholds models.StatusThresholds) Option {
+	return func(h *BitcoinHandler) {
+		h.thresholds = thresholds
+	}
 }
 
 // NewBitcoinHandler creates a new Bitcoin handler
-func NewBitcoinHandler(service *services.BitcoinService) *BitcoinHandler {
-	return &BitcoinHandler{service: service}
+func NewBitcoinHandler(service *services.BitcoinService, opts ...Option) *BitcoinHandler {
+	h := &BitcoinHandler{
+		service:    service,
+		thresholds: models.DefaultStatusThresholds,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// addressVar returns the normalized {address} path parameter
+func addressVar(r *http.Request) string {
+	return address.Normalize(mux.Vars(r)["address"])
 }
 
 // AddAddress handles POST /addresses
 func (h *BitcoinHandler) AddAddress(w http.ResponseWriter, r *http.Request) {
 	var req models.AddAddressRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	req.Address = address.Normalize(req.Address)
 
 	if req.Address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address is required")
 		return
 	}
 
-	address, err := h.service.AddAddress(req.Address, req.Label)
+	added, err := h.service.AddAddress(r.Context(), req)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, err.Error())
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	h.writeSuccess(w, http.StatusCreated, address)
+	// Once the initial sync has completed, respond with the funded state straight away
+	if added.LastSynced != nil {
+		if withBalance, err := h.service.GetAddress(added.Address); err == nil {
+			h.writeSuccess(w, r, http.StatusCreated, withBalance)
+			return
+		}
+	}
+
+	h.writeSuccess(w, r, http.StatusCreated, added)
+}
+
+// maxBulkAdd caps how many addresses a single bulk add may contain
+const maxBulkAdd = 1000
+
+// AddAddresses handles POST /addresses/bulk
+func (h *BitcoinHandler) AddAddresses(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "At least one address is required")
+		return
+	}
+	if len(req.Addresses) > maxBulkAdd {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d addresses can be added per request", maxBulkAdd))
+		return
+	}
+
+	for i := range req.Addresses {
+		req.Addresses[i].Address = address.Normalize(req.Addresses[i].Address)
+	}
+
+	result := models.BulkAddResult{Results: h.service.AddAddresses(r.Context(), req.Addresses)}
+	for _, item := range result.Results {
+		if item.Status == models.BulkStatusCreated {
+			result.Created++
+		} else {
+			result.Failed++
+		}
+	}
+
+	// Report partial or total failure as multi-status so clients inspect each entry
+	status := http.StatusCreated
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	h.writeSuccess(w, r, status, result)
 }
 
 // RemoveAddress handles DELETE /addresses/{address}
 func (h *BitcoinHandler) RemoveAddress(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	address := vars["address"]
+	address := addressVar(r)
 
 	if address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address parameter is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
+		return
+	}
+
+	soft, _ := strconv.ParseBool(r.URL.Query().Get("soft"))
+	if err := h.service.RemoveAddress(address, soft); err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeMessage(w, r, http.StatusOK, "Address removed successfully")
+}
+
+// maxBatchRemove caps how many addresses a single batch delete may name
+const maxBatchRemove = 1000
+
+// RemoveAddresses handles POST /addresses/delete
+func (h *BitcoinHandler) RemoveAddresses(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRemoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "At least one address is required")
 		return
 	}
+	if len(req.Addresses) > maxBatchRemove {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d addresses can be removed per request", maxBatchRemove))
+		return
+	}
+
+	for i := range req.Addresses {
+		req.Addresses[i] = address.Normalize(req.Addresses[i])
+	}
 
-	if err := h.service.RemoveAddress(address); err != nil {
-		h.writeError(w, http.StatusNotFound, err.Error())
+	soft, _ := strconv.ParseBool(r.URL.Query().Get("soft"))
+	result, err := h.service.RemoveAddresses(req.Addresses, soft)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	h.writeMessage(w, http.StatusOK, "Address removed successfully")
+	h.writeSuccess(w, r, http.StatusOK, result)
+}
+
+// maxBatchTag caps how many addresses a single bulk tag change may name
+const maxBatchTag = 1000
+
+// TagAddresses handles POST /tags/{tag}/addresses
+func (h *BitcoinHandler) TagAddresses(w http.ResponseWriter, r *http.Request) {
+	h.assignTag(w, r, h.service.TagAddresses)
+}
+
+// UntagAddresses handles POST /tags/{tag}/addresses/delete
+func (h *BitcoinHandler) UntagAddresses(w http.ResponseWriter, r *http.Request) {
+	h.assignTag(w, r, h.service.UntagAddresses)
+}
+
+// assignTag reads a list of addresses and applies a bulk tag change to them
+func (h *BitcoinHandler) assignTag(w http.ResponseWriter, r *http.Request, apply func(tag string, addresses []string) (*models.TagAssignmentResult, error)) {
+	var req models.TagAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "At least one address is required")
+		return
+	}
+	if len(req.Addresses) > maxBatchTag {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d addresses can be tagged per request", maxBatchTag))
+		return
+	}
+
+	for i := range req.Addresses {
+		req.Addresses[i] = address.Normalize(req.Addresses[i])
+	}
+
+	result, err := apply(mux.Vars(r)["tag"], req.Addresses)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTag) {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, result)
+}
+
+// GetTaggedAddresses handles GET /tags/{tag}/addresses
+func (h *BitcoinHandler) GetTaggedAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.service.GetTaggedAddresses(mux.Vars(r)["tag"])
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTag) {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, addresses)
 }
 
 // GetAllAddresses handles GET /addresses
 func (h *BitcoinHandler) GetAllAddresses(w http.ResponseWriter, r *http.Request) {
-	addresses, err := h.service.GetAllAddresses()
+	if withBalance, err := strconv.ParseBool(r.URL.Query().Get("with_balance")); err == nil && !withBalance {
+		addresses, err := h.service.ListAddresses(r.Context())
+		if err != nil {
+			h.writeError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		h.writeSuccess(w, r, http.StatusOK, addresses)
+		return
+	}
+
+	addresses, err := h.service.GetAllAddresses(r.Context())
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, addresses)
+}
+
+// UpdateAddress handles PUT /addresses/{address}
+func (h *BitcoinHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	var req models.UpdateAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	updated, err := h.service.UpdateAddress(address, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPriority):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrAddressNotFound):
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		default:
+			h.writeServiceError(w, r, err)
+		}
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, updated)
+}
+
+// CreateOwnershipChallenge handles POST /addresses/{address}/verify/challenge
+func (h *BitcoinHandler) CreateOwnershipChallenge(w http.ResponseWriter, r *http.Request) {
+	challenge, err := h.service.CreateOwnershipChallenge(addressVar(r))
+	if err != nil {
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusCreated, challenge)
+}
+
+// VerifyOwnership handles POST /addresses/{address}/verify
+func (h *BitcoinHandler) VerifyOwnership(w http.ResponseWriter, r *http.Request) {
+	addr := addressVar(r)
+
+	var req models.VerifyOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Signature == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Request body must contain a challenge message and its signature")
+		return
+	}
+
+	verified, err := h.service.VerifyOwnership(addr, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, address.ErrSignatureMismatch):
+			h.writeError(w, r, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrInvalidSignature), errors.Is(err, services.ErrInvalidChallenge):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrAddressNotFound):
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		default:
+			h.writeServiceError(w, r, err)
+		}
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, verified)
+}
+
+// GetPortfolio handles GET /portfolio
+func (h *BitcoinHandler) GetPortfolio(w http.ResponseWriter, r *http.Request) {
+	ownedOnly, _ := strconv.ParseBool(r.URL.Query().Get("owned_only"))
+
+	portfolio, err := h.service.GetPortfolio(r.Context(), ownedOnly)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, portfolio)
+}
+
+// GetPortfolioHistory handles GET /portfolio/history
+func (h *BitcoinHandler) GetPortfolioHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ownedOnly, _ := strconv.ParseBool(query.Get("owned_only"))
+
+	interval := query.Get("interval")
+	switch interval {
+	case "":
+		interval = models.VolumeBucketDay
+	case models.VolumeBucketDay, models.VolumeBucketWeek, models.VolumeBucketMonth:
+	default:
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid interval %q, expected day, week or month", interval))
+		return
+	}
+
+	// Default to the last 30 days
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if t, err := parseTimeParam(query.Get("from")); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid from: "+err.Error())
+		return
+	} else if t != nil {
+		from = *t
+	}
+	if t, err := parseTimeParam(query.Get("to")); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid to: "+err.Error())
+		return
+	} else if t != nil {
+		to = *t
+	}
+	if from.After(to) {
+		h.writeError(w, r, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	history, err := h.service.GetPortfolioHistory(from, to, interval, query.Get("currency"), ownedOnly)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, services.ErrHistoryDisabled), errors.Is(err, services.ErrCurrencyUnsupported):
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, services.ErrHistoryRangeTooLarge):
+			h.writeError(w, r, http.StatusBadRequest, err.Error()+"; use a shorter range or a longer interval")
+		default:
+			h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	h.writeSuccess(w, http.StatusOK, addresses)
+	h.writeSuccess(w, r, http.StatusOK, history)
 }
 
 // GetAddress handles GET /addresses/{address}
 func (h *BitcoinHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	address := vars["address"]
+	address := addressVar(r)
 
 	if address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address parameter is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
 		return
 	}
 
 	addressWithBalance, err := h.service.GetAddress(address)
 	if err != nil {
-		h.writeError(w, http.StatusNotFound, err.Error())
+		h.writeError(w, r, http.StatusNotFound, err.Error())
 		return
 	}
 
-	h.writeSuccess(w, http.StatusOK, addressWithBalance)
+	h.writeSuccess(w, r, http.StatusOK, addressWithBalance)
 }
 
 // GetBalance handles GET /addresses/{address}/balance
 func (h *BitcoinHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	address := vars["address"]
+	address := addressVar(r)
 
 	if address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address parameter is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
+		return
+	}
+
+	if compare, _ := strconv.ParseBool(r.URL.Query().Get("compare")); compare {
+		comparison, err := h.service.CompareBalance(r.Context(), address)
+		if err != nil {
+			h.writeBalanceError(w, r, err)
+			return
+		}
+		h.writeSuccess(w, r, http.StatusOK, comparison)
+		return
+	}
+
+	if detailed, _ := strconv.ParseBool(r.URL.Query().Get("detailed")); detailed {
+		balance, err := h.service.GetDetailedBalance(r.Context(), address)
+		if err != nil {
+			h.writeBalanceError(w, r, err)
+			return
+		}
+		h.writeSuccess(w, r, http.StatusOK, balance)
+		return
+	}
+
+	balance, err := h.service.GetBalance(r.Context(), address)
+	if err != nil {
+		h.writeBalanceError(w, r, err)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, balance)
+}
+
+// writeBalanceError reports a failed balance lookup: 404 for untracked addresses, 502 when the
+// provider could not be reached and 500 when the balance could not be computed
+func (h *BitcoinHandler) writeBalanceError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.writeRateLimited(w, r, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrAddressNotFound):
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrProviderUnavailable):
+		h.writeError(w, r, http.StatusBadGateway, err.Error())
+	default:
+		h.writeServiceError(w, r, err)
+	}
+}
+
+// Watch timeout bounds for long-polling
+const (
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 5 * time.Minute
+)
+
+// WatchBalance handles GET /addresses/{address}/watch
+func (h *BitcoinHandler) WatchBalance(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	timeout := defaultWatchTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d > maxWatchTimeout {
+			h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid timeout; use a duration up to %s", maxWatchTimeout))
+			return
+		}
+		timeout = d
+	}
+
+	// Outlive the server's write timeout for the duration of the poll
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
+
+	// A draining instance ends the poll early, as if it had timed out, so the client polls another one
+	if h.drain != nil {
+		go func() {
+			select {
+			case <-h.drain.Started():
+				cancel(errDraining)
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	balance, err := h.service.WaitForBalanceChange(ctx, address)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(context.Cause(ctx), errDraining) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			return // client went away
+		}
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	balance, err := h.service.GetBalance(address)
+	h.writeSuccess(w, r, http.StatusOK, balance)
+}
+
+// GetBalanceHistory handles GET /addresses/{address}/history
+func (h *BitcoinHandler) GetBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	// Default to the last 30 days
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if t, err := parseTimeParam(r.URL.Query().Get("from")); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid from: "+err.Error())
+		return
+	} else if t != nil {
+		from = *t
+	}
+	if t, err := parseEndTimeParam(r.URL.Query().Get("to")); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid to: "+err.Error())
+		return
+	} else if t != nil {
+		to = *t
+	}
+
+	history, err := h.service.GetBalanceHistory(address, from, to)
 	if err != nil {
-		h.writeError(w, http.StatusNotFound, err.Error())
+		switch {
+		case errors.Is(err, services.ErrHistoryDisabled):
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, services.ErrAddressNotFound):
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		default:
+			h.writeServiceError(w, r, err)
+		}
 		return
 	}
 
-	h.writeSuccess(w, http.StatusOK, balance)
+	h.writeSuccess(w, r, http.StatusOK, history)
 }
 
 // GetTransactions handles GET /addresses/{address}/transactions
 func (h *BitcoinHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	address := vars["address"]
+	address := addressVar(r)
 
 	if address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address parameter is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
 		return
 	}
 
-	// Parse pagination parameters
-	limit := 50
+	// Parse pagination parameters; a missing limit gets the repository's default page size
+	limit := 0
 	offset := 0
 
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
@@ -136,66 +590,382 @@ func (h *BitcoinHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	transactions, err := h.service.GetTransactions(address, limit, offset)
+	fullHistory, err := parseHistoryParam(r.URL.Query().Get("history"))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	getTransactions := h.service.GetTransactions
+	if fullHistory {
+		getTransactions = h.service.GetTransactionHistory
+	}
+	transactions, err := getTransactions(r.Context(), address, limit, offset)
+	if err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.NewTransactionResponses(transactions, h.thresholds))
+}
+
+// GetLatestTransaction handles GET /addresses/{address}/transactions/latest
+func (h *BitcoinHandler) GetLatestTransaction(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	if address == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
+		return
+	}
+
+	tx, err := h.service.GetLatestTransaction(r.Context(), address)
+	if err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if tx == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.NewTransactionResponse(*tx, h.thresholds))
+}
+
+// GetSyncHistory handles GET /addresses/{address}/sync-history
+func (h *BitcoinHandler) GetSyncHistory(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	if address == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	entries, err := h.service.GetSyncHistory(address, limit)
+	if err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, entries)
+}
+
+// GetTransactionTypes handles GET /addresses/{address}/types
+func (h *BitcoinHandler) GetTransactionTypes(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+
+	if address == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
+		return
+	}
+
+	types, err := h.service.GetTransactionTypes(address)
 	if err != nil {
-		h.writeError(w, http.StatusNotFound, err.Error())
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	h.writeSuccess(w, http.StatusOK, transactions)
+	h.writeSuccess(w, r, http.StatusOK, types)
+}
+
+// GetVolume handles GET /addresses/{address}/volume
+func (h *BitcoinHandler) GetVolume(w http.ResponseWriter, r *http.Request) {
+	address := addressVar(r)
+	query := r.URL.Query()
+
+	bucket := query.Get("bucket")
+	switch bucket {
+	case "":
+		bucket = models.VolumeBucketDay
+	case models.VolumeBucketDay, models.VolumeBucketWeek, models.VolumeBucketMonth:
+	default:
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid bucket %q, expected day, week or month", bucket))
+		return
+	}
+
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid from: "+err.Error())
+		return
+	}
+	to, err := parseEndTimeParam(query.Get("to"))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid to: "+err.Error())
+		return
+	}
+
+	volume, err := h.service.GetVolume(address, bucket, from, to)
+	if err != nil {
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, volume)
 }
 
 // SyncAddress handles POST /addresses/{address}/sync
 func (h *BitcoinHandler) SyncAddress(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	address := vars["address"]
+	address := addressVar(r)
 
 	if address == "" {
-		h.writeError(w, http.StatusBadRequest, "Address parameter is required")
+		h.writeError(w, r, http.StatusBadRequest, "Address parameter is required")
 		return
 	}
 
-	if err := h.service.SyncAddress(address); err != nil {
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+		preview, err := h.service.PreviewSync(r.Context(), address)
+		if err != nil {
+			h.writeSyncError(w, r, err)
+			return
+		}
+		h.writeSuccess(w, r, http.StatusOK, preview)
+		return
+	}
+
+	coalesced, err := h.service.RequestSync(r.Context(), address)
+	if err != nil {
+		h.writeSyncError(w, r, err)
+		return
+	}
+
+	if coalesced {
+		h.writeMessage(w, r, http.StatusOK, "Address synchronized successfully (shared with a sync already running or just finished)")
+		return
+	}
+	h.writeMessage(w, r, http.StatusOK, "Address synchronized successfully")
+}
+
+// writeSyncError answers a failed manual sync or preview, telling the client when the address is untracked or its syncing is paused
+func (h *BitcoinHandler) writeSyncError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrAddressNotFound):
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSyncPaused):
+		h.writeError(w, r, http.StatusConflict, "Address sync is paused; resume it with POST /addresses/{address}/resume")
+	default:
+		h.writeServiceError(w, r, err)
+	}
+}
+
+// PauseSync handles POST /addresses/{address}/pause
+func (h *BitcoinHandler) PauseSync(w http.ResponseWriter, r *http.Request) {
+	h.setSyncEnabled(w, r, h.service.PauseSync)
+}
+
+// ResumeSync handles POST /addresses/{address}/resume
+func (h *BitcoinHandler) ResumeSync(w http.ResponseWriter, r *http.Request) {
+	h.setSyncEnabled(w, r, h.service.ResumeSync)
+}
+
+// setSyncEnabled applies a pause or resume and responds with the updated address
+func (h *BitcoinHandler) setSyncEnabled(w http.ResponseWriter, r *http.Request, apply func(address string) (*models.Address, error)) {
+	updated, err := apply(addressVar(r))
+	if err != nil {
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		} else {
+			h.writeServiceError(w, r, err)
+		}
 		return
 	}
 
-	h.writeMessage(w, http.StatusOK, "Address synchronized successfully")
+	h.writeSuccess(w, r, http.StatusOK, updated)
 }
 
 // SyncAllAddresses handles POST /sync
 func (h *BitcoinHandler) SyncAllAddresses(w http.ResponseWriter, r *http.Request) {
-	if err := h.service.SyncAllAddresses(); err != nil {
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+	result, err := h.service.SyncAllAddresses(r.Context())
+	if err != nil {
+		if result == nil || errors.Is(err, context.DeadlineExceeded) {
+			h.writeServiceError(w, r, err)
+			return
+		}
+		// Partial runs still report which addresses were synced, failed or skipped
+		h.writeFailure(w, r, http.StatusInternalServerError, err.Error(), result)
 		return
 	}
 
-	h.writeMessage(w, http.StatusOK, "All addresses synchronized successfully")
+	h.writeSuccess(w, r, http.StatusOK, result)
 }
 
-// HealthCheck handles GET /health
-func (h *BitcoinHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	h.writeSuccess(w, http.StatusOK, map[string]string{
-		"status":  "healthy",
-		"service": "bitcoin-tracker",
-	})
+// maxBatchSync caps how many addresses a single targeted sync may contain
+const maxBatchSync = 1000
+
+// SyncAddresses handles POST /sync/batch
+func (h *BitcoinHandler) SyncAddresses(w http.ResponseWriter, r *http.Request) {
+	var req models.SyncBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "At least one address is required")
+		return
+	}
+	if len(req.Addresses) > maxBatchSync {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("At most %d addresses can be synced per request", maxBatchSync))
+		return
+	}
+
+	for i := range req.Addresses {
+		req.Addresses[i] = address.Normalize(req.Addresses[i])
+	}
+
+	results, err := h.service.SyncAddresses(r.Context(), req.Addresses)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.writeServiceError(w, r, err)
+			return
+		}
+		// The run stopped early; still report what happened to each address
+		h.writeFailure(w, r, http.StatusInternalServerError, err.Error(), results)
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, results)
+}
+
+// CompareAddresses handles GET /compare
+func (h *BitcoinHandler) CompareAddresses(w http.ResponseWriter, r *http.Request) {
+	a := address.Normalize(r.URL.Query().Get("a"))
+	b := address.Normalize(r.URL.Query().Get("b"))
+
+	if a == "" || b == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Both a and b query parameters are required")
+		return
+	}
+	if a == b {
+		h.writeError(w, r, http.StatusBadRequest, "Cannot compare an address with itself")
+		return
+	}
+
+	comparison, err := h.service.CompareAddresses(r.Context(), a, b)
+	if err != nil {
+		if errors.Is(err, services.ErrAddressNotFound) {
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		} else {
+			h.writeServiceError(w, r, err)
+		}
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, comparison)
 }
 
-// Helper methods for response handling
-func (h *BitcoinHandler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.SuccessResponse(data))
+// GetClusters handles GET /clusters
+func (h *BitcoinHandler) GetClusters(w http.ResponseWriter, r *http.Request) {
+	clusters, err := h.service.GetClusters()
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, clusters)
 }
 
-func (h *BitcoinHandler) writeError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse(message))
+// GetFeeEstimates handles GET /fees
+func (h *BitcoinHandler) GetFeeEstimates(w http.ResponseWriter, r *http.Request) {
+	fees, err := h.service.GetFeeEstimates()
+	if err != nil {
+		if errors.Is(err, services.ErrFeesDisabled) {
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+			return
+		}
+		if h.writeRateLimited(w, r, err) {
+			return
+		}
+		h.writeError(w, r, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, fees)
 }
 
-func (h *BitcoinHandler) writeMessage(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.MessageResponse(message))
+// EstimateConfirmation handles GET /transactions/{hash}/eta
+func (h *BitcoinHandler) EstimateConfirmation(w http.ResponseWriter, r *http.Request) {
+	hash := strings.ToLower(mux.Vars(r)["hash"])
+	if !isTransactionHash(hash) {
+		h.writeError(w, r, http.StatusBadRequest, "Transaction hash must be 64 hex characters")
+		return
+	}
+
+	eta, err := h.service.EstimateConfirmation(r.Context(), hash)
+	if err != nil {
+		if h.writeRateLimited(w, r, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrFeesDisabled), errors.Is(err, services.ErrFeeLookupUnsupported):
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, services.ErrTransactionNotFound):
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrProviderUnavailable):
+			h.writeError(w, r, http.StatusBadGateway, err.Error())
+		default:
+			h.writeServiceError(w, r, err)
+		}
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, eta)
+}
+
+// isTransactionHash reports whether hash is a 64 character hex transaction ID
+func isTransactionHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// GetCapabilities handles GET /capabilities
+func (h *BitcoinHandler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	h.writeSuccess(w, r, http.StatusOK, h.service.Capabilities())
+}
+
+// GetChainStatus handles GET /chain
+func (h *BitcoinHandler) GetChainStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetChainStatus(r.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrChainTipUnavailable) {
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+			return
+		}
+		if h.writeRateLimited(w, r, err) {
+			return
+		}
+		h.writeError(w, r, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, status)
+}
+
+// HealthCheck handles GET /health
+func (h *BitcoinHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	// Degraded sync still serves stored data, so the status code stays 200
+	syncHealth := h.service.SyncHealth()
+	status := "healthy"
+	if syncHealth.Degraded {
+		status = "degraded"
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, map[string]interface{}{
+		"status":  status,
+		"service": "bitcoin-tracker",
+		"sync":    syncHealth,
+	})
 }
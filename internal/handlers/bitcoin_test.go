@@ -0,0 +1,1554 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/gorilla/mux"
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/notify"
+	"github.com/ihladush/bitcoin/internal/repository"
+	"github.com/ihladush/bitcoin/internal/services"
+)
+
+// newTestRouter serves the address routes from a temporary database and the static provider fixture
+func newTestRouter(t *testing.T, opts ...services.Option) *mux.Router {
+	t.Helper()
+
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	client, err := clients.NewStaticClient("../clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(repo, client, opts...))
+	router := mux.NewRouter()
+	router.HandleFunc("/addresses", h.AddAddress).Methods("POST")
+	router.HandleFunc("/addresses/bulk", h.AddAddresses).Methods("POST")
+	router.HandleFunc("/addresses", h.GetAllAddresses).Methods("GET")
+	router.HandleFunc("/addresses/{address}", h.GetAddress).Methods("GET")
+	router.HandleFunc("/addresses/{address}", h.UpdateAddress).Methods("PUT")
+	router.HandleFunc("/addresses/{address}", h.RemoveAddress).Methods("DELETE")
+	router.HandleFunc("/addresses/{address}/balance", h.GetBalance).Methods("GET")
+	router.HandleFunc("/addresses/{address}/history", h.GetBalanceHistory).Methods("GET")
+	router.HandleFunc("/addresses/{address}/types", h.GetTransactionTypes).Methods("GET")
+	router.HandleFunc("/addresses/{address}/watch", h.WatchBalance).Methods("GET")
+	router.HandleFunc("/addresses/{address}/verify/challenge", h.CreateOwnershipChallenge).Methods("POST")
+	router.HandleFunc("/addresses/{address}/verify", h.VerifyOwnership).Methods("POST")
+	router.HandleFunc("/addresses/{address}/transactions/import", h.ImportTransactions).Methods("POST")
+	router.HandleFunc("/transactions", h.GetAllTransactions).Methods("GET")
+	router.HandleFunc("/batch", h.Batch).Methods("POST")
+	router.HandleFunc("/clusters", h.GetClusters).Methods("GET")
+	router.HandleFunc("/search", h.Search).Methods("GET")
+	router.HandleFunc("/portfolio/history", h.GetPortfolioHistory).Methods("GET")
+	router.HandleFunc("/addresses/{address}/sync", h.SyncAddress).Methods("POST")
+	router.HandleFunc("/addresses/{address}/pause", h.PauseSync).Methods("POST")
+	router.HandleFunc("/addresses/{address}/resume", h.ResumeSync).Methods("POST")
+	router.HandleFunc("/sync", h.SyncAllAddresses).Methods("POST")
+	router.HandleFunc("/admin/addresses/{address}/reset-sync", h.ResetSync).Methods("POST")
+	router.HandleFunc("/admin/cleanup", h.Cleanup).Methods("POST")
+	router.HandleFunc("/admin/recalculate", h.RecalculateBalances).Methods("POST")
+	router.HandleFunc("/admin/recalculate", h.GetRecalculation).Methods("GET")
+	router.HandleFunc("/transactions/{hash}/eta", h.EstimateConfirmation).Methods("GET")
+	router.HandleFunc("/capabilities", h.GetCapabilities).Methods("GET")
+	return router
+}
+
+// serve sends a request to router and returns the recorded response
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestUppercaseBech32ResolvesToTrackedAddress(t *testing.T) {
+	router := newTestRouter(t)
+	lower := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	upper := strings.ToUpper(lower)
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+lower+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	// The uppercase form is the same address, so it is already tracked
+	rec := serve(router, "POST", "/addresses", `{"address": "`+upper+`"}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "already being tracked") {
+		t.Errorf("Expected uppercase re-add to be rejected as a duplicate, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, path := range []string{"/addresses/" + upper, "/addresses/" + upper + "/balance"} {
+		rec := serve(router, "GET", path, "")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), lower) {
+			t.Errorf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := serve(router, "DELETE", "/addresses/"+upper, ""); rec.Code != http.StatusOK {
+		t.Errorf("Delete by uppercase address failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/addresses/"+lower, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the address to be removed, got %d", rec.Code)
+	}
+}
+
+func TestMixedCaseBech32Rejected(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := serve(router, "POST", "/addresses", `{"address": "bc1Q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected mixed-case bech32 to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAddAddressRespondsWithSyncedBalance(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := serve(router, "POST", "/addresses", `{"address": "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"total_balance":2500000`) {
+		t.Errorf("Expected the post-sync balance in the response, got %s", rec.Body)
+	}
+}
+
+func TestDefaultLabelTemplate(t *testing.T) {
+	router := newTestRouter(t, services.WithLabelTemplate("Address {n} ({short})"))
+
+	rec := serve(router, "POST", "/addresses", `{"address": "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"label":"Address 1 (3E8oci…3FNDcd)"`) {
+		t.Errorf("Expected a derived label, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, "POST", "/addresses", `{"address": "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", "label": "cold storage"}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"label":"cold storage"`) {
+		t.Errorf("Expected the supplied label to be kept, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if _, err := services.ParseLabelTemplate("addr-{first6}"); err == nil {
+		t.Error("Expected an unknown placeholder to be rejected")
+	}
+}
+
+// signMessage signs message with key the way Bitcoin Core's signmessage does for a compressed P2PKH address
+func signMessage(t *testing.T, key *btcec.PrivateKey, message string) string {
+	t.Helper()
+
+	const magic = "Bitcoin Signed Message:\n"
+	if len(message) >= 0xfd {
+		t.Fatalf("message too long for a one-byte length: %d", len(message))
+	}
+	payload := append([]byte{byte(len(magic))}, magic...)
+	payload = append(append(payload, byte(len(message))), message...)
+	first := sha256.Sum256(payload)
+	hash := sha256.Sum256(first[:])
+	return base64.StdEncoding.EncodeToString(ecdsa.SignCompact(key, hash[:], true))
+}
+
+func TestVerifyOwnership(t *testing.T) {
+	router := newTestRouter(t)
+	// The compressed P2PKH address of private key 1
+	addr := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	key, _ := btcec.PrivKeyFromBytes([]byte{1})
+	other, _ := btcec.PrivKeyFromBytes([]byte{2})
+
+	if rec := serve(router, "POST", "/addresses/"+addr+"/verify/challenge", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a challenge for an untracked address to answer 404, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(router, "POST", "/addresses/"+addr+"/verify/challenge", "")
+	var resp struct {
+		Data models.OwnershipChallenge `json:"data"`
+	}
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+		t.Fatalf("Challenge failed with status %d: %s", rec.Code, rec.Body)
+	}
+	challenge := resp.Data
+	if !strings.Contains(challenge.Message, addr) || !challenge.ExpiresAt.After(time.Now()) {
+		t.Errorf("Expected an unexpired challenge naming the address, got %+v", challenge)
+	}
+
+	verify := func(message, signature string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.VerifyOwnershipRequest{Message: message, Signature: signature})
+		return serve(router, "POST", "/addresses/"+addr+"/verify", string(body))
+	}
+
+	// A valid signature over a message the server did not issue proves nothing
+	if rec := verify("I own this address", signMessage(t, key, "I own this address")); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a signature over a chosen message to be rejected with 400, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := verify(challenge.Message, signMessage(t, other, challenge.Message)); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a signature from another key to be rejected with 403, got %d: %s", rec.Code, rec.Body)
+	}
+
+	signature := signMessage(t, key, challenge.Message)
+	rec = verify(challenge.Message, signature)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"owned":true`) || !strings.Contains(rec.Body.String(), "ownership_verified_at") {
+		t.Errorf("Expected verification to mark the address owned, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// The challenge is used up, so the same proof cannot be replayed
+	if rec := verify(challenge.Message, signature); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a replayed signature to be rejected with 400, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, "POST", "/addresses/1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV/verify", `{"message": "x", "signature": "`+signature+`"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an untracked address to answer 404, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestImportTransactions(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV" // not in the fixture, so it starts empty
+	raw := `[{"hash": "cc", "amount": 70000, "confirmations": 3, "block_height": 830003, "timestamp": "2024-03-02T10:00:00Z"}]`
+
+	if rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected import into an untracked address to answer 404, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"hash":"cc"`) {
+		t.Fatalf("Import failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Importing the same data again changes nothing
+	rec = serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"new":[]`) {
+		t.Errorf("Expected a repeated import to add nothing, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, "GET", "/addresses/"+addr+"/balance", "")
+	if !strings.Contains(rec.Body.String(), `"total_balance":70000`) {
+		t.Errorf("Expected the imported transaction in the balance, got %s", rec.Body)
+	}
+
+	rec = serve(router, "POST", "/addresses/"+addr+"/transactions/import", `{"not": "an array"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected malformed JSON to answer 400, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCSVExportRowCap(t *testing.T) {
+	router := newTestRouter(t, services.WithMaxExportRows(1))
+	if rec := serve(router, "POST", "/addresses", `{"address": "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Both fixture transactions match, one more than the cap allows
+	rec := serve(router, "GET", "/transactions?format=csv", "")
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "from and to") {
+		t.Errorf("Expected an unbounded export over the cap to answer 413 asking for a date range, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, "GET", "/transactions?format=csv&from=2024-01-01&to=2024-12-31", "")
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "narrow") {
+		t.Errorf("Expected a date range over the cap to answer 413 asking to narrow it, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, query := range []string{"from=2024-03-01&to=2024-03-31", "limit=1"} {
+		rec := serve(router, "GET", "/transactions?format=csv&"+query, "")
+		if rec.Code != http.StatusOK || strings.Count(rec.Body.String(), "\n") != 2 {
+			t.Errorf("Expected %s to export the header and one row, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestCSVExportByAcceptHeader(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	req := httptest.NewRequest("GET", "/transactions", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV export, got %d with Content-Type %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse the export: %v", err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16", addr, "-50000", "0", "0", "2024-03-02T12:00:00Z", "sent", "pending"},
+		{"a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d", addr, "150000", "6", "830000", "2024-02-20T08:30:00Z", "received", "confirmed"},
+	}
+	if !slices.EqualFunc(records, want, slices.Equal[[]string]) {
+		t.Errorf("Expected the header and both fixture rows newest first, got %q", records)
+	}
+
+	// A JSON request to the same route is unaffected
+	if rec := serve(router, "GET", "/transactions", ""); !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected JSON without an Accept header, got Content-Type %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestTransactionFilterValidation(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	// An unknown sort field is rejected before a CSV export writes its status
+	for _, target := range []string{"/transactions?sort=bogus", "/transactions?sort=bogus&format=csv"} {
+		rec := serve(router, "GET", target, "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid sort") {
+			t.Errorf("GET %s = %d: %s; want 400", target, rec.Code, rec.Body)
+		}
+	}
+
+	// A date-only to covers the whole day; the fixture has one transaction on 2024-03-02 at noon
+	testCases := []struct {
+		query string
+		want  int
+	}{
+		{"to=2024-03-02", 2},
+		{"from=2024-03-02&to=2024-03-02", 1},
+		{"to=2024-03-01", 1},
+		{"to=2024-03-02T00:00:00Z", 1},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "GET", "/transactions?"+tc.query, "")
+		var body struct {
+			Data []models.TransactionResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("GET /transactions?%s = %d: %s", tc.query, rec.Code, rec.Body)
+		}
+		if len(body.Data) != tc.want {
+			t.Errorf("GET /transactions?%s returned %d transactions; want %d", tc.query, len(body.Data), tc.want)
+		}
+	}
+}
+
+func TestClusters(t *testing.T) {
+	router := newTestRouter(t)
+	a, b, c, d := "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+
+	// a and b spend together in aa, b and c in bb; d only pays a in cc
+	imports := map[string]string{
+		a: `[{"hash": "aa", "amount": -1000}, {"hash": "cc", "amount": 500}]`,
+		b: `[{"hash": "aa", "amount": -2000}, {"hash": "bb", "amount": -3000}]`,
+		c: `[{"hash": "bb", "amount": -4000}]`,
+		d: `[{"hash": "cc", "amount": -500}]`,
+	}
+	for addr, txs := range imports {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+		raw := strings.ReplaceAll(txs, `}`, `, "confirmations": 1, "block_height": 830000, "timestamp": "2024-03-02T10:00:00Z"}`)
+		if rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw); rec.Code != http.StatusOK {
+			t.Fatalf("Import into %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(router, "GET", "/clusters", "")
+	want := `"data":[{"addresses":["` + b + `","` + c + `","` + a + `"],"transactions":["aa","bb"]}]`
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected a single cluster of a, b and c, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestBalanceCompare(t *testing.T) {
+	router := newTestRouter(t)
+	synced, imported := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+
+	for _, addr := range []string{synced, imported} {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(router, "GET", "/addresses/"+synced+"/balance?compare=true", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"delta":{"confirmed":0,"unconfirmed":0,"total":0},"matches":true`) {
+		t.Errorf("Expected a synced address to match the provider, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// The provider knows nothing about transactions imported by hand
+	raw := `[{"hash": "cc", "amount": 70000, "confirmations": 3, "block_height": 830003, "timestamp": "2024-03-02T10:00:00Z"}]`
+	if rec := serve(router, "POST", "/addresses/"+imported+"/transactions/import", raw); rec.Code != http.StatusOK {
+		t.Fatalf("Import failed with status %d: %s", rec.Code, rec.Body)
+	}
+	rec = serve(router, "GET", "/addresses/"+imported+"/balance?compare=true", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"delta":{"confirmed":-70000,"unconfirmed":0,"total":-70000},"matches":false`) {
+		t.Errorf("Expected the imported amount as drift, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "GET", "/addresses/1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2/balance?compare=true", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an untracked address to answer 404, got %d", rec.Code)
+	}
+}
+
+func TestSyncCooldownSharesRecentSync(t *testing.T) {
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	for _, tc := range []struct {
+		cooldown time.Duration
+		shared   bool
+	}{{time.Minute, true}, {0, false}} {
+		router := newTestRouter(t, services.WithSyncCooldown(tc.cooldown))
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+		}
+
+		var messages []string
+		for range 2 {
+			rec := serve(router, "POST", "/addresses/"+addr+"/sync", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Sync failed with status %d: %s", rec.Code, rec.Body)
+			}
+			messages = append(messages, rec.Body.String())
+		}
+
+		if strings.Contains(messages[0], "shared") {
+			t.Errorf("cooldown %s: the first sync was reported as shared: %s", tc.cooldown, messages[0])
+		}
+		if got := strings.Contains(messages[1], "shared"); got != tc.shared {
+			t.Errorf("cooldown %s: second sync shared = %v; want %v (%s)", tc.cooldown, got, tc.shared, messages[1])
+		}
+	}
+}
+
+// failingBalanceRepository tracks addresses normally but cannot compute balances
+type failingBalanceRepository struct {
+	repository.Repository
+}
+
+func (failingBalanceRepository) GetBalance(address string) (*models.Balance, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestGetBalanceErrors(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(failingBalanceRepository{repo}, nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/addresses/{address}/balance", h.GetBalance).Methods("GET")
+
+	testCases := []struct {
+		address string
+		status  int
+	}{
+		{"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", http.StatusNotFound},
+		{addr, http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "GET", "/addresses/"+tc.address+"/balance", "")
+		if rec.Code != tc.status {
+			t.Errorf("GET balance of %s = %d; want %d: %s", tc.address, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+// failingResetRepository tracks addresses normally but cannot write sync times
+type failingResetRepository struct {
+	repository.Repository
+}
+
+func (r failingResetRepository) ResetLastSynced(address string, syncTime *time.Time) error {
+	if _, err := r.GetAddress(address); err != nil {
+		return err
+	}
+	return errors.New("database is locked")
+}
+
+func TestResetSyncErrors(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(failingResetRepository{repo}, nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/addresses/{address}/reset-sync", h.ResetSync).Methods("POST")
+
+	testCases := []struct {
+		address string
+		status  int
+	}{
+		{"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", http.StatusNotFound},
+		{addr, http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "POST", "/admin/addresses/"+tc.address+"/reset-sync", "")
+		if rec.Code != tc.status {
+			t.Errorf("POST reset-sync of %s = %d; want %d: %s", tc.address, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+// failingUpdateRepository tracks addresses normally but cannot write address changes
+type failingUpdateRepository struct {
+	repository.Repository
+}
+
+func (r failingUpdateRepository) UpdateAddress(address string, update models.UpdateAddressRequest) (*models.Address, error) {
+	if _, err := r.GetAddress(address); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("database is locked")
+}
+
+func TestUpdateAddressErrors(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(failingUpdateRepository{repo}, nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/addresses/{address}", h.UpdateAddress).Methods("PUT")
+
+	testCases := []struct {
+		address string
+		body    string
+		status  int
+	}{
+		{addr, `{"priority": 99}`, http.StatusBadRequest},
+		{addr, `not json`, http.StatusBadRequest},
+		{"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", `{"label": "cold storage"}`, http.StatusNotFound},
+		{addr, `{"label": "cold storage"}`, http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "PUT", "/addresses/"+tc.address, tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("PUT %s with %s = %d; want %d: %s", tc.address, tc.body, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+// failingSharedRepository tracks addresses normally but cannot find shared transactions
+type failingSharedRepository struct {
+	repository.Repository
+}
+
+func (failingSharedRepository) GetSharedTransactions(a, b string) ([]models.SharedTransaction, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestCompareAddressesErrors(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	a, b := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+	for _, addr := range []string{a, b} {
+		if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(failingSharedRepository{repo}, nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/compare", h.CompareAddresses).Methods("GET")
+
+	testCases := []struct {
+		query  string
+		status int
+	}{
+		{"a=" + a + "&b=1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", http.StatusNotFound},
+		{"a=" + a + "&b=" + b, http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "GET", "/compare?"+tc.query, "")
+		if rec.Code != tc.status {
+			t.Errorf("GET /compare?%s = %d; want %d: %s", tc.query, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+// failingSyncClient serves the static fixture but fails every transaction fetch after a delay
+type failingSyncClient struct {
+	*clients.StaticClient
+}
+
+func (failingSyncClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	time.Sleep(50 * time.Millisecond)
+	return nil, errors.New("provider unavailable")
+}
+
+func TestSyncAllReportsPartialResultWhenBudgetExhausted(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	addresses := []string{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"}
+	for _, addr := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: addr, SyncEnabled: true}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+	static, err := clients.NewStaticClient("../clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	// One worker whose first failure already uses up the budget
+	h := NewBitcoinHandler(services.NewBitcoinService(repo, failingSyncClient{static},
+		services.WithSyncLimits(1, 0), services.WithSyncBudget(0, 10*time.Millisecond)))
+	router := mux.NewRouter()
+	router.HandleFunc("/sync", h.SyncAllAddresses).Methods("POST")
+
+	rec := serve(router, "POST", "/sync", "")
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), services.ErrSyncBudgetExhausted.Error()) {
+		t.Fatalf("Expected the exhausted budget to answer 500, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var response struct {
+		Data models.SyncRunResult `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(response.Data.Failed) != 1 || len(response.Data.Skipped) != 2 {
+		t.Errorf("Expected the partial result to list 1 failed and 2 skipped addresses, got %+v", response.Data)
+	}
+}
+
+// addressFailingClient serves the static fixture but fails the transaction fetches of one address
+type addressFailingClient struct {
+	*clients.StaticClient
+	failing string
+}
+
+func (c addressFailingClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	if address == c.failing {
+		return nil, errors.New("provider unavailable")
+	}
+	return c.StaticClient.GetTransactions(ctx, address, limit)
+}
+
+func TestSyncAddresses(t *testing.T) {
+	const synced = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const failing = "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+	const untracked = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	for _, addr := range []string{synced, failing} {
+		if _, err := repo.AddAddress(models.Address{Address: addr, SyncEnabled: true}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+	static, err := clients.NewStaticClient("../clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(repo, addressFailingClient{static, failing}))
+	router := mux.NewRouter()
+	router.HandleFunc("/sync/batch", h.SyncAddresses).Methods("POST")
+
+	// Each address succeeds or fails on its own; repeated addresses are synced once
+	body := `{"addresses": ["` + synced + `", "` + failing + `", "` + untracked + `", "` + synced + `"]}`
+	rec := serve(router, "POST", "/sync/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected per-address failures to answer 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var response struct {
+		Data []models.SyncResult `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := []struct{ address, status, err string }{
+		{synced, models.SyncStatusSynced, ""},
+		{failing, models.SyncStatusFailed, "provider unavailable"},
+		{untracked, models.SyncStatusFailed, "not found"},
+	}
+	if len(response.Data) != len(want) {
+		t.Fatalf("Expected a result per distinct address, got %+v", response.Data)
+	}
+	for i, w := range want {
+		got := response.Data[i]
+		if got.Address != w.address || got.Status != w.status || (w.err == "") != (got.Error == "") || !strings.Contains(got.Error, w.err) {
+			t.Errorf("Result %d = %+v; want %s %s with error %q", i, got, w.address, w.status, w.err)
+		}
+	}
+
+	tooMany := `{"addresses": [` + strings.Repeat(`"`+synced+`", `, maxBatchSync) + `"` + synced + `"]}`
+	for _, body := range []string{`{"addresses": []}`, `not json`, tooMany} {
+		if rec := serve(router, "POST", "/sync/batch", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.40s..., got %d: %s", body, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestSyncAddressesReportsPartialResultWhenBudgetExhausted(t *testing.T) {
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	addresses := []string{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"}
+	for _, addr := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: addr, SyncEnabled: true}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+	static, err := clients.NewStaticClient("../clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(repo, failingSyncClient{static},
+		services.WithSyncLimits(1, 0), services.WithSyncBudget(0, 10*time.Millisecond)))
+	router := mux.NewRouter()
+	router.HandleFunc("/sync/batch", h.SyncAddresses).Methods("POST")
+
+	rec := serve(router, "POST", "/sync/batch", `{"addresses": ["`+strings.Join(addresses, `", "`)+`"]}`)
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), services.ErrSyncBudgetExhausted.Error()) {
+		t.Fatalf("Expected the exhausted budget to answer 500, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var response struct {
+		Data []models.SyncResult `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(response.Data) != 2 || response.Data[0].Status != models.SyncStatusFailed || response.Data[1].Status != models.SyncStatusSkipped {
+		t.Errorf("Expected the first address to fail and the second to be skipped, got %+v", response.Data)
+	}
+}
+
+func TestGetBalanceFromReadReplica(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath := filepath.Join(dir, "primary.db")
+	replicaPath := filepath.Join(dir, "replica.db")
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	primary, err := repository.NewSQLiteRepository(primaryPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	if _, err := primary.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	received := models.Transaction{Hash: "aa", Address: addr, Amount: 50000, Confirmations: 3, BlockHeight: 800000, Timestamp: time.Now(), Type: "received"}
+	if err := primary.SaveTransaction(&received); err != nil {
+		t.Fatalf("SaveTransaction failed: %v", err)
+	}
+	primary.Close()
+
+	// The replica is a snapshot taken before the second transaction reached the primary
+	data, err := os.ReadFile(primaryPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := os.WriteFile(replicaPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	repo, err := repository.NewSQLiteRepository(primaryPath, repository.WithReadDSN(replicaPath))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	later := models.Transaction{Hash: "bb", Address: addr, Amount: 20000, Confirmations: 1, BlockHeight: 800002, Timestamp: time.Now(), Type: "received"}
+	if err := repo.SaveTransaction(&later); err != nil {
+		t.Fatalf("SaveTransaction failed: %v", err)
+	}
+
+	h := NewBitcoinHandler(services.NewBitcoinService(repo, nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/addresses/{address}/balance", h.GetBalance).Methods("GET")
+
+	rec := serve(router, "GET", "/addresses/"+addr+"/balance", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total_balance":50000`) {
+		t.Errorf("Expected the balance to be served from the replica, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPauseAndResumeSync(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"sync_enabled":true`) {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(router, "POST", "/addresses/"+addr+"/pause", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sync_enabled":false`) {
+		t.Fatalf("Pause failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, target := range []string{"/addresses/" + addr + "/sync", "/addresses/" + addr + "/sync?dry_run=true"} {
+		rec := serve(router, "POST", target, "")
+		if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "paused") {
+			t.Errorf("POST %s on a paused address = %d: %s", target, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := serve(router, "POST", "/addresses/"+addr+"/resume", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sync_enabled":true`) {
+		t.Fatalf("Resume failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/addresses/"+addr+"/sync", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected a resumed address to sync, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "POST", "/addresses/1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV/pause", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected pausing an untracked address to answer 404, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestEstimateConfirmation(t *testing.T) {
+	fees, err := clients.NewStaticClient("../clients/testdata/static.json")
+	if err != nil {
+		t.Fatalf("NewStaticClient failed: %v", err)
+	}
+	router := newTestRouter(t, services.WithFeeEstimator(fees))
+
+	// 2820 sat over 141 vB pays 20 sat/vB, between the fixture's half hour (18) and fastest (21) rates
+	rec := serve(router, "GET", "/transactions/f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16/eta", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	for _, want := range []string{`"fee_rate":20`, `"bracket":"half_hour"`, `"estimated_blocks":3`, `"estimated_minutes":30`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %s in %s", want, rec.Body)
+		}
+	}
+
+	rec = serve(router, "GET", "/transactions/a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d/eta", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"confirmed":true`) || strings.Contains(rec.Body.String(), "estimated_blocks") {
+		t.Errorf("Expected a confirmed transaction without an estimate, got %d: %s", rec.Code, rec.Body)
+	}
+
+	unknown := strings.Repeat("ab", 32)
+	if rec := serve(router, "GET", "/transactions/"+unknown+"/eta", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown transaction to answer 404, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/transactions/not-a-hash/eta", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a malformed hash to answer 400, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := serve(router, "GET", "/capabilities", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	for _, want := range []string{`"chain_tip":true`, `"transaction_fees":true`, `"coinbase_detection":false`, `"fee_estimates":false`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %s in %s", want, rec.Body)
+		}
+	}
+}
+
+func TestProviderRateLimit(t *testing.T) {
+	testCases := []struct {
+		retryAfter string
+		remaining  string
+		want       string
+		body       string
+	}{
+		{"45", "0", "45", `"remaining":0`},
+		{"", "", "60", `"retry_after_seconds":60}`},
+	}
+
+	for _, tc := range testCases {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.retryAfter != "" {
+				w.Header().Set("Retry-After", tc.retryAfter)
+			}
+			if tc.remaining != "" {
+				w.Header().Set("X-RateLimit-Remaining", tc.remaining)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+
+		service := services.NewBitcoinService(nil, nil, services.WithFeeEstimator(clients.NewMempoolFeeClient(upstream.URL)))
+		router := mux.NewRouter()
+		router.HandleFunc("/fees", NewBitcoinHandler(service).GetFeeEstimates).Methods("GET")
+
+		rec := serve(router, "GET", "/fees", "")
+		upstream.Close()
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("GET /fees while rate-limited = %d; want 503: %s", rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tc.want {
+			t.Errorf("Retry-After = %q; want %q", got, tc.want)
+		}
+		if !strings.Contains(rec.Body.String(), tc.body) {
+			t.Errorf("Expected %s in the response: %s", tc.body, rec.Body)
+		}
+	}
+}
+
+func TestSyncPriority(t *testing.T) {
+	router := newTestRouter(t, services.WithSyncBatchSize(1))
+	now := time.Now().UTC()
+
+	// Staleness weighted by priority: low scores 3h, high 2h x 3 = 6h, recent 1h
+	addresses := []struct {
+		address  string
+		priority int
+		age      time.Duration
+	}{
+		{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", 0, 3 * time.Hour},
+		{"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", 2, 2 * time.Hour},
+		{"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", 0, time.Hour},
+	}
+	for _, a := range addresses {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+a.address+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", a.address, rec.Code, rec.Body)
+		}
+		body := fmt.Sprintf(`{"priority": %d}`, a.priority)
+		if rec := serve(router, "PUT", "/addresses/"+a.address, body); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"priority":`+strconv.Itoa(a.priority)) {
+			t.Fatalf("Setting priority of %s failed with status %d: %s", a.address, rec.Code, rec.Body)
+		}
+		syncedAt := now.Add(-a.age).Format(time.RFC3339)
+		if rec := serve(router, "POST", "/admin/addresses/"+a.address+"/reset-sync?synced_at="+syncedAt, ""); rec.Code != http.StatusOK {
+			t.Fatalf("Backdating %s failed with status %d: %s", a.address, rec.Code, rec.Body)
+		}
+	}
+
+	// The high-priority address wins the first run; then the stalest of the rest
+	for _, want := range []string{addresses[1].address, addresses[0].address} {
+		rec := serve(router, "POST", "/sync", "")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"synced":["`+want+`"]`) {
+			t.Errorf("Expected a run syncing only %s, got status %d: %s", want, rec.Code, rec.Body)
+		}
+	}
+
+	for _, body := range []string{`{"priority": 11}`, `{"priority": -1}`} {
+		if rec := serve(router, "PUT", "/addresses/"+addresses[0].address, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s = %d; want 400: %s", body, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestConfirmationWebhook(t *testing.T) {
+	var received []notify.Notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		received = append(received, n)
+	}))
+	defer webhook.Close()
+
+	router := newTestRouter(t, services.WithConfirmationAlerts(6, notify.NewWebhookNotifier(webhook.URL)))
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if len(received) != 0 {
+		t.Fatalf("Expected no notifications for history already past the target, got %+v", received)
+	}
+
+	// Roll the fixture's six-confirmation transaction back to two, as an earlier sync would have stored it
+	hash := "a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d"
+	raw := `[{"hash": "` + hash + `", "amount": 150000, "confirmations": 2, "block_height": 830000, "timestamp": "2024-02-20T08:30:00Z"}]`
+	if rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw); rec.Code != http.StatusOK {
+		t.Fatalf("Import failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "POST", "/addresses/"+addr+"/sync", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Sync failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if len(received) != 1 || received[0].Event != notify.EventTransactionConfirmed {
+		t.Fatalf("Expected one transaction_confirmed notification, got %+v", received)
+	}
+	data, _ := json.Marshal(received[0].Data)
+	for _, want := range []string{`"address":"` + addr + `"`, `"target":6`, `"previous_confirmations":2`, `"hash":"` + hash + `"`, `"confirmations":6`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in the notification data, got %s", want, data)
+		}
+	}
+
+	// A transaction already past the target is not announced again
+	if rec := serve(router, "POST", "/addresses/"+addr+"/sync", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Sync failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if len(received) != 1 {
+		t.Errorf("Expected no further notifications, got %+v", received)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`", "label": "Cold_Storage 100%"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/addresses", `{"address": "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", "label": "Exchange"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	testCases := []struct {
+		query string
+		kind  string
+		want  []string
+		avoid []string
+	}{
+		{"A1075DB55D416D3CA199F55B6084E2115B9345E16C5CF302FC80E9D5FBF5D48D", "transaction", []string{`"hash":"a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d"`, `"status":"confirmed"`}, []string{`"address":"3E8`}},
+		{strings.ToUpper(addr), "address", []string{`"address":"` + addr + `"`, `"transactions":[]`}, nil},
+		{"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", "address", []string{`"addresses":[]`}, nil},
+		{"cold_", "label", []string{`"label":"Cold_Storage 100%"`}, []string{`"label":"Exchange"`}},
+		{"%", "label", []string{`"label":"Cold_Storage 100%"`}, []string{`"label":"Exchange"`}},
+		{"8ociq", "label", []string{`"label":"Exchange"`}, []string{`"label":"Cold_Storage 100%"`}},
+		{"nothing", "label", []string{`"addresses":[]`}, nil},
+	}
+	for _, tc := range testCases {
+		rec := serve(router, "GET", "/search?q="+url.QueryEscape(tc.query), "")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"kind":"`+tc.kind+`"`) {
+			t.Errorf("Search for %q answered %d: %s", tc.query, rec.Code, rec.Body)
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("Expected %s in the results for %q, got %s", want, tc.query, rec.Body)
+			}
+		}
+		for _, avoid := range tc.avoid {
+			if strings.Contains(rec.Body.String(), avoid) {
+				t.Errorf("Expected no %s in the results for %q, got %s", avoid, tc.query, rec.Body)
+			}
+		}
+	}
+
+	if rec := serve(router, "GET", "/search?q=+", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty query to answer 400, got %d", rec.Code)
+	}
+}
+
+func TestBatchedConfirmationWebhook(t *testing.T) {
+	var batches [][]notify.Notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Failed to decode notification batch: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer webhook.Close()
+
+	for _, batchSize := range []int{10, 1} {
+		batches = nil
+		router := newTestRouter(t,
+			services.WithConfirmationAlerts(6, notify.NewWebhookNotifier(webhook.URL)),
+			services.WithNotificationBatching(batchSize),
+		)
+
+		// Roll each address's six-confirmation transaction back to two
+		rollbacks := map[string]string{
+			"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5": `[{"hash": "a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d", "amount": 150000, "confirmations": 2, "block_height": 830000, "timestamp": "2024-02-20T08:30:00Z"}]`,
+			"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd":         `[{"hash": "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098", "amount": 2500000, "confirmations": 2, "block_height": 825000, "timestamp": "2024-01-15T17:45:00Z"}]`,
+		}
+		for addr, raw := range rollbacks {
+			if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+				t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+			}
+			if rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw); rec.Code != http.StatusOK {
+				t.Fatalf("Import failed with status %d: %s", rec.Code, rec.Body)
+			}
+		}
+
+		if rec := serve(router, "POST", "/sync", ""); rec.Code != http.StatusOK {
+			t.Fatalf("Sync failed with status %d: %s", rec.Code, rec.Body)
+		}
+
+		wantBatches := (2 + batchSize - 1) / batchSize
+		delivered := 0
+		for _, batch := range batches {
+			delivered += len(batch)
+			if len(batch) > batchSize {
+				t.Errorf("Batch of %d exceeds the maximum of %d", len(batch), batchSize)
+			}
+		}
+		if len(batches) != wantBatches || delivered != 2 {
+			t.Errorf("With batch size %d expected 2 notifications in %d deliveries, got %+v", batchSize, wantBatches, batches)
+		}
+	}
+}
+
+func TestPortfolioHistory(t *testing.T) {
+	snapshots, err := repository.NewSQLiteSnapshotStore(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotStore failed: %v", err)
+	}
+	t.Cleanup(func() { snapshots.Close() })
+	router := newTestRouter(t, services.WithSnapshotStore(snapshots))
+
+	first, second := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+	for _, addr := range []string{first, second} {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	// The first address has a balance before the range that changes on the 3rd; the second appears on the 2nd
+	day := func(d, hour int) time.Time { return time.Date(2024, 1, d, hour, 0, 0, 0, time.UTC) }
+	for _, snapshot := range []models.BalanceSnapshot{
+		{Address: first, Timestamp: day(1, 12).AddDate(0, 0, -10), ConfirmedBalance: 1000, TotalBalance: 1000},
+		{Address: first, Timestamp: day(3, 12), ConfirmedBalance: 4000, UnconfirmedBalance: 500, TotalBalance: 4500},
+		{Address: second, Timestamp: day(2, 8), ConfirmedBalance: 200, TotalBalance: 200},
+	} {
+		if err := snapshots.SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("SaveSnapshot failed: %v", err)
+		}
+	}
+
+	rec := serve(router, "GET", "/portfolio/history?from=2024-01-01&to=2024-01-03T18:00:00Z", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Portfolio history failed with status %d: %s", rec.Code, rec.Body)
+	}
+	var history struct {
+		Data models.PortfolioHistory `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := []models.PortfolioPoint{
+		{Period: "2024-01-01", AddressCount: 1, ConfirmedBalance: 1000, TotalBalance: 1000},
+		{Period: "2024-01-02", AddressCount: 2, ConfirmedBalance: 1200, TotalBalance: 1200},
+		{Period: "2024-01-03", AddressCount: 2, ConfirmedBalance: 4200, UnconfirmedBalance: 500, TotalBalance: 4700},
+	}
+	if got := history.Data.Points; len(got) != len(want) {
+		t.Fatalf("Expected %d points, got %+v", len(want), got)
+	}
+	for i, point := range history.Data.Points {
+		point.Timestamp, point.BalanceBTC = time.Time{}, 0
+		if point != want[i] {
+			t.Errorf("Point %d = %+v, want %+v", i, point, want[i])
+		}
+	}
+	if last := history.Data.Points[2].Timestamp; !last.Equal(day(3, 18)) {
+		t.Errorf("Expected the last point to be taken at to, got %s", last)
+	}
+
+	rec = serve(router, "GET", "/portfolio/history?from=2024-01-01&to=2024-01-31&interval=month", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"period":"2024-01","timestamp":"2024-01-31T00:00:00Z","address_count":2`) {
+		t.Errorf("Expected a single monthly point, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for target, status := range map[string]int{
+		"/portfolio/history?currency=USD":                                 http.StatusNotImplemented,
+		"/portfolio/history?interval=hour":                                http.StatusBadRequest,
+		"/portfolio/history?from=2024-02-01&to=2024-01-01":                http.StatusBadRequest,
+		"/portfolio/history?from=2000-01-01&to=2024-01-01":                http.StatusBadRequest,
+		"/portfolio/history?from=2000-01-01&to=2024-01-01&interval=month": http.StatusOK,
+	} {
+		if rec := serve(router, "GET", target, ""); rec.Code != status {
+			t.Errorf("GET %s answered %d, want %d: %s", target, rec.Code, status, rec.Body)
+		}
+	}
+}
+
+func TestRecalculateRunsInBackground(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "GET", "/admin/recalculate", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any recalculation, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(router, "POST", "/admin/recalculate", "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body)
+	}
+	if location := rec.Header().Get("Location"); location != "/admin/recalculate" {
+		t.Errorf("Expected the status endpoint in Location, got %q", location)
+	}
+
+	var job struct {
+		Data models.RecalculateJob `json:"data"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := serve(router, "GET", "/admin/recalculate", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if job.Data.Status != models.RecalculationRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Data.Status != models.RecalculationCompleted || job.Data.Result == nil || job.Data.Result.Checked != 1 {
+		t.Errorf("Expected a completed run over the tracked address, got %+v", job.Data)
+	}
+}
+
+func TestGetBalanceHistory(t *testing.T) {
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if rec := serve(newTestRouter(t), "GET", "/addresses/"+addr+"/history", ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without a snapshot store, got %d: %s", rec.Code, rec.Body)
+	}
+
+	store, err := repository.NewSQLiteSnapshotStore(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotStore failed: %v", err)
+	}
+	defer store.Close()
+	router := newTestRouter(t, services.WithSnapshotStore(store))
+
+	if rec := serve(router, "GET", "/addresses/"+addr+"/history", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an untracked address, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	for d := 1; d <= 3; d++ {
+		snapshot := models.BalanceSnapshot{Address: addr, Timestamp: day(d), ConfirmedBalance: int64(d) * 1000, TotalBalance: int64(d) * 1000}
+		if err := store.SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("SaveSnapshot failed: %v", err)
+		}
+	}
+
+	rec := serve(router, "GET", "/addresses/"+addr+"/history?from=2024-01-02&to=2024-01-03", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var history struct {
+		Data []models.BalanceSnapshot `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(history.Data) != 2 || history.Data[0].TotalBalance != 2000 || history.Data[1].TotalBalance != 3000 {
+		t.Errorf("Expected the day 2 and 3 snapshots oldest first, got %+v", history.Data)
+	}
+
+	if rec := serve(router, "GET", "/addresses/"+addr+"/history?from=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid from, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestWatchBalance(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV" // not in the fixture, so it starts empty
+
+	if rec := serve(router, "GET", "/addresses/"+addr+"/watch?timeout=10ms", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an untracked address, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/addresses/"+addr+"/watch?timeout=1h", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a timeout over the maximum, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "GET", "/addresses/"+addr+"/watch?timeout=20ms", ""); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 when nothing changes before the timeout, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Import new transactions until the watcher, once subscribed, sees a change
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- serve(router, "GET", "/addresses/"+addr+"/watch?timeout=10s", "") }()
+	for i := 0; ; i++ {
+		raw := fmt.Sprintf(`[{"hash": "c%d", "amount": 1000, "confirmations": 3, "block_height": 830003, "timestamp": "2024-03-02T10:00:00Z"}]`, i)
+		if rec := serve(router, "POST", "/addresses/"+addr+"/transactions/import", raw); rec.Code != http.StatusOK {
+			t.Fatalf("Import failed with status %d: %s", rec.Code, rec.Body)
+		}
+		select {
+		case rec := <-done:
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total_balance":`) {
+				t.Errorf("Expected the changed balance, got %d: %s", rec.Code, rec.Body)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	router := newTestRouter(t)
+	addr := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	for _, target := range []string{"/addresses/" + addr + "/sync?dry_run=true", "/addresses/" + addr + "/sync"} {
+		if rec := serve(router, "POST", target, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("POST %s for an untracked address answered %d, want 404: %s", target, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Add failed with status %d: %s", rec.Code, rec.Body)
+	}
+
+	// The initial sync stored the fixture's transactions, so the provider has nothing new
+	rec := serve(router, "POST", "/addresses/"+addr+"/sync?dry_run=true", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var preview struct {
+		Data models.SyncPreview `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if preview.Data.Address != addr || len(preview.Data.New) != 0 || len(preview.Data.Updated) != 0 || preview.Data.Unchanged == 0 {
+		t.Errorf("Expected only unchanged transactions, got %+v", preview.Data)
+	}
+
+	if rec := serve(router, "POST", "/addresses/"+addr+"/pause", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Pause failed with status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/addresses/"+addr+"/sync?dry_run=true", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a paused address, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCleanupArchivesAndReAddRestores(t *testing.T) {
+	const funded = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const empty = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	router := newTestRouter(t)
+
+	for _, addr := range []string{funded, empty} {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := serve(router, "POST", "/admin/cleanup", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a configured period, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "POST", "/admin/cleanup?older_than=soon", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid older_than, got %d: %s", rec.Code, rec.Body)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	rec := serve(router, "POST", "/admin/cleanup?older_than=1ms", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var cleanup struct {
+		Data models.CleanupResult `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &cleanup); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if cleanup.Data.Count != 1 || cleanup.Data.Archived[0] != empty {
+		t.Errorf("Expected only the empty address to be archived, got %+v", cleanup.Data)
+	}
+
+	if rec := serve(router, "GET", "/addresses/"+empty, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an archived address to be hidden, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/addresses/"+funded, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected a funded address to stay tracked, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, "POST", "/addresses", `{"address": "`+empty+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected re-adding an archived address to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/addresses/"+empty, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the re-added address to be tracked again, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAddAddressesBulk(t *testing.T) {
+	const funded = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const empty = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	const other = "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+	router := newTestRouter(t)
+
+	decode := func(rec *httptest.ResponseRecorder) models.BulkAddResult {
+		t.Helper()
+		var response struct {
+			Data models.BulkAddResult `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	rec := serve(router, "POST", "/addresses/bulk", `{"addresses": [{"address": "`+funded+`"}, {"address": "`+empty+`", "label": "cold"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 when every address is added, got %d: %s", rec.Code, rec.Body)
+	}
+	if result := decode(rec); result.Created != 2 || result.Failed != 0 {
+		t.Errorf("Expected 2 created, got %+v", result)
+	}
+	if rec := serve(router, "GET", "/addresses/"+empty, ""); !strings.Contains(rec.Body.String(), `"label":"cold"`) {
+		t.Errorf("Expected the bulk label to be stored, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Each entry succeeds or fails on its own, in request order
+	body := `{"addresses": [{"address": "` + other + `"}, {"address": "not-an-address"}, {"address": "` + other + `"}, {"address": "` + funded + `"}, {"address": ""}]}`
+	rec = serve(router, "POST", "/addresses/bulk", body)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207 for a partial failure, got %d: %s", rec.Code, rec.Body)
+	}
+	result := decode(rec)
+	if result.Created != 1 || result.Failed != 4 || len(result.Results) != 5 {
+		t.Fatalf("Expected 1 created and 4 failed, got %+v", result)
+	}
+	wantStatus := []string{models.BulkStatusCreated, models.BulkStatusFailed, models.BulkStatusFailed, models.BulkStatusFailed, models.BulkStatusFailed}
+	for i, item := range result.Results {
+		if item.Index != i || item.Status != wantStatus[i] || (item.Status == models.BulkStatusFailed) == (item.Error == "") {
+			t.Errorf("Result %d = %+v; want status %s with an error only on failure", i, item, wantStatus[i])
+		}
+	}
+	if result.Results[2].Error != "duplicate address in request" {
+		t.Errorf("Expected the repeated address to be reported as a duplicate, got %+v", result.Results[2])
+	}
+
+	for _, body := range []string{`{"addresses": []}`, `not json`, `{"addresses": [` + strings.Repeat(`{"address": "`+other+`"},`, maxBulkAdd) + `{"address": "` + other + `"}]}`} {
+		if rec := serve(router, "POST", "/addresses/bulk", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.40s..., got %d: %s", body, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestGetAllAddressesWithBalance(t *testing.T) {
+	const funded = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const empty = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	router := newTestRouter(t)
+
+	for _, addr := range []string{funded, empty} {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+	}
+	var balance struct {
+		Data models.Balance `json:"data"`
+	}
+	if err := json.Unmarshal(serve(router, "GET", "/addresses/"+funded+"/balance", "").Body.Bytes(), &balance); err != nil {
+		t.Fatalf("Failed to decode balance: %v", err)
+	}
+
+	// Balances are included by default, zero for addresses without transactions
+	rec := serve(router, "GET", "/addresses", "")
+	var listed struct {
+		Data []models.AddressWithBalance `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	got := map[string]int64{}
+	for _, addr := range listed.Data {
+		if addr.BalanceError != "" {
+			t.Errorf("Unexpected balance error for %s: %s", addr.Address.Address, addr.BalanceError)
+		}
+		got[addr.Address.Address] = addr.Balance.TotalBalance
+	}
+	if len(got) != 2 || got[funded] != balance.Data.TotalBalance || got[funded] == 0 || got[empty] != 0 {
+		t.Errorf("Expected %s to list %d and %s 0, got %v", funded, balance.Data.TotalBalance, empty, got)
+	}
+
+	for _, query := range []string{"?with_balance=false", "?with_balance=0"} {
+		rec := serve(router, "GET", "/addresses"+query, "")
+		if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"balance"`) || !strings.Contains(rec.Body.String(), funded) {
+			t.Errorf("Expected %s to list addresses without balances, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+	if rec := serve(router, "GET", "/addresses?with_balance=true", ""); !strings.Contains(rec.Body.String(), `"balance"`) {
+		t.Errorf("Expected with_balance=true to include balances, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGetTransactionTypes(t *testing.T) {
+	const funded = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const empty = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	router := newTestRouter(t)
+
+	for _, addr := range []string{funded, empty} {
+		if rec := serve(router, "POST", "/addresses", `{"address": "`+addr+`"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Add %s failed with status %d: %s", addr, rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(router, "GET", "/addresses/"+funded+"/types", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var response struct {
+		Data []models.TypeCount `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	count := 0
+	for _, tc := range response.Data {
+		if tc.Type == "" || tc.Count == 0 {
+			t.Errorf("Unexpected type count %+v", tc)
+		}
+		count += tc.Count
+	}
+	if count != 2 {
+		t.Errorf("Expected the counts to cover the 2 synced transactions, got %+v", response.Data)
+	}
+
+	if rec := serve(router, "GET", "/addresses/"+empty+"/types", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"data":[]`) {
+		t.Errorf("Expected an empty list for an address without transactions, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/addresses/3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd/types", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an untracked address, got %d: %s", rec.Code, rec.Body)
+	}
+}
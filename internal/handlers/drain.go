@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DrainRoute names the drain route, which is neither counted as in flight nor refused in read-only mode
+const DrainRoute = "drain"
+
+// defaultDrainTimeout bounds how long POST /admin/drain waits for in-flight requests
+const defaultDrainTimeout = 30 * time.Second
+
+// errDraining ends watch long-polls once the instance starts draining
+var errDraining = errors.New("instance is draining")
+
+// Drain tracks in-flight requests and the instance's readiness, so a load balancer can stop sending
+// traffic before the instance is stopped. Once draining starts the instance stays not ready until
+// the process exits.
+type Drain struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{} // closed when the last in-flight request finishes while draining
+	started  chan struct{} // closed when draining starts
+}
+
+// NewDrain creates a ready instance state with no requests in flight
+func NewDrain() *Drain {
+	return &Drain{idle: make(chan struct{}), started: make(chan struct{})}
+}
+
+// Track counts the requests passing through it as in flight
+func (d *Drain) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && route.GetName() == DrainRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		d.mu.Lock()
+		d.inFlight++
+		d.mu.Unlock()
+
+		defer func() {
+			d.mu.Lock()
+			d.inFlight--
+			if d.inFlight == 0 && d.draining {
+				close(d.idle)
+				d.idle = make(chan struct{})
+			}
+			d.mu.Unlock()
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start marks the instance not ready and returns a channel closed once no request is in flight.
+// Calling it again is harmless.
+func (d *Drain) Start() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.draining {
+		d.draining = true
+		close(d.started)
+	}
+	if d.inFlight == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return d.idle
+}
+
+// Started returns a channel closed once draining starts, so long-running requests can end early
+func (d *Drain) Started() <-chan struct{} {
+	return d.started
+}
+
+// Ready reports whether the instance should receive traffic
+func (d *Drain) Ready() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.draining
+}
+
+// InFlight returns the number of requests being served
+func (d *Drain) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// WithDrain enables GET /readyz and POST /admin/drain backed by drain
+func WithDrain(drain *Drain) Option {
+	return func(h *BitcoinHandler) {
+		h.drain = drain
+	}
+}
+
+// WithAdminToken sets the bearer token required by AdminAuth
+func WithAdminToken(token string) Option {
+	return func(h *BitcoinHandler) {
+		h.adminToken = token
+	}
+}
+
+// AdminAuth requires an "Authorization: Bearer <token>" header matching the admin token, answering
+// 401 Unauthorized otherwise. Without a configured token the protected routes are refused with 403.
+func (h *BitcoinHandler) AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			h.writeError(w, r, http.StatusForbidden, "Set ADMIN_TOKEN to enable this endpoint")
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.writeError(w, r, http.StatusUnauthorized, "Missing or invalid admin token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Readyz handles GET /readyz
+func (h *BitcoinHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.drain != nil && !h.drain.Ready() {
+		h.writeError(w, r, http.StatusServiceUnavailable, "Instance is draining")
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// DrainInstance handles POST /admin/drain. It fails readiness and waits up to ?timeout= (default 30s)
+// for in-flight requests to finish, answering 200 once drained and 202 if requests are still running
+// when the wait or the request ends. Watch long-polls are woken as draining starts, so they do not
+// hold it up. The process keeps serving; stopping it is left to the orchestrator.
+func (h *BitcoinHandler) DrainInstance(w http.ResponseWriter, r *http.Request) {
+	if h.drain == nil {
+		h.writeError(w, r, http.StatusNotImplemented, "Draining is not enabled")
+		return
+	}
+
+	timeout := defaultDrainTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			h.writeError(w, r, http.StatusBadRequest, "Invalid timeout: expected a duration such as 30s")
+			return
+		}
+		timeout = parsed
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	drained := false
+	select {
+	case <-h.drain.Start():
+		drained = true
+	case <-timer.C:
+	case <-r.Context().Done():
+		// Report progress so far rather than an empty response
+	}
+
+	status := http.StatusOK
+	if !drained {
+		status = http.StatusAccepted
+	}
+	h.writeSuccess(w, r, status, map[string]interface{}{
+		"ready":     false,
+		"drained":   drained,
+		"in_flight": h.drain.InFlight(),
+	})
+}
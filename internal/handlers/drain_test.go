@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDrainWaitsForInFlightRequests(t *testing.T) {
+	drain := NewDrain()
+	h := &BitcoinHandler{drain: drain, adminToken: "secret"}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	router := mux.NewRouter()
+	router.Use(drain.Track)
+	router.HandleFunc("/readyz", h.Readyz)
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	router.Handle("/admin/drain", h.AdminAuth(http.HandlerFunc(h.DrainInstance))).Name(DrainRoute)
+
+	drainRequest := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(router, "GET", "/readyz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected a fresh instance to be ready, got %d", rec.Code)
+	}
+	if rec := drainRequest("/admin/drain", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a drain without a token to answer 401, got %d", rec.Code)
+	}
+	if rec := drainRequest("/admin/drain", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a drain with a wrong token to answer 401, got %d", rec.Code)
+	}
+	if !drain.Ready() {
+		t.Fatal("Expected rejected drain requests to leave the instance ready")
+	}
+
+	go serve(router, "GET", "/slow", "")
+	<-started
+
+	if rec := drainRequest("/admin/drain?timeout=10ms", "secret"); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 while a request is in flight, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, "GET", "/readyz", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness to fail while draining, got %d", rec.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- drainRequest("/admin/drain", "secret") }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case rec := <-done:
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected 200 once drained, got %d: %s", rec.Code, rec.Body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drain did not finish after the in-flight request completed")
+	}
+
+	// Draining again is idempotent and returns at once
+	if rec := drainRequest("/admin/drain", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a repeated drain to answer 200, got %d", rec.Code)
+	}
+}
+
+func TestAdminAuthWithoutToken(t *testing.T) {
+	h := &BitcoinHandler{drain: NewDrain()}
+	rec := httptest.NewRecorder()
+	h.AdminAuth(http.HandlerFunc(h.DrainInstance)).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/drain", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without a configured token, got %d", rec.Code)
+	}
+	if !h.drain.Ready() {
+		t.Error("Expected the instance to stay ready")
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// selectFields trims a struct, or a slice of structs, to the requested top-level JSON fields.
+// Field names are checked against the JSON names of the Go type, so a field that is valid but
+// omitted from this particular response is not mistaken for an unknown one.
+func selectFields(data interface{}, fields []string) (interface{}, error) {
+	t := reflect.TypeOf(data)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("fields is not supported for this response")
+	}
+
+	known := jsonFieldNames(t, map[string]bool{})
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if !known[field] {
+			return nil, fmt.Errorf("unknown field: %q", field)
+		}
+		wanted[field] = true
+	}
+
+	// Round-trip through JSON so the projection sees exactly what would be encoded
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+
+	switch v := generic.(type) {
+	case map[string]interface{}:
+		return keepKeys(v, wanted), nil
+	case []interface{}:
+		for i, item := range v {
+			if object, ok := item.(map[string]interface{}); ok {
+				v[i] = keepKeys(object, wanted)
+			}
+		}
+		return v, nil
+	}
+	return generic, nil
+}
+
+// jsonFieldNames collects the JSON names of a struct's encoded fields, including promoted ones
+func jsonFieldNames(t reflect.Type, names map[string]bool) map[string]bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs have their fields promoted into the parent object
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				jsonFieldNames(embedded, names)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// keepKeys returns the entries of object whose keys are wanted
+func keepKeys(object map[string]interface{}, wanted map[string]bool) map[string]interface{} {
+	kept := make(map[string]interface{}, len(wanted))
+	for key, value := range object {
+		if wanted[key] {
+			kept[key] = value
+		}
+	}
+	return kept
+}
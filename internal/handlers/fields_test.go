@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestWriteSuccessSelectsFields(t *testing.T) {
+	h := &BitcoinHandler{}
+	data := []models.AddressWithBalance{{
+		Address: models.Address{Address: "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", Label: "cold", CreatedAt: time.Now()},
+		Balance: models.Balance{TotalBalance: 2500000},
+	}}
+
+	testCases := []struct {
+		fields string
+		status int
+		want   []string
+	}{
+		{"address,balance", http.StatusOK, []string{"address", "balance"}},
+		{"label", http.StatusOK, []string{"label"}},
+		{"balance_error", http.StatusOK, []string{}}, // known but omitted from this response
+		{"address,nope", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		h.writeSuccess(rec, httptest.NewRequest("GET", "/addresses?fields="+tc.fields, nil), http.StatusOK, data)
+		if rec.Code != tc.status {
+			t.Errorf("fields=%s: got status %d; want %d", tc.fields, rec.Code, tc.status)
+			continue
+		}
+		if tc.want == nil {
+			continue
+		}
+
+		var response struct {
+			Data []map[string]json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || len(response.Data) != 1 {
+			t.Fatalf("fields=%s: unexpected body %s: %v", tc.fields, rec.Body, err)
+		}
+		got := []string{}
+		for _, key := range []string{"address", "balance", "label", "balance_error", "created_at"} {
+			if _, ok := response.Data[0][key]; ok {
+				got = append(got, key)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("fields=%s: got keys %v; want %v", tc.fields, got, tc.want)
+		}
+	}
+}
+
+func TestWriteSuccessFieldsUnsupported(t *testing.T) {
+	h := &BitcoinHandler{}
+	rec := httptest.NewRecorder()
+	h.writeSuccess(rec, httptest.NewRequest("GET", "/?fields=a", nil), http.StatusOK, map[string]string{"a": "b"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected fields on a map response to be rejected, got %d", rec.Code)
+	}
+}
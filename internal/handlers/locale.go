@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Amount kinds that get a locale-formatted display_ field
+const (
+	amountSatoshis = iota // formatted as BTC
+	amountBTC
+	amountFiat
+)
+
+// displayAmounts maps JSON field names to the kind of amount they hold
+var displayAmounts = map[string]int{
+	"amount":              amountSatoshis,
+	"confirmed_balance":   amountSatoshis,
+	"unconfirmed_balance": amountSatoshis,
+	"total_balance":       amountSatoshis,
+	"immature_balance":    amountSatoshis,
+	"balance_btc":         amountBTC,
+	"provider_fiat_value": amountFiat,
+}
+
+// Decimal places of displayed amounts
+const (
+	btcDecimals  = 8
+	fiatDecimals = 2
+)
+
+// displayFormat formats amounts with the separators and decimal mark of a locale
+type displayFormat struct {
+	printer  *message.Printer
+	decimals int
+}
+
+// parseDisplayFormat reads the locale from ?locale= or the Accept-Language header, and the number
+// of BTC decimals from ?decimals=. It returns nil when the client asked for no locale.
+func parseDisplayFormat(r *http.Request) (*displayFormat, error) {
+	query := r.URL.Query()
+
+	var tag language.Tag
+	if value := query.Get("locale"); value != "" {
+		parsed, err := language.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale: %q", value)
+		}
+		tag = parsed
+	} else if header := r.Header.Get("Accept-Language"); header != "" {
+		// Tags come back sorted by preference; a malformed header is ignored like a missing one
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err != nil || len(tags) == 0 {
+			return nil, nil
+		}
+		tag = tags[0]
+	} else {
+		return nil, nil
+	}
+
+	format := &displayFormat{printer: message.NewPrinter(tag), decimals: btcDecimals}
+	if value := query.Get("decimals"); value != "" {
+		decimals, err := strconv.Atoi(value)
+		if err != nil || decimals < 0 || decimals > btcDecimals {
+			return nil, fmt.Errorf("invalid decimals: expected 0 to %d", btcDecimals)
+		}
+		format.decimals = decimals
+	}
+
+	return format, nil
+}
+
+// format renders an amount of the given kind
+func (f *displayFormat) format(value json.Number, kind int) (string, bool) {
+	decimals := f.decimals
+	var amount float64
+	switch kind {
+	case amountSatoshis:
+		satoshis, err := value.Int64()
+		if err != nil {
+			return "", false
+		}
+		amount = models.SatoshisToBTC(satoshis)
+	case amountBTC, amountFiat:
+		parsed, err := value.Float64()
+		if err != nil {
+			return "", false
+		}
+		amount = parsed
+		if kind == amountFiat {
+			decimals = fiatDecimals
+		}
+	}
+
+	return f.printer.Sprint(number.Decimal(amount, number.Scale(decimals))), true
+}
+
+// localize adds a display_<field> string next to every known amount field in the response, at any
+// depth. The response is round-tripped through JSON with exact numbers, so raw values are unchanged.
+func localize(data interface{}, format *displayFormat) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	format.addDisplayFields(generic)
+	return generic, nil
+}
+
+// addDisplayFields walks a decoded JSON value and adds display fields to its objects
+func (f *displayFormat) addDisplayFields(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		display := map[string]interface{}{}
+		for key, field := range v {
+			if amount, ok := field.(json.Number); ok {
+				if kind, known := displayAmounts[key]; known {
+					if formatted, ok := f.format(amount, kind); ok {
+						display["display_"+key] = formatted
+					}
+				}
+				continue
+			}
+			f.addDisplayFields(field)
+		}
+		for key, formatted := range display {
+			v[key] = formatted
+		}
+	case []interface{}:
+		for _, item := range v {
+			f.addDisplayFields(item)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestLocaleDisplayFields(t *testing.T) {
+	h := &BitcoinHandler{}
+	fiat := 1234567.891
+	data := models.DetailedBalance{
+		Balance:  models.Balance{TotalBalance: 123456789012, BalanceBTC: 1234.56789012},
+		Provider: &models.AddressStats{ProviderFiatValue: &fiat},
+	}
+
+	testCases := []struct {
+		name     string
+		target   string
+		language string
+		want     []string
+	}{
+		{"english", "/?locale=en-US", "", []string{`"display_total_balance":"1,234.56789012"`, `"display_balance_btc":"1,234.56789012"`}},
+		{"german from header", "/", "de-DE,de;q=0.9,en;q=0.5", []string{`"display_total_balance":"1.234,56789012"`}},
+		{"query overrides header", "/?locale=en", "de-DE", []string{`"display_total_balance":"1,234.56789012"`}},
+		{"rounded", "/?locale=en&decimals=2", "", []string{`"display_total_balance":"1,234.57"`, `"display_unconfirmed_balance":"0.00"`}},
+		{"nested fiat", "/?locale=de", "", []string{`"display_provider_fiat_value":"1.234.567,89"`}},
+		{"raw values kept", "/?locale=de", "", []string{`"total_balance":123456789012,`, `"balance_btc":1234.56789012,`, `"provider_fiat_value":1234567.891,`}},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", tc.target, nil)
+		if tc.language != "" {
+			r.Header.Set("Accept-Language", tc.language)
+		}
+		rec := httptest.NewRecorder()
+		h.writeSuccess(rec, r, http.StatusOK, data)
+
+		for _, want := range tc.want {
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("%s: expected %s in %d %s", tc.name, want, rec.Code, rec.Body)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.writeSuccess(rec, httptest.NewRequest("GET", "/", nil), http.StatusOK, data)
+	if strings.Contains(rec.Body.String(), "display_") {
+		t.Errorf("Expected no display fields without a locale, got %s", rec.Body)
+	}
+
+	for _, target := range []string{"/?locale=not_a_locale!", "/?locale=en&decimals=9"} {
+		rec := httptest.NewRecorder()
+		h.writeSuccess(rec, httptest.NewRequest("GET", target, nil), http.StatusOK, data)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ihladush/bitcoin/internal/address"
+)
+
+// ReadOnly rejects every request that could modify data with 403 Forbidden.
+// Background sync is not affected because it does not go through the API, and draining changes no data.
+func (h *BitcoinHandler) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && route.GetName() == DrainRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			h.writeError(w, r, http.StatusForbidden, "Server is in read-only mode")
+		}
+	})
+}
+
+// AddressParam rejects requests whose {address} path variable is too long or contains characters no
+// address can have with 400 Bad Request, before any database or provider work is done.
+// An optional ?network= qualifier must name the configured network.
+func (h *BitcoinHandler) AddressParam(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := mux.Vars(r)["address"]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !address.Plausible(address.Normalize(value)) {
+			h.writeError(w, r, http.StatusBadRequest, "Invalid address in path")
+			return
+		}
+		if err := h.service.CheckNetwork(r.URL.Query().Get("network")); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ihladush/bitcoin/internal/services"
+)
+
+func TestReadOnly(t *testing.T) {
+	h := &BitcoinHandler{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodHead, http.StatusOK},
+		{http.MethodPost, http.StatusForbidden},
+		{http.MethodPut, http.StatusForbidden},
+		{http.MethodDelete, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		h.ReadOnly(next).ServeHTTP(rec, httptest.NewRequest(tc.method, "/addresses", nil))
+		if rec.Code != tc.want {
+			t.Errorf("%s: got status %d; want %d", tc.method, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestAddressParam(t *testing.T) {
+	h := &BitcoinHandler{service: services.NewBitcoinService(nil, nil)}
+	router := mux.NewRouter()
+	router.Use(h.AddressParam)
+	router.HandleFunc("/addresses/{address}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router.HandleFunc("/addresses", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := []struct {
+		path string
+		want int
+	}{
+		{"/addresses/bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", http.StatusOK},
+		{"/addresses/BC1Q0SG9RDST255GTLDSMCF8RK0764AVQY2H2KSQS5", http.StatusOK},
+		{"/addresses/not-an-address", http.StatusBadRequest},
+		{"/addresses/1A1z'%20OR%201=1", http.StatusBadRequest},
+		{"/addresses/" + strings.Repeat("a", 10000), http.StatusBadRequest},
+		{"/addresses/bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5?network=mainnet", http.StatusOK},
+		{"/addresses/bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5?network=testnet", http.StatusBadRequest},
+		{"/addresses", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.want {
+			t.Errorf("%.60s: got status %d; want %d", tc.path, rec.Code, tc.want)
+		}
+	}
+}
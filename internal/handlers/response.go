@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/services"
+)
+
+// Helper methods for response handling
+
+// rawError is the minimal error body sent instead of the envelope to clients that asked for raw responses
+type rawError struct {
+	Error string      `json:"error"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// rawMessage is the body of a message response sent to clients that asked for raw responses
+type rawMessage struct {
+	Message string `json:"message"`
+}
+
+// RawResponseHeader is the request header that asks for responses without the envelope
+const RawResponseHeader = "X-Raw-Response"
+
+// wantsRaw reports whether the client asked for responses without the {success, data} envelope,
+// with ?raw=true or an X-Raw-Response: true header
+func wantsRaw(r *http.Request) bool {
+	if raw, err := strconv.ParseBool(r.URL.Query().Get("raw")); err == nil {
+		return raw
+	}
+	raw, _ := strconv.ParseBool(r.Header.Get(RawResponseHeader))
+	return raw
+}
+
+// writeSuccess writes data in the success envelope, or bare for raw responses, trimmed to the fields
+// listed in ?fields when present and with locale-formatted amounts when a locale was requested
+func (h *BitcoinHandler) writeSuccess(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		projected, err := selectFields(data, strings.Split(fields, ","))
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		data = projected
+	}
+
+	format, err := parseDisplayFormat(r)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if format != nil {
+		localized, err := localize(data, format)
+		if err != nil {
+			h.writeError(w, r, http.StatusInternalServerError, "Failed to format amounts")
+			return
+		}
+		data = localized
+	}
+
+	if wantsRaw(r) {
+		writeJSON(w, statusCode, data)
+		return
+	}
+	writeJSON(w, statusCode, models.SuccessResponse(data))
+}
+
+func (h *BitcoinHandler) writeError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	if prefersPlainText(r) {
+		writePlainText(w, statusCode, message)
+		return
+	}
+
+	if wantsRaw(r) {
+		writeJSON(w, statusCode, rawError{Error: message})
+		return
+	}
+	writeJSON(w, statusCode, models.ErrorResponse(message))
+}
+
+// writeFailure writes an error envelope that also carries data describing the partial outcome
+func (h *BitcoinHandler) writeFailure(w http.ResponseWriter, r *http.Request, statusCode int, message string, data interface{}) {
+	if prefersPlainText(r) {
+		writePlainText(w, statusCode, message)
+		return
+	}
+
+	if wantsRaw(r) {
+		writeJSON(w, statusCode, rawError{Error: message, Data: data})
+		return
+	}
+	response := models.ErrorResponse(message)
+	response.Data = data
+	writeJSON(w, statusCode, response)
+}
+
+func (h *BitcoinHandler) writeMessage(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	if prefersPlainText(r) {
+		writePlainText(w, statusCode, message)
+		return
+	}
+
+	if wantsRaw(r) {
+		writeJSON(w, statusCode, rawMessage{Message: message})
+		return
+	}
+	writeJSON(w, statusCode, models.MessageResponse(message))
+}
+
+// writeJSON encodes response into a buffer before writing anything, so an encoding failure is
+// logged and answered with a clean 500 instead of a success status followed by a truncated body
+func writeJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(response); err != nil {
+		log.Printf("Failed to encode %d response: %v", statusCode, err)
+		buf.Reset()
+		json.NewEncoder(&buf).Encode(models.ErrorResponse("Failed to encode response"))
+		statusCode = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// defaultRetryAfter is sent when a rate-limited provider did not say when to retry
+const defaultRetryAfter = 60 * time.Second
+
+// writeRateLimited answers 503 with a Retry-After header when err comes from the provider
+// rate-limiting us, reporting whether it did
+func (h *BitcoinHandler) writeRateLimited(w http.ResponseWriter, r *http.Request, err error) bool {
+	var limited *services.RateLimitError
+	if !errors.As(err, &limited) {
+		return false
+	}
+
+	retryAfter := limited.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = defaultRetryAfter
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	h.writeFailure(w, r, http.StatusServiceUnavailable, "Provider rate limit exceeded, retry later", models.RateLimitStatus{
+		RetryAfterSeconds: seconds,
+		Limit:             limited.Limit,
+		Remaining:         limited.Remaining,
+	})
+	return true
+}
+
+// writeServiceError reports a failed service call, mapping a provider rate limit to 503 and an
+// exceeded request deadline to 504
+func (h *BitcoinHandler) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.writeRateLimited(w, r, err) {
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.writeError(w, r, http.StatusGatewayTimeout, "Request deadline exceeded")
+		return
+	}
+
+	h.writeError(w, r, http.StatusInternalServerError, err.Error())
+}
+
+// writePlainText writes a single line of text for clients that asked for text/plain
+func writePlainText(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(message + "\n"))
+}
+
+// prefersPlainText reports whether the Accept header ranks text/plain above JSON.
+// Wildcards count at half weight so an explicit type beats them, and ties go to JSON
+// so clients without an Accept header are unaffected.
+func prefersPlainText(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	var plainQ, jsonQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseMediaRange(part)
+		switch mediaType {
+		case "text/plain":
+			plainQ = max(plainQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/*":
+			plainQ = max(plainQ, q/2)
+		case "application/*", "*/*":
+			jsonQ = max(jsonQ, q/2)
+		}
+	}
+
+	return plainQ > jsonQ
+}
+
+// parseMediaRange splits an Accept entry into its media type and quality value
+func parseMediaRange(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+
+	for _, param := range params[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+
+	return mediaType, q
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestPrefersPlainText(t *testing.T) {
+	testCases := []struct {
+		accept string
+		plain  bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"text/plain", true},
+		{"text/plain, */*", true},
+		{"application/json, text/plain", false},
+		{"application/json;q=0.5, text/plain", true},
+		{"text/plain;q=0.2, application/json;q=0.9", false},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/health", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		if got := prefersPlainText(r); got != tc.plain {
+			t.Errorf("prefersPlainText(%q) = %v; want %v", tc.accept, got, tc.plain)
+		}
+	}
+}
+
+func TestWriteSuccessEncodeFailure(t *testing.T) {
+	h := &BitcoinHandler{}
+
+	rec := httptest.NewRecorder()
+	h.writeSuccess(rec, httptest.NewRequest("GET", "/", nil), http.StatusOK, map[string]interface{}{"unencodable": make(chan int)})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	var response models.APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected a complete JSON body, got %q: %v", rec.Body, err)
+	}
+	if response.Success || response.Error == "" {
+		t.Errorf("Expected an error envelope, got %+v", response)
+	}
+}
+
+func TestRawResponses(t *testing.T) {
+	h := &BitcoinHandler{}
+	data := map[string]int{"total_balance": 100}
+
+	testCases := []struct {
+		name   string
+		target string
+		header string
+		write  func(w http.ResponseWriter, r *http.Request)
+		want   string
+	}{
+		{"envelope by default", "/", "", func(w http.ResponseWriter, r *http.Request) { h.writeSuccess(w, r, http.StatusOK, data) }, `{"success":true,"data":{"total_balance":100}}`},
+		{"raw query", "/?raw=true", "", func(w http.ResponseWriter, r *http.Request) { h.writeSuccess(w, r, http.StatusOK, data) }, `{"total_balance":100}`},
+		{"raw header", "/", "true", func(w http.ResponseWriter, r *http.Request) { h.writeSuccess(w, r, http.StatusOK, data) }, `{"total_balance":100}`},
+		{"query overrides header", "/?raw=false", "true", func(w http.ResponseWriter, r *http.Request) { h.writeSuccess(w, r, http.StatusOK, data) }, `{"success":true,"data":{"total_balance":100}}`},
+		{"raw list", "/?raw=1", "", func(w http.ResponseWriter, r *http.Request) { h.writeSuccess(w, r, http.StatusOK, []int{1, 2}) }, `[1,2]`},
+		{"raw error", "/?raw=true", "", func(w http.ResponseWriter, r *http.Request) {
+			h.writeError(w, r, http.StatusNotFound, "address not found")
+		}, `{"error":"address not found"}`},
+		{"raw message", "/?raw=true", "", func(w http.ResponseWriter, r *http.Request) { h.writeMessage(w, r, http.StatusOK, "done") }, `{"message":"done"}`},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", tc.target, nil)
+		if tc.header != "" {
+			r.Header.Set("X-Raw-Response", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		tc.write(rec, r)
+
+		if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s: body = %s; want %s", tc.name, got, tc.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.writeError(rec, httptest.NewRequest("GET", "/?raw=true", nil), http.StatusNotFound, "address not found")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected raw errors to keep their status, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// Search handles GET /search
+func (h *BitcoinHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+
+	// A missing limit gets the repository's default page size
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	result, err := h.service.Search(query, limit)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.NewSearchResponse(*result, h.thresholds))
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/services"
+)
+
+// csvHeader lists the columns written by the CSV export
+var csvHeader = []string{"hash", "address", "amount", "confirmations", "block_height", "timestamp", "type", "status"}
+
+// GetAllTransactions handles GET /transactions
+func (h *BitcoinHandler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if wantsCSV(r) {
+		h.writeTransactionsCSV(w, r, filter)
+		return
+	}
+
+	transactions, err := h.service.QueryTransactions(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, models.NewTransactionResponses(transactions, h.thresholds))
+}
+
+// maxImportBytes caps the size of a raw transaction import
+const maxImportBytes = 10 << 20
+
+// ImportTransactions handles POST /addresses/{address}/transactions/import
+func (h *BitcoinHandler) ImportTransactions(w http.ResponseWriter, r *http.Request) {
+	addr := addressVar(r)
+
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Request body must be at most %d bytes of provider JSON", maxImportBytes))
+		return
+	}
+
+	preview, err := h.service.ImportTransactions(addr, raw)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImportUnsupported):
+			h.writeError(w, r, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, services.ErrInvalidImport):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		}
+		return
+	}
+
+	h.writeSuccess(w, r, http.StatusOK, preview)
+}
+
+// writeTransactionsCSV streams the matching transactions as CSV rows, refusing exports over the row cap
+func (h *BitcoinHandler) writeTransactionsCSV(w http.ResponseWriter, r *http.Request, filter models.TransactionFilter) {
+	filter, err := h.service.BoundExport(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrExportTooLarge) {
+			h.writeError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+		} else {
+			h.writeError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		log.Printf("Failed to write CSV header: %v", err)
+		return
+	}
+
+	err = h.service.StreamTransactions(r.Context(), filter, func(tx *models.Transaction) error {
+		return cw.Write([]string{
+			tx.Hash,
+			tx.Address,
+			strconv.FormatInt(tx.Amount, 10),
+			strconv.Itoa(tx.Confirmations),
+			strconv.Itoa(tx.BlockHeight),
+			tx.Timestamp.UTC().Format(time.RFC3339),
+			tx.Type,
+			h.thresholds.Status(tx.Confirmations),
+		})
+	})
+	cw.Flush()
+
+	// The status line is already sent, so a failure can only be logged
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		log.Printf("CSV export aborted: %v", err)
+	}
+}
+
+// wantsCSV reports whether the client asked for a CSV response
+func wantsCSV(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/csv") || r.URL.Query().Get("format") == "csv"
+}
+
+// parseTransactionFilter reads transaction filters, sorting and pagination from the query string
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := models.TransactionFilter{
+		Address:  address.Normalize(query.Get("address")),
+		Type:     query.Get("type"),
+		SortBy:   query.Get("sort"),
+		SortDesc: true,
+	}
+
+	if filter.Type != "" && filter.Type != "sent" && filter.Type != "received" {
+		return filter, fmt.Errorf("invalid type: %s", filter.Type)
+	}
+
+	// Rejected here because a CSV export has sent its status before the query would fail
+	switch filter.SortBy {
+	case "", models.TransactionSortTimestamp, models.TransactionSortAmount, models.TransactionSortBlockHeight:
+	default:
+		return filter, fmt.Errorf("invalid sort: %s; expected timestamp, amount or block_height", filter.SortBy)
+	}
+
+	var err error
+	if filter.IncludeArchive, err = parseHistoryParam(query.Get("history")); err != nil {
+		return filter, err
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.SortDesc = false
+	default:
+		return filter, fmt.Errorf("invalid order: %s", query.Get("order"))
+	}
+
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseEndTimeParam(query.Get("to")); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if filter.MinAmount, err = parseAmountParam(query.Get("min_amount")); err != nil {
+		return filter, fmt.Errorf("invalid min_amount: %w", err)
+	}
+	if filter.MaxAmount, err = parseAmountParam(query.Get("max_amount")); err != nil {
+		return filter, fmt.Errorf("invalid max_amount: %w", err)
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	return filter, nil
+}
+
+// parseHistoryParam reports whether ?history= asks for the full history including archived
+// transactions ("full") rather than the recent working set ("hot", the default)
+func parseHistoryParam(value string) (bool, error) {
+	switch value {
+	case "", "hot":
+		return false, nil
+	case "full":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid history: %s; expected hot or full", value)
+	}
+}
+
+// parseTimeParam accepts either an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, dateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date, got %q", value)
+}
+
+// dateLayout is the date-only form accepted by parseTimeParam
+const dateLayout = "2006-01-02"
+
+// parseEndTimeParam parses the inclusive end of a range like parseTimeParam, except that a bare date
+// stands for the end of that day, so its transactions are included
+func parseEndTimeParam(value string) (*time.Time, error) {
+	t, err := parseTimeParam(value)
+	if err != nil || t == nil {
+		return t, err
+	}
+	if _, err := time.Parse(dateLayout, value); err == nil {
+		end := t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		return &end, nil
+	}
+	return t, nil
+}
+
+// parseAmountParam parses an optional amount in satoshis
+func parseAmountParam(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("expected an integer amount in satoshis, got %q", value)
+	}
+
+	return &amount, nil
+}
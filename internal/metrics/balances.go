@@ -0,0 +1,105 @@
+// Package metrics exposes application metrics in the Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// addressSeries is the latest balance gauge value of one address
+type addressSeries struct {
+	label    string
+	satoshis int64
+}
+
+// AddressBalances holds one btc_address_balance_satoshis gauge per address. Series are capped at
+// maxSeries and, when an allowlist is given, limited to the listed addresses so that tracking many
+// addresses cannot blow up the metric's cardinality.
+type AddressBalances struct {
+	mu        sync.Mutex
+	maxSeries int
+	allowlist map[string]bool
+	series    map[string]addressSeries
+	dropped   int64
+}
+
+// NewAddressBalances creates an empty gauge set; an empty allowlist admits any address up to maxSeries
+func NewAddressBalances(maxSeries int, allowlist []string) *AddressBalances {
+	g := &AddressBalances{
+		maxSeries: maxSeries,
+		series:    make(map[string]addressSeries),
+	}
+	if len(allowlist) > 0 {
+		g.allowlist = make(map[string]bool, len(allowlist))
+		for _, address := range allowlist {
+			g.allowlist[address] = true
+		}
+	}
+	return g
+}
+
+// Set records the balance of an address. Addresses outside the allowlist are ignored, and new
+// addresses beyond the series limit are counted as dropped instead of added.
+func (g *AddressBalances) Set(address, label string, satoshis int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.allowlist != nil && !g.allowlist[address] {
+		return
+	}
+	if _, exists := g.series[address]; !exists && len(g.series) >= g.maxSeries {
+		g.dropped++
+		return
+	}
+	g.series[address] = addressSeries{label: label, satoshis: satoshis}
+}
+
+// Delete removes the series of an address that is no longer tracked
+func (g *AddressBalances) Delete(address string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.series, address)
+}
+
+// WriteTo writes the gauges in the Prometheus text format, ordered by address
+func (g *AddressBalances) WriteTo(w io.Writer) (int64, error) {
+	g.mu.Lock()
+	addresses := make([]string, 0, len(g.series))
+	for address := range g.series {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var b strings.Builder
+	b.WriteString("# HELP btc_address_balance_satoshis Total balance of a tracked address in satoshis, updated after each sync.\n")
+	b.WriteString("# TYPE btc_address_balance_satoshis gauge\n")
+	for _, address := range addresses {
+		s := g.series[address]
+		fmt.Fprintf(&b, "btc_address_balance_satoshis{address=\"%s\",label=\"%s\"} %d\n", escapeLabel(address), escapeLabel(s.label), s.satoshis)
+	}
+	b.WriteString("# HELP btc_address_balance_series_dropped_total Balance updates dropped because the series limit was reached.\n")
+	b.WriteString("# TYPE btc_address_balance_series_dropped_total counter\n")
+	fmt.Fprintf(&b, "btc_address_balance_series_dropped_total %d\n", g.dropped)
+	g.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the gauges to a Prometheus scraper
+func (g *AddressBalances) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	g.WriteTo(w)
+}
+
+// labelEscaper escapes label values as required by the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
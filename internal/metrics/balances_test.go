@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddressBalances(t *testing.T) {
+	g := NewAddressBalances(2, nil)
+	g.Set("3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", `cold "vault"`, 2500000)
+	g.Set("bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "hot", 100000)
+	g.Set("bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "hot", 90000) // updates keep the series
+	g.Set("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", 5000000000)       // over the limit
+
+	var b strings.Builder
+	if _, err := g.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`btc_address_balance_satoshis{address="3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd",label="cold \"vault\""} 2500000`,
+		`btc_address_balance_satoshis{address="bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5",label="hot"} 90000`,
+		"btc_address_balance_series_dropped_total 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa") {
+		t.Errorf("Expected the series over the limit to be dropped:\n%s", out)
+	}
+
+	// Deleting frees a slot for another address
+	g.Delete("3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd")
+	g.Set("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", 5000000000)
+	b.Reset()
+	g.WriteTo(&b)
+	if strings.Contains(b.String(), "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd") || !strings.Contains(b.String(), "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa") {
+		t.Errorf("Expected the deleted series to be replaced:\n%s", b.String())
+	}
+}
+
+func TestAddressBalancesAllowlist(t *testing.T) {
+	g := NewAddressBalances(10, []string{"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"})
+	g.Set("3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", "", 1)
+	g.Set("bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "", 2)
+
+	var b strings.Builder
+	g.WriteTo(&b)
+	if !strings.Contains(b.String(), "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd") || strings.Contains(b.String(), "bc1q0sg9") {
+		t.Errorf("Expected only the allowlisted address:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), "btc_address_balance_series_dropped_total 0") {
+		t.Errorf("Addresses outside the allowlist should not count as dropped:\n%s", b.String())
+	}
+}
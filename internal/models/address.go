@@ -7,7 +7,18 @@ import "time"
 type Address struct {
 	ID         int       `json:"id" db:"id"`
 	Address    string    `json:"address" db:"address"`
+	Network    string    `json:"network" db:"network"`
 	Label      string    `json:"label" db:"label"`
+	Type       string    `json:"type,omitempty" db:"address_type"`
+	Owned      bool      `json:"owned" db:"owned"` // false for watch-only addresses
+	// OwnershipVerifiedAt is set when ownership was proven with a signed message
+	OwnershipVerifiedAt *time.Time `json:"ownership_verified_at,omitempty" db:"ownership_verified_at"`
+	// HistoryTruncated is set once older transactions were pruned to respect the history cap
+	HistoryTruncated bool `json:"history_truncated" db:"history_truncated"`
+	// SyncEnabled is false while syncing is paused; paused addresses are skipped by background and manual syncs
+	SyncEnabled bool `json:"sync_enabled" db:"sync_enabled"`
+	// Priority weights the address in background sync scheduling, from MinSyncPriority to MaxSyncPriority
+	Priority   int       `json:"priority" db:"priority"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	LastSynced *time.Time `json:"last_synced" db:"last_synced"`
 }
@@ -15,5 +26,174 @@ type Address struct {
 // AddAddressRequest represents the request payload for adding an address
 type AddAddressRequest struct {
 	Address string `json:"address"`
+	Network string `json:"network,omitempty"` // defaults to the configured network
 	Label   string `json:"label,omitempty"`
+	Owned   bool   `json:"owned,omitempty"`
+}
+
+// UpdateAddressRequest represents a partial update of an address; nil fields are left unchanged
+type UpdateAddressRequest struct {
+	Label    *string `json:"label,omitempty"`
+	Owned    *bool   `json:"owned,omitempty"`
+	Priority *int    `json:"priority,omitempty"`
+}
+
+// Bounds of an address's sync priority; new addresses start at MinSyncPriority
+const (
+	MinSyncPriority = 0
+	MaxSyncPriority = 10
+)
+
+// OwnershipChallenge is a message issued by the server for the owner of an address to sign.
+// It names the address and a random nonce, and proves ownership once, before ExpiresAt.
+type OwnershipChallenge struct {
+	Address   string    `json:"address"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerifyOwnershipRequest carries an ownership challenge signed with the key of the address being verified
+type VerifyOwnershipRequest struct {
+	Message   string `json:"message"` // the challenge message, exactly as issued`
+	Signature string `json:"signature"` // base64 compact signature, as produced by signmessage
+}
+
+// Portfolio aggregates balances across tracked addresses
+type Portfolio struct {
+	AddressCount       int     `json:"address_count"`
+	OwnedOnly          bool    `json:"owned_only"`
+	ConfirmedBalance   int64   `json:"confirmed_balance"`
+	UnconfirmedBalance int64   `json:"unconfirmed_balance"`
+	TotalBalance       int64   `json:"total_balance"`
+	BalanceBTC         float64 `json:"balance_btc"`
+}
+
+// Currencies a portfolio history can be valued in
+const (
+	CurrencyBTC = "BTC"
+)
+
+// PortfolioPoint is the portfolio's balance at the end of one history bucket, in satoshis
+type PortfolioPoint struct {
+	Period             string    `json:"period"`    // YYYY-MM-DD for days, the Monday starting the week for weeks, YYYY-MM for months
+	Timestamp          time.Time `json:"timestamp"` // the instant the balances are taken at: the bucket's end, or to for the last bucket
+	AddressCount       int       `json:"address_count"`
+	ConfirmedBalance   int64     `json:"confirmed_balance"`
+	UnconfirmedBalance int64     `json:"unconfirmed_balance"`
+	TotalBalance       int64     `json:"total_balance"`
+	BalanceBTC         float64   `json:"balance_btc"`
+}
+
+// PortfolioHistory is a time series of the total balance across tracked addresses
+type PortfolioHistory struct {
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Interval  string           `json:"interval"`
+	Currency  string           `json:"currency"`
+	OwnedOnly bool             `json:"owned_only"`
+	Points    []PortfolioPoint `json:"points"`
+}
+
+// CleanupResult reports the addresses archived by a cleanup run
+type CleanupResult struct {
+	Archived []string `json:"archived"`
+	Count    int      `json:"count"`
+}
+
+// TransactionArchiveResult reports how many transactions an archive run moved out of the working set
+type TransactionArchiveResult struct {
+	Archived int64 `json:"archived"`
+}
+
+// TypeBackfillResult reports a run of the address type backfill
+type TypeBackfillResult struct {
+	Checked   int `json:"checked"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Unknown   int `json:"unknown"` // addresses whose type could not be detected; left as stored
+}
+
+// BalanceRepair reports an address whose stored balance disagreed with the provider, in satoshis
+type BalanceRepair struct {
+	Address  string `json:"address"`
+	Before   int64  `json:"before"`
+	After    int64  `json:"after"`
+	Provider int64  `json:"provider"`
+	Resolved bool   `json:"resolved"` // the stored balance matches the provider after the repair
+}
+
+// RecalculateResult summarizes a balance recalculation across all tracked addresses
+type RecalculateResult struct {
+	Checked int               `json:"checked"`
+	Drifted int               `json:"drifted"`
+	Changed int               `json:"changed"`
+	Repairs []BalanceRepair   `json:"repairs"`
+	Failed  map[string]string `json:"failed"` // address -> error
+}
+
+// Recalculation job statuses
+const (
+	RecalculationRunning   = "running"
+	RecalculationCompleted = "completed"
+	RecalculationFailed    = "failed"
+)
+
+// RecalculateJob reports the progress of a background balance recalculation
+type RecalculateJob struct {
+	Status     string             `json:"status"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	Result     *RecalculateResult `json:"result,omitempty"` // partial when the run failed
+	Error      string             `json:"error,omitempty"`
+}
+
+// BulkAddRequest represents the request payload for adding several addresses at once
+type BulkAddRequest struct {
+	Addresses []AddAddressRequest `json:"addresses"`
+}
+
+// Bulk item statuses
+const (
+	BulkStatusCreated = "created"
+	BulkStatusFailed  = "failed"
+)
+
+// BulkResult reports the outcome for a single entry of a bulk request
+type BulkResult struct {
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkAddResult summarizes a bulk add
+type BulkAddResult struct {
+	Created int          `json:"created"`
+	Failed  int          `json:"failed"`
+	Results []BulkResult `json:"results"`
+}
+
+// BatchRemoveRequest represents the request payload for removing several addresses
+type BatchRemoveRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// BatchRemoveResult reports the outcome of a batch removal
+type BatchRemoveResult struct {
+	Removed  []string `json:"removed"`
+	NotFound []string `json:"not_found"`
+}
+
+// TagAssignmentRequest represents the request payload for adding or removing a tag on several addresses
+type TagAssignmentRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// TagAssignmentResult reports the outcome of a bulk tag change: the addresses whose tags changed,
+// tracked addresses already in the requested state, and addresses that are not tracked
+type TagAssignmentResult struct {
+	Tag       string   `json:"tag"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	NotFound  []string `json:"not_found"`
 }
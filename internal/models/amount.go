@@ -0,0 +1,20 @@
+package models
+
+import "math"
+
+// SatoshisPerBTC is the number of satoshis in one bitcoin
+const SatoshisPerBTC = 100000000
+
+// MaxSupplySatoshis is the total bitcoin supply cap in satoshis
+const MaxSupplySatoshis = 21000000 * SatoshisPerBTC
+
+// SatoshisToBTC converts a satoshi amount to BTC.
+// Amounts up to 2^53 satoshis, far beyond the supply cap, convert with a single rounding.
+func SatoshisToBTC(satoshis int64) float64 {
+	return float64(satoshis) / SatoshisPerBTC
+}
+
+// BTCToSatoshis converts a BTC amount to satoshis, rounding to the nearest satoshi
+func BTCToSatoshis(btc float64) int64 {
+	return int64(math.Round(btc * SatoshisPerBTC))
+}
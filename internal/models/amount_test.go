@@ -0,0 +1,54 @@
+package models
+
+import "testing"
+
+func TestSatoshisToBTC(t *testing.T) {
+	testCases := []struct {
+		satoshis int64
+		btc      float64
+	}{
+		{0, 0},
+		{1, 0.00000001},
+		{-1, -0.00000001},
+		{SatoshisPerBTC, 1},
+		{150000, 0.0015},
+		{MaxSupplySatoshis, 21000000},
+		{MaxSupplySatoshis - 1, 20999999.99999999},
+	}
+
+	for _, tc := range testCases {
+		if got := SatoshisToBTC(tc.satoshis); got != tc.btc {
+			t.Errorf("SatoshisToBTC(%d) = %v; want %v", tc.satoshis, got, tc.btc)
+		}
+	}
+}
+
+func TestBTCToSatoshis(t *testing.T) {
+	testCases := []struct {
+		btc      float64
+		satoshis int64
+	}{
+		{0, 0},
+		{0.00000001, 1},
+		{-0.00000001, -1},
+		{0.1, 10000000},
+		{0.29, 29000000},
+		{1, SatoshisPerBTC},
+		{21000000, MaxSupplySatoshis},
+		{20999999.99999999, MaxSupplySatoshis - 1},
+	}
+
+	for _, tc := range testCases {
+		if got := BTCToSatoshis(tc.btc); got != tc.satoshis {
+			t.Errorf("BTCToSatoshis(%v) = %d; want %d", tc.btc, got, tc.satoshis)
+		}
+	}
+}
+
+func TestSatoshiRoundTrip(t *testing.T) {
+	for _, satoshis := range []int64{1, 99999999, 123456789, MaxSupplySatoshis - 1, MaxSupplySatoshis} {
+		if got := BTCToSatoshis(SatoshisToBTC(satoshis)); got != satoshis {
+			t.Errorf("round trip of %d satoshis gave %d", satoshis, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "encoding/json"
+
+// BatchOperation describes a single call within a POST /batch request
+type BatchOperation struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// BatchAddressParams are the parameters of batch methods that act on one address
+type BatchAddressParams struct {
+	Address string `json:"address"`
+}
+
+// BatchTransactionsParams are the parameters of the get_transactions batch method
+type BatchTransactionsParams struct {
+	Address string `json:"address"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+// BatchPortfolioParams are the parameters of the get_portfolio batch method
+type BatchPortfolioParams struct {
+	OwnedOnly bool `json:"owned_only"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// ClientCapabilities lists the optional features a blockchain data provider can back
+type ClientCapabilities struct {
+	AddressStats      bool `json:"address_stats"`      // provider statistics stored on each sync
+	TransactionImport bool `json:"transaction_import"` // raw provider JSON can be imported
+	ChainTip          bool `json:"chain_tip"`          // current best block for GET /chain
+	CoinbaseDetection bool `json:"coinbase_detection"` // mining rewards are recognised and held until mature
+	TransactionFees   bool `json:"transaction_fees"`   // fee lookups for GET /transactions/{hash}/eta
+	MempoolBalance    bool `json:"mempool_balance"`    // unconfirmed_balance reflects mempool transactions
+}
+
+// Capabilities lists the optional features the running service can back
+type Capabilities struct {
+	ClientCapabilities
+	FeeEstimates bool `json:"fee_estimates"` // recommended fee rates for GET /fees
+}
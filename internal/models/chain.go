@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ChainTip describes the provider's current best block
+type ChainTip struct {
+	Height int       `json:"height"`
+	Hash   string    `json:"hash"`
+	Time   time.Time `json:"time"`
+}
+
+// ChainStatus compares the provider's chain tip with the newest block stored locally
+type ChainStatus struct {
+	Tip          ChainTip  `json:"tip"`
+	StoredHeight int       `json:"stored_height"` // highest block height of any stored transaction
+	Lag          int       `json:"lag"`           // blocks between the tip and StoredHeight
+	CheckedAt    time.Time `json:"checked_at"`    // when the tip was fetched from the provider
+}
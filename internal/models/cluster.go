@@ -0,0 +1,13 @@
+package models
+
+// CoSpend is a transaction in which several tracked addresses spent coins together
+type CoSpend struct {
+	Hash      string
+	Addresses []string
+}
+
+// AddressCluster groups tracked addresses that probably belong to the same wallet
+type AddressCluster struct {
+	Addresses    []string `json:"addresses"`
+	Transactions []string `json:"transactions"` // hashes of the co-spends linking the addresses
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ActivityPeriod summarizes an address's transactions in one calendar month
+type ActivityPeriod struct {
+	Period           string `json:"period"` // YYYY-MM
+	TransactionCount int    `json:"transaction_count"`
+	NetAmount        int64  `json:"net_amount"`
+}
+
+// AddressActivity summarizes the stored activity of one address
+type AddressActivity struct {
+	Address          string           `json:"address"`
+	Balance          Balance          `json:"balance"`
+	TransactionCount int              `json:"transaction_count"`
+	TotalReceived    int64            `json:"total_received"`
+	TotalSent        int64            `json:"total_sent"` // negative, following the amount sign convention
+	FirstActivity    *time.Time       `json:"first_activity"`
+	LastActivity     *time.Time       `json:"last_activity"`
+	Timeline         []ActivityPeriod `json:"timeline"`
+}
+
+// SharedTransaction is a transaction that touches both compared addresses
+type SharedTransaction struct {
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	AmountA   int64     `json:"amount_a"`
+	AmountB   int64     `json:"amount_b"`
+}
+
+// AddressComparison is the side-by-side view of two addresses
+type AddressComparison struct {
+	A                  AddressActivity     `json:"a"`
+	B                  AddressActivity     `json:"b"`
+	SharedTransactions []SharedTransaction `json:"shared_transactions"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// FeeEstimates holds recommended fee rates in sat/vB for common confirmation targets
+type FeeEstimates struct {
+	FastestFee  float64 `json:"fastest_fee"`   // next block
+	HalfHourFee float64 `json:"half_hour_fee"` // within about 3 blocks
+	HourFee     float64 `json:"hour_fee"`      // within about 6 blocks
+	EconomyFee  float64 `json:"economy_fee"`   // no time preference
+	MinimumFee  float64 `json:"minimum_fee"`   // lowest rate relayed by nodes
+}
+
+// TransactionFee is the fee paid by a transaction as reported by the provider
+type TransactionFee struct {
+	Hash      string `json:"hash"`
+	Fee       int64  `json:"fee"`   // satoshis
+	VSize     int    `json:"vsize"` // virtual bytes
+	Confirmed bool   `json:"confirmed"`
+}
+
+// FeeRate returns the fee rate in sat/vB
+func (f TransactionFee) FeeRate() float64 {
+	if f.VSize <= 0 {
+		return 0
+	}
+	return float64(f.Fee) / float64(f.VSize)
+}
+
+// TransactionETA estimates when a pending transaction will confirm from where its fee rate sits
+// among the current recommended rates. The estimate is left out for confirmed transactions and for
+// rates below the economy rate, since such transactions have no predictable confirmation time.
+type TransactionETA struct {
+	Hash             string        `json:"hash"`
+	Confirmed        bool          `json:"confirmed"`
+	Fee              int64         `json:"fee"`
+	VSize            int           `json:"vsize"`
+	FeeRate          float64       `json:"fee_rate"`
+	Bracket          string        `json:"bracket"`
+	EstimatedBlocks  *int          `json:"estimated_blocks,omitempty"`
+	EstimatedMinutes *int          `json:"estimated_minutes,omitempty"`
+	CurrentFees      *FeeEstimates `json:"current_fees,omitempty"`
+}
@@ -8,6 +8,14 @@ type APIResponse struct {
 	Message string      `json:"message,omitempty"`
 }
 
+// RateLimitStatus is the data of a 503 response caused by the provider rate-limiting us. Limit and
+// Remaining are only present when the provider reports its quota.
+type RateLimitStatus struct {
+	RetryAfterSeconds int  `json:"retry_after_seconds"`
+	Limit             *int `json:"limit,omitempty"`
+	Remaining         *int `json:"remaining,omitempty"`
+}
+
 // ErrorResponse creates a standardized error response
 func ErrorResponse(message string) APIResponse {
 	return APIResponse{
@@ -31,3 +39,10 @@ func MessageResponse(message string) APIResponse {
 		Message: message,
 	}
 }
+
+// IntegrityReport summarizes the result of a database integrity check
+type IntegrityReport struct {
+	Healthy              bool     `json:"healthy"`
+	Problems             []string `json:"problems"`
+	OrphanedTransactions int      `json:"orphaned_transactions"`
+}
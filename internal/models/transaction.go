@@ -12,8 +12,12 @@ type Transaction struct {
 	BlockHeight   int       `json:"block_height" db:"block_height"`
 	Timestamp     time.Time `json:"timestamp" db:"timestamp"`
 	Type          string    `json:"type" db:"type"` // "sent" or "received"
+	Coinbase      bool      `json:"coinbase" db:"coinbase"` // mining reward; spendable after CoinbaseMaturity confirmations
 }
 
+// CoinbaseMaturity is the number of confirmations before a mining reward can be spent
+const CoinbaseMaturity = 100
+
 // Balance represents the balance for a Bitcoin address
 type Balance struct {
 	Address           string  `json:"address"`
@@ -21,10 +25,277 @@ type Balance struct {
 	UnconfirmedBalance int64  `json:"unconfirmed_balance"` // Unconfirmed balance in satoshis
 	TotalBalance      int64   `json:"total_balance"`      // Total balance in satoshis
 	BalanceBTC        float64 `json:"balance_btc"`        // Balance in BTC
+	ImmatureBalance   int64   `json:"immature_balance,omitempty"` // Confirmed coinbase rewards that are not yet spendable
+}
+
+// ExcludeImmature removes immature coinbase rewards from the confirmed and total balance
+func (b *Balance) ExcludeImmature() {
+	b.ConfirmedBalance -= b.ImmatureBalance
+	b.TotalBalance -= b.ImmatureBalance
+	b.BalanceBTC = SatoshisToBTC(b.TotalBalance)
+}
+
+// AddressStats holds provider-reported statistics about an address, cached from the last sync
+type AddressStats struct {
+	TransactionCount   int        `json:"transaction_count"`
+	OutputCount        int        `json:"output_count"`
+	UnspentOutputCount int        `json:"unspent_output_count"`
+	FirstSeenReceiving *time.Time `json:"first_seen_receiving"`
+	LastSeenReceiving  *time.Time `json:"last_seen_receiving"`
+	FirstSeenSpending  *time.Time `json:"first_seen_spending"`
+	LastSeenSpending   *time.Time `json:"last_seen_spending"`
+	// ProviderFiatValue is the balance in USD as the provider valued it at UpdatedAt; nil when not reported
+	ProviderFiatValue *float64  `json:"provider_fiat_value"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// DetailedBalance extends a balance with the provider statistics stored for the address
+type DetailedBalance struct {
+	Balance
+	Provider *AddressStats `json:"provider"` // nil until a sync has fetched statistics
+}
+
+// BalanceComparison sets the balance derived from stored transactions against the provider's live balance
+type BalanceComparison struct {
+	Address          string       `json:"address"`
+	Derived          Balance      `json:"derived"`
+	Provider         Balance      `json:"provider"`
+	Delta            BalanceDelta `json:"delta"` // provider minus derived
+	Matches          bool         `json:"matches"`
+	HistoryTruncated bool         `json:"history_truncated"` // stored history was capped, a common cause of drift
+}
+
+// BalanceDelta is the difference between two balances in satoshis
+type BalanceDelta struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+	Total       int64 `json:"total"`
 }
 
 // AddressWithBalance combines address info with its current balance
 type AddressWithBalance struct {
 	Address
 	Balance Balance `json:"balance"`
+	// BalanceError is set when the balance could not be computed; Balance is then zero
+	BalanceError string `json:"balance_error,omitempty"`
+}
+
+// TransactionFilter describes the criteria for querying transactions across addresses
+type TransactionFilter struct {
+	Address   string
+	Hash      string
+	Type      string
+	From      *time.Time
+	To        *time.Time
+	MinAmount *int64
+	MaxAmount *int64
+	SortBy    string // one of the TransactionSort fields; empty sorts by timestamp
+	SortDesc  bool
+	Limit     int // 0 means no limit
+	Offset    int
+	// IncludeArchive merges archived transactions into the results; by default only the hot table is read
+	IncludeArchive bool
+}
+
+// TypeCount summarizes an address's stored transactions of one type
+type TypeCount struct {
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	TotalAmount int64  `json:"total_amount"`
+}
+
+// Fields transaction listings can be sorted by
+const (
+	TransactionSortTimestamp   = "timestamp"
+	TransactionSortAmount      = "amount"
+	TransactionSortBlockHeight = "block_height"
+)
+
+// Volume bucket sizes accepted by the volume report
+const (
+	VolumeBucketDay   = "day"
+	VolumeBucketWeek  = "week"
+	VolumeBucketMonth = "month"
+)
+
+// VolumeBucket summarizes an address's stored transactions in one time bucket
+type VolumeBucket struct {
+	Period           string `json:"period"` // YYYY-MM-DD for days, the Monday starting the week for weeks, YYYY-MM for months
+	TransactionCount int    `json:"transaction_count"`
+	Received         int64  `json:"received"`
+	Sent             int64  `json:"sent"` // negative, following the amount sign convention
+	NetAmount        int64  `json:"net_amount"`
+	GrossAmount      int64  `json:"gross_amount"` // received plus the absolute value of sent
+}
+
+// SyncRunResult reports what happened to each address during a full sync run
+type SyncRunResult struct {
+	Synced      []string          `json:"synced"`
+	Failed      map[string]string `json:"failed"`                 // address -> error
+	Skipped     []string          `json:"skipped"`                // not attempted because the run stopped early
+	ResumedFrom *time.Time        `json:"resumed_from,omitempty"` // start of the unfinished run this one continued
+}
+
+// SyncCheckpoint records the progress of a full sync run so it can resume after a restart.
+// Addresses synced since RunStartedAt are done; Cursor is the most recently completed one.
+type SyncCheckpoint struct {
+	RunStartedAt time.Time `json:"run_started_at"`
+	Cursor       string    `json:"cursor"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SyncHealth summarizes recent full sync run outcomes for health checks
+type SyncHealth struct {
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Degraded            bool   `json:"degraded"`
+	LastError           string `json:"last_error,omitempty"`
+}
+
+// Per-address outcomes of a targeted sync
+const (
+	SyncStatusSynced  = "synced"
+	SyncStatusFailed  = "failed"
+	SyncStatusSkipped = "skipped" // not attempted because the run stopped early
+)
+
+// SyncResult reports the outcome of syncing one address in a targeted sync
+type SyncResult struct {
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SyncLogEntry records one sync of an address, kept as a per-address timeline
+type SyncLogEntry struct {
+	Address             string    `json:"address"`
+	StartedAt           time.Time `json:"started_at"`
+	FinishedAt          time.Time `json:"finished_at"`
+	NewTransactions     int       `json:"new_tx"`
+	UpdatedTransactions int       `json:"updated_tx"`
+	Status              string    `json:"status"` // SyncStatusSynced or SyncStatusFailed
+	Error               string    `json:"error,omitempty"`
+}
+
+// SyncBatchRequest lists the addresses to sync with POST /sync/batch
+type SyncBatchRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// SyncPreview describes the changes a sync would make without applying them
+type SyncPreview struct {
+	Address   string        `json:"address"`
+	New       []Transaction `json:"new"`
+	Updated   []Transaction `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	// Confirmed lists the updated transactions that reached the confirmation alert target
+	Confirmed []ConfirmationAlert `json:"-"`
+}
+
+// ConfirmationAlert is the data of a transaction_confirmed notification
+type ConfirmationAlert struct {
+	Address               string      `json:"address"`
+	Target                int         `json:"target"`
+	PreviousConfirmations int         `json:"previous_confirmations"`
+	Transaction           Transaction `json:"transaction"`
+}
+
+// Transaction status labels derived from confirmation depth
+const (
+	StatusPending    = "pending"
+	StatusConfirming = "confirming"
+	StatusConfirmed  = "confirmed"
+	StatusFinal      = "final"
+)
+
+// StatusThresholds sets the confirmation depths at which a transaction becomes confirmed and final.
+// Anything with zero confirmations is pending and anything in between is confirming.
+type StatusThresholds struct {
+	Confirmed int
+	Final     int
+}
+
+// DefaultStatusThresholds treats 6 confirmations as confirmed and 100 as final
+var DefaultStatusThresholds = StatusThresholds{Confirmed: 6, Final: 100}
+
+// Status returns the label for a transaction with the given number of confirmations
+func (t StatusThresholds) Status(confirmations int) string {
+	switch {
+	case confirmations <= 0:
+		return StatusPending
+	case confirmations >= t.Final:
+		return StatusFinal
+	case confirmations >= t.Confirmed:
+		return StatusConfirmed
+	default:
+		return StatusConfirming
+	}
+}
+
+// TransactionResponse is the API representation of a transaction with its computed status
+type TransactionResponse struct {
+	Transaction
+	Status string `json:"status"`
+}
+
+// NewTransactionResponse labels a transaction using the given thresholds
+func NewTransactionResponse(tx Transaction, thresholds StatusThresholds) TransactionResponse {
+	return TransactionResponse{
+		Transaction: tx,
+		Status:      thresholds.Status(tx.Confirmations),
+	}
+}
+
+// Search result kinds, named after what the query was recognized as
+const (
+	SearchKindTransaction = "transaction"
+	SearchKindAddress     = "address"
+	SearchKindLabel       = "label"
+)
+
+// SearchResult holds the stored addresses and transactions matching a search query
+type SearchResult struct {
+	Query        string
+	Kind         string
+	Addresses    []Address
+	Transactions []Transaction
+}
+
+// SearchResponse represents a search result with labelled transactions
+type SearchResponse struct {
+	Query        string                `json:"query"`
+	Kind         string                `json:"kind"`
+	Addresses    []Address             `json:"addresses"`
+	Transactions []TransactionResponse `json:"transactions"`
+}
+
+// NewSearchResponse labels the transactions of a search result using the given thresholds
+func NewSearchResponse(result SearchResult, thresholds StatusThresholds) SearchResponse {
+	addresses := result.Addresses
+	if addresses == nil {
+		addresses = []Address{}
+	}
+	return SearchResponse{
+		Query:        result.Query,
+		Kind:         result.Kind,
+		Addresses:    addresses,
+		Transactions: NewTransactionResponses(result.Transactions, thresholds),
+	}
+}
+
+// NewTransactionResponses labels each transaction using the given thresholds
+func NewTransactionResponses(transactions []Transaction, thresholds StatusThresholds) []TransactionResponse {
+	responses := make([]TransactionResponse, len(transactions))
+	for i, tx := range transactions {
+		responses[i] = NewTransactionResponse(tx, thresholds)
+	}
+	return responses
+}
+
+// BalanceSnapshot records an address's balance at a point in time
+type BalanceSnapshot struct {
+	Address            string    `json:"address"`
+	Timestamp          time.Time `json:"timestamp"`
+	ConfirmedBalance   int64     `json:"confirmed_balance"`
+	UnconfirmedBalance int64     `json:"unconfirmed_balance"`
+	TotalBalance       int64     `json:"total_balance"`
 }
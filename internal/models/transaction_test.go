@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestStatusThresholds(t *testing.T) {
+	testCases := []struct {
+		confirmations int
+		status        string
+	}{
+		{0, StatusPending},
+		{1, StatusConfirming},
+		{5, StatusConfirming},
+		{6, StatusConfirmed},
+		{99, StatusConfirmed},
+		{100, StatusFinal},
+	}
+
+	for _, tc := range testCases {
+		if got := DefaultStatusThresholds.Status(tc.confirmations); got != tc.status {
+			t.Errorf("Status(%d) = %s; want %s", tc.confirmations, got, tc.status)
+		}
+	}
+}
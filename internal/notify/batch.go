@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// BatchNotifier is implemented by channels that can deliver several notifications at once
+type BatchNotifier interface {
+	NotifyBatch(ctx context.Context, ns []Notification) error
+}
+
+// NotifyBatch logs each notification of the batch
+func (LogNotifier) NotifyBatch(ctx context.Context, ns []Notification) error {
+	log.Printf("🔔 %d notifications:", len(ns))
+	for _, n := range ns {
+		log.Printf("🔔 %s: %s", n.Event, n.Message)
+	}
+	return nil
+}
+
+// SendBatch delivers ns in batches of at most maxSize, one delivery per batch when the notifier
+// supports it and one per notification otherwise. A non-positive maxSize sends everything at once.
+// Delivery continues past failures; the first error is returned.
+func SendBatch(ctx context.Context, notifier Notifier, ns []Notification, maxSize int) error {
+	if maxSize <= 0 {
+		maxSize = len(ns)
+	}
+
+	var firstErr error
+	for start := 0; start < len(ns); start += maxSize {
+		batch := ns[start:min(start+maxSize, len(ns))]
+		if err := sendBatch(ctx, notifier, batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sendBatch delivers one batch
+func sendBatch(ctx context.Context, notifier Notifier, batch []Notification) error {
+	if batcher, ok := notifier.(BatchNotifier); ok {
+		if err := batcher.NotifyBatch(ctx, batch); err != nil {
+			return fmt.Errorf("failed to deliver batch of %d notifications: %w", len(batch), err)
+		}
+		return nil
+	}
+
+	var firstErr error
+	for _, n := range batch {
+		if err := notifier.Notify(ctx, n); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,95 @@
+// Package notify delivers operational notifications to operators
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification events
+const (
+	EventSyncDegraded  = "sync_degraded"
+	EventSyncRecovered = "sync_recovered"
+
+	EventTransactionConfirmed = "transaction_confirmed"
+)
+
+// Notification describes an event worth telling an operator about
+type Notification struct {
+	Event   string    `json:"event"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	// Data carries event details, such as the transaction of a transaction_confirmed event
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Notifier is implemented by notification channels
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("🔔 %s: %s", n.Event, n.Message)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify posts the notification and fails unless the webhook answers with a 2xx status
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	return w.post(ctx, n)
+}
+
+// NotifyBatch posts the notifications as one JSON array and fails unless the webhook answers with a 2xx status
+func (w *WebhookNotifier) NotifyBatch(ctx context.Context, ns []Notification) error {
+	return w.post(ctx, ns)
+}
+
+// post sends payload as JSON to the webhook
+func (w *WebhookNotifier) post(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
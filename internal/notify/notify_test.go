@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sent := Notification{Event: EventSyncDegraded, Message: "3 consecutive sync runs failed", Time: time.Now().UTC()}
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), sent); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if received.Event != sent.Event || received.Message != sent.Message || !received.Time.Equal(sent.Time) {
+		t.Errorf("Webhook received %+v, want %+v", received, sent)
+	}
+}
+
+func TestWebhookNotifierRejectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), Notification{Event: EventSyncRecovered}); err == nil {
+		t.Error("Expected an error when the webhook fails")
+	}
+}
+
+// recordingNotifier records notifications delivered one at a time
+type recordingNotifier struct {
+	received []Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n Notification) error {
+	r.received = append(r.received, n)
+	return nil
+}
+
+func TestSendBatch(t *testing.T) {
+	var batches [][]Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []Notification
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Failed to decode batch: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	ns := []Notification{{Event: "a"}, {Event: "b"}, {Event: "c"}}
+	if err := SendBatch(context.Background(), NewWebhookNotifier(server.URL), ns, 2); err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0].Event != "c" {
+		t.Errorf("Expected batches of two and one, got %+v", batches)
+	}
+
+	// Notifiers without batch support get every notification on its own
+	recorder := &recordingNotifier{}
+	if err := SendBatch(context.Background(), recorder, ns, 2); err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+	if len(recorder.received) != 3 {
+		t.Errorf("Expected three single deliveries, got %+v", recorder.received)
+	}
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// GetActivity summarizes the stored transactions of an address, including a monthly timeline.
+// The balance is left for the caller to fill in.
+func (r *SQLiteRepository) GetActivity(address string) (*models.AddressActivity, error) {
+	activity := &models.AddressActivity{
+		Address:  address,
+		Timeline: []models.ActivityPeriod{},
+	}
+
+	totalsQuery := `
+	SELECT COUNT(*),
+		COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0)
+	FROM transactions 
+	WHERE address = ? AND network = ?`
+
+	err := r.reads.QueryRow(totalsQuery, address, r.network).Scan(
+		&activity.TransactionCount, &activity.TotalReceived, &activity.TotalSent,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize activity: %w", err)
+	}
+
+	if activity.FirstActivity, err = r.activityBound(address, "ASC"); err != nil {
+		return nil, err
+	}
+	if activity.LastActivity, err = r.activityBound(address, "DESC"); err != nil {
+		return nil, err
+	}
+
+	timelineQuery := `
+	SELECT strftime('%Y-%m', timestamp) AS period, COUNT(*), SUM(amount) 
+	FROM transactions 
+	WHERE address = ? AND network = ? 
+	GROUP BY period 
+	ORDER BY period`
+
+	rows, err := r.reads.Query(timelineQuery, address, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity timeline: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var period models.ActivityPeriod
+		if err := rows.Scan(&period.Period, &period.TransactionCount, &period.NetAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan activity period: %w", err)
+		}
+		activity.Timeline = append(activity.Timeline, period)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate activity timeline: %w", err)
+	}
+
+	return activity, nil
+}
+
+// activityBound returns the earliest (ASC) or latest (DESC) transaction time of an address
+func (r *SQLiteRepository) activityBound(address, direction string) (*time.Time, error) {
+	query := fmt.Sprintf(`SELECT timestamp FROM transactions WHERE address = ? AND network = ? ORDER BY timestamp %s LIMIT 1`, direction)
+
+	var t time.Time
+	if err := r.reads.QueryRow(query, address, r.network).Scan(&t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get activity bounds: %w", err)
+	}
+
+	return &t, nil
+}
+
+// GetCoSpends finds transactions in which two or more tracked addresses lost coins. A negative
+// net change means the address funded at least one input, so such transactions co-spend them.
+func (r *SQLiteRepository) GetCoSpends() ([]models.CoSpend, error) {
+	query := `
+	SELECT t.hash, t.address 
+	FROM transactions t 
+	JOIN addresses a ON a.address = t.address AND a.network = t.network 
+	WHERE t.network = ? AND t.amount < 0 AND a.archived_at IS NULL AND t.hash IN (
+		SELECT hash FROM transactions WHERE network = ? AND amount < 0 GROUP BY hash HAVING COUNT(*) > 1
+	) 
+	ORDER BY t.hash, t.address`
+
+	rows, err := r.reads.Query(query, r.network, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get co-spends: %w", err)
+	}
+	defer rows.Close()
+
+	var coSpends []models.CoSpend
+	for rows.Next() {
+		var hash, address string
+		if err := rows.Scan(&hash, &address); err != nil {
+			return nil, fmt.Errorf("failed to scan co-spend: %w", err)
+		}
+		if n := len(coSpends); n > 0 && coSpends[n-1].Hash == hash {
+			coSpends[n-1].Addresses = append(coSpends[n-1].Addresses, address)
+		} else {
+			coSpends = append(coSpends, models.CoSpend{Hash: hash, Addresses: []string{address}})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate co-spends: %w", err)
+	}
+
+	return coSpends, nil
+}
+
+// GetSharedTransactions finds transactions stored for both addresses, newest first
+func (r *SQLiteRepository) GetSharedTransactions(a, b string) ([]models.SharedTransaction, error) {
+	query := `
+	SELECT ta.hash, ta.timestamp, ta.amount, tb.amount 
+	FROM transactions ta 
+	JOIN transactions tb ON tb.hash = ta.hash AND tb.network = ta.network 
+	WHERE ta.address = ? AND tb.address = ? AND ta.network = ? 
+	ORDER BY ta.timestamp DESC`
+
+	rows, err := r.reads.Query(query, a, b, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared transactions: %w", err)
+	}
+	defer rows.Close()
+
+	shared := []models.SharedTransaction{}
+	for rows.Next() {
+		var tx models.SharedTransaction
+		if err := rows.Scan(&tx.Hash, &tx.Timestamp, &tx.AmountA, &tx.AmountB); err != nil {
+			return nil, fmt.Errorf("failed to scan shared transaction: %w", err)
+		}
+		shared = append(shared, tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shared transactions: %w", err)
+	}
+
+	return shared, nil
+}
+
+// GetTypeCounts returns the number and summed amount of an address's stored transactions per type
+func (r *SQLiteRepository) GetTypeCounts(address string) ([]models.TypeCount, error) {
+	query := `
+	SELECT type, COUNT(*), SUM(amount) 
+	FROM transactions 
+	WHERE address = ? AND network = ? 
+	GROUP BY type 
+	ORDER BY type`
+
+	rows, err := r.reads.Query(query, address, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction types: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.TypeCount{}
+	for rows.Next() {
+		var count models.TypeCount
+		if err := rows.Scan(&count.Type, &count.Count, &count.TotalAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction type: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transaction types: %w", err)
+	}
+
+	return counts, nil
+}
+
+// volumePeriods maps each volume bucket size to the SQL expression naming a transaction's bucket
+var volumePeriods = map[string]string{
+	models.VolumeBucketDay:   "strftime('%Y-%m-%d', timestamp)",
+	models.VolumeBucketWeek:  "date(timestamp, '-6 days', 'weekday 1')",
+	models.VolumeBucketMonth: "strftime('%Y-%m', timestamp)",
+}
+
+// GetVolume groups an address's stored transactions into day, week or month buckets, optionally
+// restricted to transactions between from and to, oldest bucket first
+func (r *SQLiteRepository) GetVolume(address, bucket string, from, to *time.Time) ([]models.VolumeBucket, error) {
+	period, ok := volumePeriods[bucket]
+	if !ok {
+		return nil, fmt.Errorf("unsupported volume bucket: %s", bucket)
+	}
+
+	conditions := "address = ? AND network = ?"
+	args := []interface{}{address, r.network}
+	if from != nil {
+		conditions += " AND timestamp >= ?"
+		args = append(args, from.UTC())
+	}
+	if to != nil {
+		conditions += " AND timestamp <= ?"
+		args = append(args, to.UTC())
+	}
+
+	query := fmt.Sprintf(`
+	SELECT %s AS period, COUNT(*),
+		COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0)
+	FROM transactions 
+	WHERE %s 
+	GROUP BY period 
+	ORDER BY period`, period, conditions)
+
+	rows, err := r.reads.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction volume: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.VolumeBucket{}
+	for rows.Next() {
+		var b models.VolumeBucket
+		if err := rows.Scan(&b.Period, &b.TransactionCount, &b.Received, &b.Sent); err != nil {
+			return nil, fmt.Errorf("failed to scan volume bucket: %w", err)
+		}
+		b.NetAmount = b.Received + b.Sent
+		b.GrossAmount = b.Received - b.Sent
+		buckets = append(buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transaction volume: %w", err)
+	}
+
+	return buckets, nil
+}
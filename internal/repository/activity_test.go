@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestGetVolume(t *testing.T) {
+	repo := newTestRepository(t)
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	// Wednesday 2024-01-03 and Sunday 2024-01-07 share a week; Monday 2024-01-08 starts the next
+	at := func(day int) time.Time { return time.Date(2024, 1, day, 12, 0, 0, 0, time.UTC) }
+	txs := []models.Transaction{
+		{Hash: "a", Address: addr, Amount: 5000, Confirmations: 6, BlockHeight: 1, Timestamp: at(3), Type: "received"},
+		{Hash: "b", Address: addr, Amount: -2000, Confirmations: 6, BlockHeight: 2, Timestamp: at(3), Type: "sent"},
+		{Hash: "c", Address: addr, Amount: 1000, Confirmations: 6, BlockHeight: 3, Timestamp: at(7), Type: "received"},
+		{Hash: "d", Address: addr, Amount: -500, Confirmations: 6, BlockHeight: 4, Timestamp: at(8), Type: "sent"},
+	}
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	testCases := []struct {
+		bucket string
+		from   *time.Time
+		want   []models.VolumeBucket
+	}{
+		{models.VolumeBucketDay, nil, []models.VolumeBucket{
+			{Period: "2024-01-03", TransactionCount: 2, Received: 5000, Sent: -2000, NetAmount: 3000, GrossAmount: 7000},
+			{Period: "2024-01-07", TransactionCount: 1, Received: 1000, NetAmount: 1000, GrossAmount: 1000},
+			{Period: "2024-01-08", TransactionCount: 1, Sent: -500, NetAmount: -500, GrossAmount: 500},
+		}},
+		{models.VolumeBucketWeek, nil, []models.VolumeBucket{
+			{Period: "2024-01-01", TransactionCount: 3, Received: 6000, Sent: -2000, NetAmount: 4000, GrossAmount: 8000},
+			{Period: "2024-01-08", TransactionCount: 1, Sent: -500, NetAmount: -500, GrossAmount: 500},
+		}},
+		{models.VolumeBucketMonth, ptr(at(5)), []models.VolumeBucket{
+			{Period: "2024-01", TransactionCount: 2, Received: 1000, Sent: -500, NetAmount: 500, GrossAmount: 1500},
+		}},
+	}
+
+	for _, tc := range testCases {
+		got, err := repo.GetVolume(addr, tc.bucket, tc.from, nil)
+		if err != nil {
+			t.Fatalf("GetVolume(%s) failed: %v", tc.bucket, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("GetVolume(%s) = %+v; want %+v", tc.bucket, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("GetVolume(%s)[%d] = %+v; want %+v", tc.bucket, i, got[i], tc.want[i])
+			}
+		}
+	}
+
+	if _, err := repo.GetVolume(addr, "year", nil, nil); err == nil {
+		t.Error("GetVolume accepted an unsupported bucket")
+	}
+}
+
+func TestGetTypeCounts(t *testing.T) {
+	repo := newTestRepository(t)
+	trackAddresses(t, repo, "addr", "other", "empty")
+
+	at := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	txs := []models.Transaction{
+		{Hash: "a", Address: "addr", Amount: 5000, Confirmations: 6, BlockHeight: 1, Timestamp: at, Type: "received"},
+		{Hash: "b", Address: "addr", Amount: 1000, Confirmations: 6, BlockHeight: 2, Timestamp: at, Type: "received"},
+		{Hash: "c", Address: "addr", Amount: -2000, Confirmations: 6, BlockHeight: 3, Timestamp: at, Type: "sent"},
+		{Hash: "d", Address: "addr", Amount: 0, Confirmations: 6, BlockHeight: 4, Timestamp: at, Type: "self"},
+		{Hash: "e", Address: "other", Amount: 7000, Confirmations: 6, BlockHeight: 5, Timestamp: at, Type: "received"},
+	}
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	got, err := repo.GetTypeCounts("addr")
+	if err != nil {
+		t.Fatalf("GetTypeCounts failed: %v", err)
+	}
+	want := []models.TypeCount{
+		{Type: "received", Count: 2, TotalAmount: 6000},
+		{Type: "self", Count: 1, TotalAmount: 0},
+		{Type: "sent", Count: 1, TotalAmount: -2000},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetTypeCounts = %+v; want %+v", got, want)
+	}
+
+	// An address without transactions has an empty, non-nil list so it encodes as []
+	got, err = repo.GetTypeCounts("empty")
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("GetTypeCounts of an empty address = %#v (err %v); want an empty list", got, err)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// transactionArchiveTable holds deeply confirmed transactions moved out of the transactions table,
+// keeping their original IDs so merged listings sort the same way as before archiving
+const transactionArchiveTable = `
+	CREATE TABLE IF NOT EXISTS transaction_archive (
+		id INTEGER PRIMARY KEY,
+		hash TEXT NOT NULL,
+		address TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		confirmations INTEGER NOT NULL,
+		block_height INTEGER NOT NULL,
+		timestamp DATETIME NOT NULL,
+		type TEXT NOT NULL,
+		coinbase INTEGER NOT NULL DEFAULT 0,
+		network TEXT NOT NULL,
+		archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(hash, address, network)
+	);`
+
+// transactionArchiveIndexes serve per-address listings of the archive
+var transactionArchiveIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_transaction_archive_address ON transaction_archive(address, network);",
+}
+
+// archiveBalanceColumns hold the sum and number of an address's archived transactions, so balances
+// stay correct without reading the archive
+var archiveBalanceColumns = []struct {
+	name       string
+	definition string
+}{
+	{"archive_balance", "INTEGER NOT NULL DEFAULT 0"},
+	{"archive_count", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// allTransactions reads the transactions table together with the archive. It is aliased as
+// transactions so the usual conditions and ordering apply unchanged.
+const allTransactions = `(
+	SELECT ` + transactionColumns + `, network FROM transactions
+	UNION ALL
+	SELECT ` + transactionColumns + `, network FROM transaction_archive
+) AS transactions`
+
+// archiveDepth is the number of confirmations a transaction needs before it is archived. It covers
+// coinbase maturity, so archived amounts only ever count towards the confirmed balance.
+const archiveDepth = models.CoinbaseMaturity
+
+// ArchiveOldTransactions moves transactions older than olderThan with at least archiveDepth
+// confirmations, or the final depth when that is deeper, to the archive in one database transaction.
+// It returns how many were moved.
+func (r *SQLiteRepository) ArchiveOldTransactions(olderThan time.Time) (int64, error) {
+	depth := max(archiveDepth, r.finalDepth)
+	eligible := `network = ? AND timestamp < ? AND block_height > 0 AND confirmations >= ?`
+	args := []interface{}{r.network, olderThan.UTC(), depth}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	copyRows := `
+	INSERT INTO transaction_archive (` + transactionColumns + `, network)
+	SELECT ` + transactionColumns + `, network FROM transactions WHERE ` + eligible
+	if _, err := tx.Exec(copyRows, args...); err != nil {
+		return 0, fmt.Errorf("failed to copy transactions to the archive: %w", err)
+	}
+
+	addTotals := `
+	UPDATE addresses
+	SET archive_balance = archive_balance + moved.amount, archive_count = archive_count + moved.count
+	FROM (
+		SELECT address, SUM(amount) AS amount, COUNT(*) AS count
+		FROM transactions WHERE ` + eligible + `
+		GROUP BY address
+	) AS moved
+	WHERE addresses.address = moved.address AND addresses.network = ?`
+	if _, err := tx.Exec(addTotals, append(args, r.network)...); err != nil {
+		return 0, fmt.Errorf("failed to update archived totals: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM transactions WHERE `+eligible, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove archived transactions: %w", err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit archive: %w", err)
+	}
+
+	return moved, nil
+}
+
+// archiveBalance returns the sum of an address's archived transactions, or zero for unknown addresses
+func (r *SQLiteRepository) archiveBalance(tx *sql.Tx, address string) (int64, error) {
+	var balance int64
+	err := tx.QueryRow(`SELECT archive_balance FROM addresses WHERE address = ? AND network = ?`,
+		address, r.network).Scan(&balance)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get archived balance: %w", err)
+	}
+	return balance, nil
+}
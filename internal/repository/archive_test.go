@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestArchiveOldTransactions(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	// The first three are deep enough to archive; the fourth is old but too shallow, the fifth too recent
+	txs := makeTransactions(address, 5)
+	for i := range txs[:4] {
+		txs[i].Confirmations = 200
+	}
+	txs[3].Confirmations = 6
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	before, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+
+	moved, err := repo.ArchiveOldTransactions(txs[4].Timestamp)
+	if err != nil {
+		t.Fatalf("ArchiveOldTransactions failed: %v", err)
+	}
+	if moved != 3 {
+		t.Fatalf("Expected 3 archived transactions, got %d", moved)
+	}
+	if moved, err := repo.ArchiveOldTransactions(txs[4].Timestamp); err != nil || moved != 0 {
+		t.Errorf("Expected a second run to archive nothing, got %d (err %v)", moved, err)
+	}
+
+	after, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+	if *after != *before {
+		t.Errorf("Balance changed by archiving: before %+v, after %+v", before, after)
+	}
+	balances, err := repo.GetBalances()
+	if err != nil {
+		t.Fatalf("GetBalances failed: %v", err)
+	}
+	if balances[address] == nil || *balances[address] != *before {
+		t.Errorf("GetBalances = %+v, want %+v", balances[address], before)
+	}
+
+	hot, err := repo.QueryTransactions(models.TransactionFilter{Address: address, SortDesc: true})
+	if err != nil {
+		t.Fatalf("QueryTransactions failed: %v", err)
+	}
+	if len(hot) != 2 || hot[0].Hash != txs[4].Hash || hot[1].Hash != txs[3].Hash {
+		t.Errorf("Expected only the two unarchived transactions by default, got %+v", hot)
+	}
+
+	full := models.TransactionFilter{Address: address, SortDesc: true, IncludeArchive: true}
+	all, err := repo.QueryTransactions(full)
+	if err != nil {
+		t.Fatalf("QueryTransactions with the archive failed: %v", err)
+	}
+	if len(all) != 5 || all[0].Hash != txs[4].Hash || all[4].Hash != txs[0].Hash || all[4].ID != hot[1].ID-3 {
+		t.Errorf("Expected all five transactions newest first with their original IDs, got %+v", all)
+	}
+	if count, err := repo.CountTransactions(full); err != nil || count != 5 {
+		t.Errorf("CountTransactions with the archive = %d (err %v); want 5", count, err)
+	}
+
+	// Point lookups and sync bookkeeping still see archived transactions
+	hashes, err := repo.GetExistingHashes(address)
+	if err != nil || len(hashes) != 5 {
+		t.Errorf("Expected 5 existing hashes, got %d (err %v)", len(hashes), err)
+	}
+	if exists, err := repo.TransactionExists(txs[0].Hash, address); err != nil || !exists {
+		t.Errorf("Expected archived transaction to exist (err %v)", err)
+	}
+	if tx, err := repo.GetTransaction(txs[0].Hash, address); err != nil || tx == nil || tx.Amount != txs[0].Amount {
+		t.Errorf("GetTransaction of an archived transaction = %+v (err %v)", tx, err)
+	}
+
+	// Deleting the address drops its archive and archived totals
+	if err := repo.RemoveAddress(address); err != nil {
+		t.Fatalf("RemoveAddress failed: %v", err)
+	}
+	var archived int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM transaction_archive`).Scan(&archived); err != nil || archived != 0 {
+		t.Errorf("Expected an empty archive after removal, got %d rows (err %v)", archived, err)
+	}
+}
+
+func TestArchiveKeepsFinalBalance(t *testing.T) {
+	repo := newTestRepository(t)
+	WithFinalDepth(6)(repo)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	txs := makeTransactions(address, 4)
+	for i := range txs {
+		txs[i].Confirmations = 200
+	}
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// Settle the final sum before half of the final transactions leave the table
+	before, err := repo.RefreshBalance(address)
+	if err != nil {
+		t.Fatalf("RefreshBalance failed: %v", err)
+	}
+	if _, err := repo.ArchiveOldTransactions(txs[2].Timestamp.Add(-time.Second)); err != nil {
+		t.Fatalf("ArchiveOldTransactions failed: %v", err)
+	}
+
+	after, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+	if after.ConfirmedBalance != before.ConfirmedBalance || after.TotalBalance != before.TotalBalance {
+		t.Errorf("Balance changed by archiving final transactions: before %+v, after %+v", before, after)
+	}
+}
+
+func TestPruneTransactionsKeepsBalance(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	// Oldest first: a mined receipt, an immature coinbase reward, an unconfirmed spend and two recent receipts
+	txs := makeTransactions(address, 5)
+	txs[1].Coinbase, txs[1].Confirmations = true, 10
+	txs[2].Amount, txs[2].Type, txs[2].Confirmations, txs[2].BlockHeight = -500, "sent", 0, 0
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	before, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+
+	pruned, err := repo.PruneTransactions(address, 2)
+	if err != nil {
+		t.Fatalf("PruneTransactions failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Fatalf("Expected only the settled receipt to be pruned, got %d", pruned)
+	}
+	if pruned, err := repo.PruneTransactions(address, 2); err != nil || pruned != 0 {
+		t.Errorf("Expected a second prune to move nothing, got %d (err %v)", pruned, err)
+	}
+
+	after, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+	if *after != *before {
+		t.Errorf("Balance changed by pruning: before %+v, after %+v", before, after)
+	}
+	assertFullRecompute(t, repo, address)
+
+	// The pruned hash is still known, so sync does not fetch it as new again
+	hashes, err := repo.GetExistingHashes(address)
+	if err != nil || !hashes[txs[0].Hash] {
+		t.Errorf("Expected the pruned hash among existing hashes, got %v (err %v)", hashes, err)
+	}
+	if count, err := repo.CountTransactions(models.TransactionFilter{Address: address}); err != nil || count != 4 {
+		t.Errorf("CountTransactions = %d (err %v); want 4 left in the working set", count, err)
+	}
+}
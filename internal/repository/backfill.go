@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// ListAddressesAfter returns up to limit addresses with an ID above afterID in ID order, archived ones
+// included, so maintenance jobs can walk every stored address in resumable batches
+func (r *SQLiteRepository) ListAddressesAfter(afterID, limit int) ([]models.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE network = ? AND id > ? ORDER BY id LIMIT ?`
+
+	rows, err := r.db.Query(query, r.network, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		addr, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, *addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// SetAddressType stores the type of an address, archived or not. It reports whether the stored
+// type changed, so writing the same type twice is a no-op.
+func (r *SQLiteRepository) SetAddressType(address, addrType string) (bool, error) {
+	query := `UPDATE addresses SET address_type = ? WHERE address = ? AND network = ? AND address_type IS NOT ?`
+	result, err := r.db.Exec(query, addrType, address, r.network, addrType)
+	if err != nil {
+		return false, fmt.Errorf("failed to set address type: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestSetAddressTypeWalksAllAddresses(t *testing.T) {
+	repo := newTestRepository(t)
+	addresses := []string{
+		"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5",
+		"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd",
+		"1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV",
+	}
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+	if _, err := repo.RemoveAddresses(addresses[2:], true); err != nil {
+		t.Fatalf("RemoveAddresses failed: %v", err)
+	}
+
+	// Batches continue after the last ID seen and include archived addresses
+	var walked []models.Address
+	for afterID := 0; ; {
+		batch, err := repo.ListAddressesAfter(afterID, 2)
+		if err != nil {
+			t.Fatalf("ListAddressesAfter failed: %v", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		walked = append(walked, batch...)
+		afterID = batch[len(batch)-1].ID
+	}
+	if len(walked) != 3 || walked[0].Address != addresses[0] || walked[2].Address != addresses[2] {
+		t.Fatalf("Expected all three addresses in ID order, got %+v", walked)
+	}
+	if walked[0].Type != "" {
+		t.Fatalf("Expected no stored type, got %q", walked[0].Type)
+	}
+
+	if updated, err := repo.SetAddressType(addresses[0], "p2wpkh"); err != nil || !updated {
+		t.Fatalf("Expected the first write to update (err %v)", err)
+	}
+	if updated, err := repo.SetAddressType(addresses[0], "p2wpkh"); err != nil || updated {
+		t.Errorf("Expected writing the same type again to change nothing (err %v)", err)
+	}
+	if updated, err := repo.SetAddressType(addresses[2], "p2pkh"); err != nil || !updated {
+		t.Errorf("Expected an archived address to be updated (err %v)", err)
+	}
+
+	addr, err := repo.GetAddress(addresses[0])
+	if err != nil {
+		t.Fatalf("GetAddress failed: %v", err)
+	}
+	if addr.Type != "p2wpkh" {
+		t.Errorf("Expected stored type p2wpkh, got %q", addr.Type)
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// balanceCacheColumns hold the balance last computed for an address when balance caching is enabled
+var balanceCacheColumns = []struct {
+	name       string
+	definition string
+}{
+	{"cached_confirmed_balance", "INTEGER"},
+	{"cached_unconfirmed_balance", "INTEGER"},
+	{"cached_total_balance", "INTEGER"},
+	{"cached_balance_btc", "REAL"},
+	{"cached_immature_balance", "INTEGER"},
+	{"balance_updated_at", "DATETIME"},
+}
+
+// WithBalanceCache serves GetBalance and GetBalances from balances stored by RefreshBalance
+// instead of aggregating transactions on every read
+func WithBalanceCache(enabled bool) Option {
+	return func(r *SQLiteRepository) {
+		r.balanceCache = enabled
+	}
+}
+
+// RefreshBalance recomputes the balance of an address from its transactions and, when balance
+// caching is enabled, stores it for later reads. With a final depth configured it first settles
+// newly final transactions. It must run after every change to the address's transactions.
+func (r *SQLiteRepository) RefreshBalance(address string) (*models.Balance, error) {
+	if r.finalDepth > 0 {
+		if err := r.settleFinalBalance(address); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read back from the primary, which has the changes just written
+	balance, err := r.calculateBalance(r.db, address)
+	if err != nil {
+		return nil, err
+	}
+	if !r.balanceCache {
+		return balance, nil
+	}
+
+	query := `
+	UPDATE addresses SET 
+		cached_confirmed_balance = ?, cached_unconfirmed_balance = ?, cached_total_balance = ?,
+		cached_balance_btc = ?, cached_immature_balance = ?, balance_updated_at = ?
+	WHERE address = ? AND network = ?`
+
+	_, err = r.db.Exec(query,
+		balance.ConfirmedBalance, balance.UnconfirmedBalance, balance.TotalBalance,
+		balance.BalanceBTC, balance.ImmatureBalance, time.Now().UTC(),
+		address, r.network,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store cached balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// cachedBalance reads the stored balance of an address, returning nil if none was stored yet
+func (r *SQLiteRepository) cachedBalance(address string) (*models.Balance, error) {
+	query := `
+	SELECT cached_confirmed_balance, cached_unconfirmed_balance, cached_total_balance,
+		cached_balance_btc, cached_immature_balance
+	FROM addresses 
+	WHERE address = ? AND network = ? AND balance_updated_at IS NOT NULL`
+
+	balance := &models.Balance{Address: address}
+	err := r.reads.QueryRow(query, address, r.network).Scan(
+		&balance.ConfirmedBalance, &balance.UnconfirmedBalance, &balance.TotalBalance,
+		&balance.BalanceBTC, &balance.ImmatureBalance,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// cachedBalances reads every stored balance, keyed by address
+func (r *SQLiteRepository) cachedBalances() (map[string]*models.Balance, error) {
+	query := `
+	SELECT address, cached_confirmed_balance, cached_unconfirmed_balance, cached_total_balance,
+		cached_balance_btc, cached_immature_balance
+	FROM addresses 
+	WHERE network = ? AND balance_updated_at IS NOT NULL`
+
+	rows, err := r.reads.Query(query, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]*models.Balance)
+	for rows.Next() {
+		var balance models.Balance
+		err := rows.Scan(
+			&balance.Address, &balance.ConfirmedBalance, &balance.UnconfirmedBalance, &balance.TotalBalance,
+			&balance.BalanceBTC, &balance.ImmatureBalance,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cached balance: %w", err)
+		}
+		balances[balance.Address] = &balance
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cached balances: %w", err)
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestBalanceCache(t *testing.T) {
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"), WithBalanceCache(true))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	txs := makeTransactions(addr, 3)
+	if err := repo.SaveTransactions(txs[:2]); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// Before the first refresh the balance is calculated live
+	assertBalances(t, repo, addr, 2001)
+
+	if _, err := repo.RefreshBalance(addr); err != nil {
+		t.Fatalf("RefreshBalance failed: %v", err)
+	}
+	if err := repo.SaveTransactions(txs[2:]); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// Reads are served from the cache until the next refresh
+	assertBalances(t, repo, addr, 2001)
+
+	if _, err := repo.RefreshBalance(addr); err != nil {
+		t.Fatalf("RefreshBalance failed: %v", err)
+	}
+	assertBalances(t, repo, addr, 3003)
+
+	calculated, err := repo.CalculateBalance(addr)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+	if calculated.TotalBalance != 3003 {
+		t.Errorf("CalculateBalance total %d; want 3003", calculated.TotalBalance)
+	}
+}
+
+// assertBalances checks the total balance reported by both GetBalance and GetBalances
+func assertBalances(t *testing.T, repo *SQLiteRepository, address string, want int64) {
+	t.Helper()
+
+	balance, err := repo.GetBalance(address)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.TotalBalance != want || balance.BalanceBTC != models.SatoshisToBTC(want) {
+		t.Errorf("GetBalance = %+v; want total %d", balance, want)
+	}
+
+	balances, err := repo.GetBalances()
+	if err != nil {
+		t.Fatalf("GetBalances failed: %v", err)
+	}
+	if got := balances[address]; got == nil || got.TotalBalance != want {
+		t.Errorf("GetBalances[%s] = %+v; want total %d", address, got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// ownershipChallengesTable holds the ownership challenges issued and not yet used. A row is deleted
+// when its challenge proves ownership, so each challenge verifies at most once.
+const ownershipChallengesTable = `
+	CREATE TABLE IF NOT EXISTS ownership_challenges (
+		message TEXT NOT NULL,
+		address TEXT NOT NULL,
+		network TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY(message, address, network)
+	);`
+
+// SaveOwnershipChallenge stores a newly issued challenge and drops the challenges that expired before now
+func (r *SQLiteRepository) SaveOwnershipChallenge(challenge models.OwnershipChallenge, now time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM ownership_challenges WHERE expires_at <= ?`, now.UTC()); err != nil {
+		return fmt.Errorf("failed to prune ownership challenges: %w", err)
+	}
+
+	query := `INSERT INTO ownership_challenges (message, address, network, expires_at) VALUES (?, ?, ?, ?)`
+	if _, err := r.db.Exec(query, challenge.Message, challenge.Address, r.network, challenge.ExpiresAt.UTC()); err != nil {
+		return fmt.Errorf("failed to save ownership challenge: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeOwnershipChallenge deletes the challenge message issued for address if it is still unexpired at now,
+// and reports whether it did. Of concurrent calls for the same challenge, at most one reports true.
+func (r *SQLiteRepository) ConsumeOwnershipChallenge(address, message string, now time.Time) (bool, error) {
+	query := `DELETE FROM ownership_challenges WHERE message = ? AND address = ? AND network = ? AND expires_at > ?`
+	result, err := r.db.Exec(query, message, address, r.network, now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to consume ownership challenge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestConsumeOwnershipChallenge(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	challenge := models.OwnershipChallenge{Address: address, Message: "nonce 1", ExpiresAt: now.Add(time.Minute)}
+	if err := repo.SaveOwnershipChallenge(challenge, now); err != nil {
+		t.Fatalf("SaveOwnershipChallenge failed: %v", err)
+	}
+
+	if ok, err := repo.ConsumeOwnershipChallenge("1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm", challenge.Message, now); err != nil || ok {
+		t.Errorf("Expected a challenge issued for another address to be refused, got %t (err %v)", ok, err)
+	}
+	if ok, err := repo.ConsumeOwnershipChallenge(address, "nonce 2", now); err != nil || ok {
+		t.Errorf("Expected an unknown challenge to be refused, got %t (err %v)", ok, err)
+	}
+	if ok, err := repo.ConsumeOwnershipChallenge(address, challenge.Message, now.Add(time.Minute)); err != nil || ok {
+		t.Errorf("Expected an expired challenge to be refused, got %t (err %v)", ok, err)
+	}
+
+	if ok, err := repo.ConsumeOwnershipChallenge(address, challenge.Message, now); err != nil || !ok {
+		t.Fatalf("Expected the challenge to be accepted, got %t (err %v)", ok, err)
+	}
+	if ok, err := repo.ConsumeOwnershipChallenge(address, challenge.Message, now); err != nil || ok {
+		t.Errorf("Expected a used challenge to be refused, got %t (err %v)", ok, err)
+	}
+}
+
+func TestSaveOwnershipChallengePrunesExpired(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expired := models.OwnershipChallenge{Address: address, Message: "old", ExpiresAt: now.Add(-time.Minute)}
+	fresh := models.OwnershipChallenge{Address: address, Message: "new", ExpiresAt: now.Add(time.Minute)}
+	if err := repo.SaveOwnershipChallenge(expired, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("SaveOwnershipChallenge failed: %v", err)
+	}
+	if err := repo.SaveOwnershipChallenge(fresh, now); err != nil {
+		t.Fatalf("SaveOwnershipChallenge failed: %v", err)
+	}
+
+	var count int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM ownership_challenges`).Scan(&count); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the unexpired challenge to remain, got %d rows", count)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// syncCheckpointTable holds at most one row per network: the full sync run in progress
+const syncCheckpointTable = `
+	CREATE TABLE IF NOT EXISTS sync_checkpoint (
+		network TEXT PRIMARY KEY,
+		run_started_at DATETIME NOT NULL,
+		cursor TEXT,
+		updated_at DATETIME NOT NULL
+	);`
+
+// GetSyncCheckpoint returns the checkpoint of an unfinished full sync run, or nil if there is none
+func (r *SQLiteRepository) GetSyncCheckpoint() (*models.SyncCheckpoint, error) {
+	var checkpoint models.SyncCheckpoint
+	var cursor sql.NullString
+	err := r.db.QueryRow(`SELECT run_started_at, cursor, updated_at FROM sync_checkpoint WHERE network = ?`, r.network).Scan(
+		&checkpoint.RunStartedAt, &cursor, &checkpoint.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sync checkpoint: %w", err)
+	}
+
+	checkpoint.Cursor = cursor.String
+	return &checkpoint, nil
+}
+
+// SaveSyncCheckpoint records the progress of the current full sync run
+func (r *SQLiteRepository) SaveSyncCheckpoint(checkpoint models.SyncCheckpoint) error {
+	query := `
+	INSERT OR REPLACE INTO sync_checkpoint (network, run_started_at, cursor, updated_at) 
+	VALUES (?, ?, ?, ?)`
+
+	_, err := r.db.Exec(query, r.network, checkpoint.RunStartedAt.UTC(), checkpoint.Cursor, checkpoint.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save sync checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ClearSyncCheckpoint removes the checkpoint once a full sync run has attempted every address
+func (r *SQLiteRepository) ClearSyncCheckpoint() error {
+	if _, err := r.db.Exec(`DELETE FROM sync_checkpoint WHERE network = ?`, r.network); err != nil {
+		return fmt.Errorf("failed to clear sync checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// GetAddressesSyncedBefore returns the tracked addresses not synced since t, least recently synced first.
+// Addresses with syncing paused are left out.
+func (r *SQLiteRepository) GetAddressesSyncedBefore(t time.Time) ([]models.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses 
+	WHERE network = ? AND archived_at IS NULL AND sync_enabled = 1 AND (last_synced IS NULL OR last_synced < ?) 
+	ORDER BY last_synced IS NOT NULL, last_synced, id`
+
+	rows, err := r.db.Query(query, r.network, t.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses to sync: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		addr, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, *addr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate addresses: %w", err)
+	}
+
+	return addresses, nil
+}
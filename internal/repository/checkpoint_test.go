@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestSyncCheckpointSurvivesRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	repo, err := NewSQLiteRepository(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+
+	addresses := []string{
+		"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5",
+		"3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd",
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+	}
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+
+	// A run starts, syncs the first address and the process dies before finishing
+	runStarted := time.Now().Add(-time.Minute)
+	if err := repo.UpdateLastSynced(addresses[2], runStarted.Add(-time.Hour)); err != nil {
+		t.Fatalf("UpdateLastSynced failed: %v", err)
+	}
+	if err := repo.UpdateLastSynced(addresses[0], runStarted.Add(time.Second)); err != nil {
+		t.Fatalf("UpdateLastSynced failed: %v", err)
+	}
+	checkpoint := models.SyncCheckpoint{RunStartedAt: runStarted, Cursor: addresses[0], UpdatedAt: runStarted.Add(time.Second)}
+	if err := repo.SaveSyncCheckpoint(checkpoint); err != nil {
+		t.Fatalf("SaveSyncCheckpoint failed: %v", err)
+	}
+	repo.Close()
+
+	repo, err = NewSQLiteRepository(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	restored, err := repo.GetSyncCheckpoint()
+	if err != nil {
+		t.Fatalf("GetSyncCheckpoint failed: %v", err)
+	}
+	if restored == nil || !restored.RunStartedAt.Equal(runStarted) || restored.Cursor != addresses[0] {
+		t.Fatalf("checkpoint = %+v, want run started at %v with cursor %s", restored, runStarted, addresses[0])
+	}
+
+	// Only the addresses the interrupted run did not reach remain, never synced first
+	pending, err := repo.GetAddressesSyncedBefore(restored.RunStartedAt)
+	if err != nil {
+		t.Fatalf("GetAddressesSyncedBefore failed: %v", err)
+	}
+	var got []string
+	for _, addr := range pending {
+		got = append(got, addr.Address)
+	}
+	if len(got) != 2 || got[0] != addresses[1] || got[1] != addresses[2] {
+		t.Errorf("pending = %v, want [%s %s]", got, addresses[1], addresses[2])
+	}
+
+	if err := repo.ClearSyncCheckpoint(); err != nil {
+		t.Fatalf("ClearSyncCheckpoint failed: %v", err)
+	}
+	if restored, err := repo.GetSyncCheckpoint(); err != nil || restored != nil {
+		t.Errorf("GetSyncCheckpoint after clear = %+v, %v, want nil", restored, err)
+	}
+}
+
+func TestPausedAddressesLeftOutOfSyncRuns(t *testing.T) {
+	repo := newTestRepository(t)
+	addresses := []string{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"}
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+
+	paused, err := repo.SetSyncEnabled(addresses[0], false)
+	if err != nil {
+		t.Fatalf("SetSyncEnabled failed: %v", err)
+	}
+	if paused.SyncEnabled {
+		t.Error("Expected the address to be paused")
+	}
+
+	pending, err := repo.GetAddressesSyncedBefore(time.Now())
+	if err != nil {
+		t.Fatalf("GetAddressesSyncedBefore failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Address != addresses[1] {
+		t.Errorf("pending = %+v, want only %s", pending, addresses[1])
+	}
+
+	if _, err := repo.SetSyncEnabled("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for an untracked address, got %v", err)
+	}
+}
+
+func TestResetLastSyncedStoresUTC(t *testing.T) {
+	repo := newTestRepository(t)
+	addresses := []string{"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"}
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+
+	if err := repo.UpdateLastSynced(addresses[0], time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("UpdateLastSynced failed: %v", err)
+	}
+	// 01:00 at UTC+2 is 23:00 UTC the day before, so this backdate is the older of the two
+	backdate := time.Date(2024, 1, 1, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	if err := repo.ResetLastSynced(addresses[1], &backdate); err != nil {
+		t.Fatalf("ResetLastSynced failed: %v", err)
+	}
+
+	pending, err := repo.GetAddressesSyncedBefore(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetAddressesSyncedBefore failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Address != addresses[1] {
+		t.Errorf("pending = %+v, want only %s", pending, addresses[1])
+	}
+
+	pending, err = repo.GetAddressesSyncedBefore(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetAddressesSyncedBefore failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Address != addresses[1] || pending[1].Address != addresses[0] {
+		t.Errorf("pending = %+v, want %s synced before %s", pending, addresses[1], addresses[0])
+	}
+
+	if err := repo.ResetLastSynced("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", nil); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for an untracked address, got %v", err)
+	}
+}
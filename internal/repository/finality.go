@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// finalBalanceColumns hold the settled sum of an address's final transactions and how many were summed
+var finalBalanceColumns = []struct {
+	name       string
+	definition string
+}{
+	{"final_balance", "INTEGER NOT NULL DEFAULT 0"},
+	{"final_count", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// WithFinalDepth marks transactions with at least depth confirmations as final when RefreshBalance
+// runs after a sync. Final amounts are summed once and stored on the address, so balance reads only
+// aggregate the remaining transactions. Zero aggregates everything on every read.
+func WithFinalDepth(depth int) Option {
+	return func(r *SQLiteRepository) {
+		r.finalDepth = depth
+	}
+}
+
+// settleFinalBalance marks the address's newly final transactions and stores the sum of all its
+// final transactions on the address. It writes, so it runs from RefreshBalance rather than on reads.
+func (r *SQLiteRepository) settleFinalBalance(address string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Immature coinbase rewards are reported separately, so they only become final once spendable
+	promote := `
+	UPDATE transactions SET final = 1 
+	WHERE address = ? AND network = ? AND final = 0 AND confirmations >= ? 
+		AND (coinbase = 0 OR confirmations >= ?) 
+	RETURNING amount`
+
+	rows, err := tx.Query(promote, address, r.network, r.finalDepth, models.CoinbaseMaturity)
+	if err != nil {
+		return fmt.Errorf("failed to mark final transactions: %w", err)
+	}
+	var promotedSum, promoted int64
+	for rows.Next() {
+		var amount int64
+		if err := rows.Scan(&amount); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan final transaction: %w", err)
+		}
+		promotedSum += amount
+		promoted++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate final transactions: %w", err)
+	}
+
+	var stored, storedCount int64
+	err = tx.QueryRow(`SELECT final_balance, final_count FROM addresses WHERE address = ? AND network = ?`,
+		address, r.network).Scan(&stored, &storedCount)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get final balance: %w", err)
+	}
+
+	var count int64
+	err = tx.QueryRow(`SELECT COUNT(*) FROM transactions WHERE address = ? AND network = ? AND final = 1`,
+		address, r.network).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count final transactions: %w", err)
+	}
+
+	// Rows replaced or removed since the last settlement leave the counts apart; sum afresh then
+	balance := stored + promotedSum
+	if count != storedCount+promoted {
+		err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE address = ? AND network = ? AND final = 1`,
+			address, r.network).Scan(&balance)
+		if err != nil {
+			return fmt.Errorf("failed to sum final transactions: %w", err)
+		}
+	}
+
+	if balance != stored || count != storedCount {
+		_, err = tx.Exec(`UPDATE addresses SET final_balance = ?, final_count = ? WHERE address = ? AND network = ?`,
+			balance, count, address, r.network)
+		if err != nil {
+			return fmt.Errorf("failed to store final balance: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit final balance: %w", err)
+	}
+	return nil
+}
+
+// finalBalance returns the sum of the address's final transactions as stored by the last settlement.
+// Replacing or deleting a final row changes the number of final rows, so a count that no longer
+// matches the stored one means the final rows are summed instead of trusting the stored value.
+func (r *SQLiteRepository) finalBalance(tx *sql.Tx, address string) (int64, error) {
+	var stored, storedCount int64
+	err := tx.QueryRow(`SELECT final_balance, final_count FROM addresses WHERE address = ? AND network = ?`,
+		address, r.network).Scan(&stored, &storedCount)
+	if err == sql.ErrNoRows {
+		storedCount = -1
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get final balance: %w", err)
+	}
+
+	var count int64
+	err = tx.QueryRow(`SELECT COUNT(*) FROM transactions WHERE address = ? AND network = ? AND final = 1`,
+		address, r.network).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count final transactions: %w", err)
+	}
+	if count == storedCount {
+		return stored, nil
+	}
+
+	var balance int64
+	err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE address = ? AND network = ? AND final = 1`,
+		address, r.network).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum final transactions: %w", err)
+	}
+	return balance, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestFinalBalanceMatchesFullRecompute(t *testing.T) {
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"), WithFinalDepth(6))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+
+	// Final, shallow, unconfirmed, spent and immature coinbase transactions
+	txs := makeTransactions(addr, 6)
+	txs[1].Confirmations = 3
+	txs[2].Confirmations, txs[2].BlockHeight = 0, 0
+	txs[3].Amount, txs[3].Type = -500, "sent"
+	txs[4].Coinbase, txs[4].Confirmations = true, 50
+	txs[5].Confirmations = 1000
+	// Saves are followed by a balance refresh, as syncs do
+	save := func(txs ...models.Transaction) {
+		t.Helper()
+		if err := repo.SaveTransactions(txs); err != nil {
+			t.Fatalf("SaveTransactions failed: %v", err)
+		}
+		if _, err := repo.RefreshBalance(addr); err != nil {
+			t.Fatalf("RefreshBalance failed: %v", err)
+		}
+	}
+	save(txs...)
+
+	assertFullRecompute(t, repo, addr)
+	assertFinalCount(t, repo, addr, 3)
+
+	// Served from the stored sum on the next read
+	assertFullRecompute(t, repo, addr)
+
+	// Shallow transactions become final once deep enough
+	txs[1].Confirmations = 6
+	save(txs[1])
+	assertFullRecompute(t, repo, addr)
+	assertFinalCount(t, repo, addr, 4)
+
+	// A final transaction replaced by a reorg drops out of the stored sum
+	txs[0].Amount, txs[0].Confirmations, txs[0].BlockHeight = 700, 0, 0
+	save(txs[0])
+	assertFullRecompute(t, repo, addr)
+	assertFinalCount(t, repo, addr, 3)
+
+	// Pruning removes final transactions too, and reads stay correct before the next refresh
+	if _, err := repo.PruneTransactions(addr, 2); err != nil {
+		t.Fatalf("PruneTransactions failed: %v", err)
+	}
+	assertFullRecompute(t, repo, addr)
+}
+
+func TestCalculateBalanceDoesNotSettle(t *testing.T) {
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"), WithFinalDepth(6))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	if err := repo.SaveTransactions(makeTransactions(addr, 3)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// A balance read neither marks transactions final nor stores their sum
+	assertFullRecompute(t, repo, addr)
+	assertFinalCount(t, repo, addr, 0)
+	var final int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE final = 1`).Scan(&final); err != nil {
+		t.Fatalf("failed to count final transactions: %v", err)
+	}
+	if final != 0 {
+		t.Errorf("Expected no final transactions after a read, got %d", final)
+	}
+
+	if _, err := repo.RefreshBalance(addr); err != nil {
+		t.Fatalf("RefreshBalance failed: %v", err)
+	}
+	assertFinalCount(t, repo, addr, 3)
+	assertFullRecompute(t, repo, addr)
+}
+
+// assertFullRecompute checks CalculateBalance against a balance aggregated from every transaction
+func assertFullRecompute(t *testing.T, repo *SQLiteRepository, address string) {
+	t.Helper()
+
+	got, err := repo.CalculateBalance(address)
+	if err != nil {
+		t.Fatalf("CalculateBalance failed: %v", err)
+	}
+	full, err := repo.GetBalances()
+	if err != nil {
+		t.Fatalf("GetBalances failed: %v", err)
+	}
+	if want := full[address]; want == nil || *got != *want {
+		t.Errorf("CalculateBalance = %+v; full recompute %+v", got, want)
+	}
+}
+
+// assertFinalCount checks how many transactions are summed into the stored final balance
+func assertFinalCount(t *testing.T, repo *SQLiteRepository, address string, want int) {
+	t.Helper()
+
+	var count int
+	err := repo.db.QueryRow(`SELECT final_count FROM addresses WHERE address = ?`, address).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to read final count: %v", err)
+	}
+	if count != want {
+		t.Errorf("final_count = %d; want %d", count, want)
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// requiredSchemaObjects lists the tables and indexes the application depends on
+var requiredSchemaObjects = map[string]string{
+	"addresses":                  "table",
+	"transactions":               "table",
+	"transaction_archive":        "table",
+	"idx_transactions_address":   "index",
+	"idx_transactions_timestamp": "index",
+	"idx_transactions_hash":      "index",
+}
+
+// CheckIntegrity verifies the database file, the schema and referential integrity.
+// Problems found are reported in the result; an error means the checks could not run.
+func (r *SQLiteRepository) CheckIntegrity() (*models.IntegrityReport, error) {
+	report := &models.IntegrityReport{Problems: []string{}}
+
+	// Verify the SQLite file itself
+	rows, err := r.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if result != "ok" {
+			report.Problems = append(report.Problems, "integrity_check: "+result)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+
+	// Verify required tables and indexes exist
+	for name, objType := range requiredSchemaObjects {
+		var count int
+		query := `SELECT COUNT(*) FROM sqlite_master WHERE type = ? AND name = ?`
+		if err := r.db.QueryRow(query, objType, name).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to inspect schema: %w", err)
+		}
+		if count == 0 {
+			report.Problems = append(report.Problems, fmt.Sprintf("missing %s: %s", objType, name))
+		}
+	}
+
+	// Find transactions whose address row was deleted without cascading, which foreign key enforcement
+	// now prevents but databases written before it was turned on may still hold
+	orphanQuery := `
+	SELECT COUNT(*) 
+	FROM transactions t 
+	LEFT JOIN addresses a ON a.address = t.address AND a.network = t.network 
+	WHERE a.address IS NULL`
+	if err := r.db.QueryRow(orphanQuery).Scan(&report.OrphanedTransactions); err != nil {
+		return nil, fmt.Errorf("failed to count orphaned transactions: %w", err)
+	}
+	if report.OrphanedTransactions > 0 {
+		report.Problems = append(report.Problems, fmt.Sprintf("%d orphaned transactions", report.OrphanedTransactions))
+	}
+
+	report.Healthy = len(report.Problems) == 0
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckIntegrityHealthy(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address)
+	if err := repo.SaveTransactions(makeTransactions(address, 3)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if !report.Healthy || len(report.Problems) != 0 || report.OrphanedTransactions != 0 {
+		t.Errorf("Expected a healthy report, got %+v", report)
+	}
+}
+
+func TestCheckIntegrityReportsOrphanedTransactions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	repo, err := NewSQLiteRepository(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address)
+	if err := repo.SaveTransactions(makeTransactions(address, 2)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// A connection without foreign key enforcement, as older versions used, deletes the row without cascading
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM addresses WHERE address = ?`, address); err != nil {
+		t.Fatalf("delete address row: %v", err)
+	}
+	db.Close()
+
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if report.Healthy || report.OrphanedTransactions != 2 {
+		t.Errorf("Expected 2 orphaned transactions, got %+v", report)
+	}
+	if !slices.Contains(report.Problems, "2 orphaned transactions") {
+		t.Errorf("Expected the orphans among the problems, got %v", report.Problems)
+	}
+}
+
+func TestForeignKeysPreventOrphans(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	if err := repo.SaveTransactions(makeTransactions(address, 1)); err == nil {
+		t.Error("Expected saving transactions for an untracked address to fail")
+	}
+
+	trackAddresses(t, repo, address)
+	if err := repo.SaveTransactions(makeTransactions(address, 2)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+	if _, err := repo.db.Exec(`DELETE FROM addresses WHERE address = ?`, address); err != nil {
+		t.Fatalf("delete address row: %v", err)
+	}
+
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if !report.Healthy {
+		t.Errorf("Expected the deletion to cascade to the transactions, got %+v", report)
+	}
+}
+
+func TestCheckIntegrityReportsMissingIndex(t *testing.T) {
+	repo := newTestRepository(t)
+	if _, err := repo.db.Exec(`DROP INDEX idx_transactions_hash`); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if report.Healthy || !slices.Equal(report.Problems, []string{"missing index: idx_transactions_hash"}) {
+		t.Errorf("Expected only the dropped index to be reported, got %+v", report)
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestNetworkScopedAddresses(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	// Legacy testnet and regtest addresses share the same encoding
+	const addr = "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"
+
+	testnet, err := NewSQLiteRepository(dbPath, WithNetwork("testnet"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer testnet.Close()
+	regtest, err := NewSQLiteRepository(dbPath, WithNetwork("regtest"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer regtest.Close()
+
+	for _, repo := range []*SQLiteRepository{testnet, regtest} {
+		if _, err := repo.AddAddress(models.Address{Address: addr}); err != nil {
+			t.Fatalf("AddAddress on %s failed: %v", repo.network, err)
+		}
+	}
+	if err := testnet.SaveTransactions(makeTransactions(addr, 3)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	got, err := regtest.GetAddress(addr)
+	if err != nil {
+		t.Fatalf("GetAddress failed: %v", err)
+	}
+	if got.Network != "regtest" {
+		t.Errorf("got network %q; want regtest", got.Network)
+	}
+	balance, err := regtest.GetBalance(addr)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.TotalBalance != 0 {
+		t.Errorf("regtest balance %d; want 0, testnet transactions must not leak", balance.TotalBalance)
+	}
+
+	if err := testnet.RemoveAddress(addr); err != nil {
+		t.Fatalf("RemoveAddress failed: %v", err)
+	}
+	if _, err := regtest.GetAddress(addr); err != nil {
+		t.Errorf("removing the testnet address removed the regtest one: %v", err)
+	}
+}
+
+func TestMigrateNetworkScope(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	const addr = "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"
+
+	// Build a database with the schema used before addresses were scoped by network
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	legacy := []string{
+		`CREATE TABLE addresses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			address TEXT UNIQUE NOT NULL,
+			label TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_synced DATETIME
+		)`,
+		`CREATE TABLE transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			hash TEXT NOT NULL,
+			address TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			confirmations INTEGER NOT NULL,
+			block_height INTEGER NOT NULL,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			UNIQUE(hash, address),
+			FOREIGN KEY(address) REFERENCES addresses(address) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE sync_checkpoint (id INTEGER PRIMARY KEY CHECK (id = 1), run_started_at DATETIME NOT NULL, cursor TEXT, updated_at DATETIME NOT NULL)`,
+		`INSERT INTO addresses (address, label) VALUES ('` + addr + `', 'savings')`,
+		`INSERT INTO transactions (hash, address, amount, confirmations, block_height, timestamp, type) 
+		VALUES ('aa', '` + addr + `', 5000, 3, 100, '2024-01-01 00:00:00', 'received')`,
+	}
+	for _, stmt := range legacy {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("legacy schema: %v", err)
+		}
+	}
+	db.Close()
+
+	repo, err := NewSQLiteRepository(dbPath, WithNetwork("testnet"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	got, err := repo.GetAddress(addr)
+	if err != nil {
+		t.Fatalf("GetAddress failed: %v", err)
+	}
+	if got.Network != "testnet" || got.Label != "savings" {
+		t.Errorf("got network %q label %q; want testnet savings", got.Network, got.Label)
+	}
+	balance, err := repo.GetBalance(addr)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.TotalBalance != 5000 {
+		t.Errorf("got balance %d; want 5000", balance.TotalBalance)
+	}
+
+	// The same address can now be tracked separately on another network
+	regtest, err := NewSQLiteRepository(dbPath, WithNetwork("regtest"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer regtest.Close()
+	if _, err := regtest.AddAddress(models.Address{Address: addr}); err != nil {
+		t.Errorf("AddAddress on regtest failed: %v", err)
+	}
+
+	report, err := repo.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if !report.Healthy {
+		t.Errorf("migrated database is unhealthy: %v", report.Problems)
+	}
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDatabaseDirNotWritable is returned when the directory holding the database file cannot be written
+var ErrDatabaseDirNotWritable = errors.New("database directory is not writable")
+
+// ErrDatabaseLocked is returned when another process holds a lock on the database file
+var ErrDatabaseLocked = errors.New("database file is locked")
+
+// openSQLite opens the SQLite database at dbPath, creating its parent directory when needed,
+// and reports unusable locations with errors that say what is wrong
+func openSQLite(dbPath string) (*sql.DB, error) {
+	if path := databaseFile(dbPath); path != "" {
+		if err := prepareDatabaseDir(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("sqlite3", enforceForeignKeys(dbPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// sql.Open is lazy; connect now so problems surface here rather than on the first query
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, describeOpenError(dbPath, err)
+	}
+
+	return db, nil
+}
+
+// enforceForeignKeys adds the DSN parameter that turns on foreign key enforcement, which SQLite leaves off
+// by default, so it applies to every pooled connection
+func enforceForeignKeys(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_foreign_keys=1"
+	}
+	return dsn + "?_foreign_keys=1"
+}
+
+// openReadOnlySQLite opens a read-only connection to the SQLite database at dsn, which must already exist
+func openReadOnlySQLite(dsn string) (*sql.DB, error) {
+	uri := dsn
+	if !strings.HasPrefix(uri, "file:") {
+		uri = "file:" + uri
+	}
+	if !strings.Contains(uri, "mode=") {
+		if strings.Contains(uri, "?") {
+			uri += "&mode=ro"
+		} else {
+			uri += "?mode=ro"
+		}
+	}
+
+	db, err := sql.Open("sqlite3", uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, describeOpenError(dsn, err)
+	}
+
+	return db, nil
+}
+
+// databaseFile returns the file behind a SQLite DSN, or "" for in-memory databases
+func databaseFile(dsn string) string {
+	path := strings.TrimPrefix(dsn, "file:")
+	path, query, _ := strings.Cut(path, "?")
+	if path == "" || path == ":memory:" || strings.Contains(query, "mode=memory") {
+		return ""
+	}
+	return path
+}
+
+// prepareDatabaseDir creates dir if it is missing and checks that new files can be written to it
+func prepareDatabaseDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create database directory %s: %w", dir, err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDatabaseDirNotWritable, dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
+
+// describeOpenError translates SQLite open failures into errors naming the cause
+func describeOpenError(dbPath string, err error) error {
+	if isLockError(err) {
+		return lockedError(dbPath)
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrCantOpen, sqlite3.ErrPerm, sqlite3.ErrReadonly:
+			return fmt.Errorf("cannot open database file %s, check its permissions: %w", dbPath, err)
+		}
+	}
+	return fmt.Errorf("failed to open database %s: %w", dbPath, err)
+}
+
+// isLockError reports whether err is SQLite refusing access because another connection holds a lock
+func isLockError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// lockedError reports that dbPath is locked by another process
+func lockedError(dbPath string) error {
+	return fmt.Errorf("%w: %s is in use by another process", ErrDatabaseLocked, dbPath)
+}
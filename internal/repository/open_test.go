@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestNewSQLiteRepositoryCreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data", "nested")
+
+	repo, err := NewSQLiteRepository(filepath.Join(dir, "tracker.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "tracker.db")); err != nil {
+		t.Errorf("Expected the database file to be created: %v", err)
+	}
+}
+
+func TestNewSQLiteRepositoryInMemory(t *testing.T) {
+	repo, err := NewSQLiteRepository(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	repo.Close()
+}
+
+func TestDatabaseFile(t *testing.T) {
+	testCases := []struct {
+		dsn  string
+		want string
+	}{
+		{"bitcoin_tracker.db", "bitcoin_tracker.db"},
+		{"/var/lib/tracker/bitcoin.db", "/var/lib/tracker/bitcoin.db"},
+		{"file:data/bitcoin.db?_busy_timeout=5000", "data/bitcoin.db"},
+		{":memory:", ""},
+		{"file::memory:?cache=shared", ""},
+		{"file:test.db?mode=memory", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := databaseFile(tc.dsn); got != tc.want {
+			t.Errorf("databaseFile(%q) = %q; want %q", tc.dsn, got, tc.want)
+		}
+	}
+}
+
+func TestReadDSNServesListings(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath, replicaPath := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+
+	primary, err := NewSQLiteRepository(primaryPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	if _, err := primary.AddAddress(models.Address{Address: "addr-a"}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	primary.Close()
+
+	// The replica is a snapshot taken before addr-b was added
+	data, err := os.ReadFile(primaryPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := os.WriteFile(replicaPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	repo, err := NewSQLiteRepository(primaryPath, WithReadDSN(replicaPath))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.AddAddress(models.Address{Address: "addr-b"}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	if _, err := repo.GetAddress("addr-b"); err != nil {
+		t.Errorf("Expected address lookups missing from the replica to fall back to the primary: %v", err)
+	}
+
+	listed, err := repo.GetAllAddresses()
+	if err != nil {
+		t.Fatalf("GetAllAddresses failed: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Address != "addr-a" {
+		t.Errorf("Expected the listing to come from the replica, got %+v", listed)
+	}
+
+	if _, err := repo.reads.Exec(`DELETE FROM addresses`); err == nil {
+		t.Error("Expected the read connection to be read-only")
+	}
+}
+
+func TestReadDSNMustExist(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewSQLiteRepository(filepath.Join(dir, "primary.db"), WithReadDSN(filepath.Join(dir, "missing.db"))); err == nil {
+		t.Error("Expected a missing read database to be rejected")
+	}
+}
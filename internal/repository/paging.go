@@ -0,0 +1,29 @@
+package repository
+
+// Page bounds applied to listing reads unless WithPageLimits says otherwise
+const (
+	DefaultPageLimit    = 50
+	DefaultMaxPageLimit = 100
+)
+
+// WithPageLimits sets the page size of listing reads made without a limit and the largest page a
+// single read may return, whatever the caller asks for. Non-positive values keep the defaults.
+func WithPageLimits(defaultLimit, maxLimit int) Option {
+	return func(r *SQLiteRepository) {
+		if defaultLimit > 0 {
+			r.pageLimit = defaultLimit
+		}
+		if maxLimit > 0 {
+			r.maxPageLimit = maxLimit
+		}
+	}
+}
+
+// clampPage bounds a listing's pagination: a missing limit becomes the default page size, larger
+// limits are capped and negative offsets start from the beginning
+func (r *SQLiteRepository) clampPage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = r.pageLimit
+	}
+	return min(limit, r.maxPageLimit), max(offset, 0)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestListingsClampPageSize(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	testCases := []struct {
+		name        string
+		opts        []Option
+		defaultSize int
+		maxSize     int
+	}{
+		{"defaults", nil, DefaultPageLimit, DefaultMaxPageLimit},
+		{"configured", []Option{WithPageLimits(10, 20)}, 10, 20},
+	}
+
+	for _, tc := range testCases {
+		repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"), tc.opts...)
+		if err != nil {
+			t.Fatalf("NewSQLiteRepository failed: %v", err)
+		}
+		defer repo.Close()
+
+		trackAddresses(t, repo, address)
+		if err := repo.SaveTransactions(makeTransactions(address, 150)); err != nil {
+			t.Fatalf("SaveTransactions failed: %v", err)
+		}
+
+		pages := []struct {
+			limit, offset, want int
+		}{
+			{1_000_000, 0, tc.maxSize},
+			{0, 0, tc.defaultSize},
+			{-5, -5, tc.defaultSize},
+			{5, 0, 5},
+			{1_000_000, 145, 5},
+		}
+		for _, page := range pages {
+			txs, err := repo.GetTransactionsByAddress(address, page.limit, page.offset)
+			if err != nil {
+				t.Fatalf("%s: GetTransactionsByAddress failed: %v", tc.name, err)
+			}
+			if len(txs) != page.want {
+				t.Errorf("%s: GetTransactionsByAddress(limit %d, offset %d) returned %d rows; want %d",
+					tc.name, page.limit, page.offset, len(txs), page.want)
+			}
+
+			txs, err = repo.QueryTransactions(models.TransactionFilter{Limit: page.limit, Offset: page.offset})
+			if err != nil {
+				t.Fatalf("%s: QueryTransactions failed: %v", tc.name, err)
+			}
+			if len(txs) != page.want {
+				t.Errorf("%s: QueryTransactions(limit %d, offset %d) returned %d rows; want %d",
+					tc.name, page.limit, page.offset, len(txs), page.want)
+			}
+		}
+
+		// Streaming is left unbounded for exports
+		streamed := 0
+		if err := repo.StreamTransactions(models.TransactionFilter{}, func(*models.Transaction) error {
+			streamed++
+			return nil
+		}); err != nil || streamed != 150 {
+			t.Errorf("%s: StreamTransactions streamed %d rows (err %v); want 150", tc.name, streamed, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import "strings"
+
+// queryBuilder assembles a SELECT statement from optional conditions
+type queryBuilder struct {
+	base       string
+	conditions []string
+	args       []interface{}
+	orderBy    string
+	limit      int
+	offset     int
+}
+
+// newQueryBuilder starts a query from a base SELECT ... FROM clause
+func newQueryBuilder(base string) *queryBuilder {
+	return &queryBuilder{base: base}
+}
+
+// where adds a condition joined to the others with AND
+func (q *queryBuilder) where(condition string, args ...interface{}) *queryBuilder {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// order sets the ORDER BY clause; callers must pass trusted column names only
+func (q *queryBuilder) order(orderBy string) *queryBuilder {
+	q.orderBy = orderBy
+	return q
+}
+
+// page sets LIMIT and OFFSET; a zero limit leaves the result unbounded
+func (q *queryBuilder) page(limit, offset int) *queryBuilder {
+	q.limit = limit
+	q.offset = offset
+	return q
+}
+
+// build returns the SQL statement and its arguments
+func (q *queryBuilder) build() (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(q.base)
+
+	if len(q.conditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(q.conditions, " AND "))
+	}
+
+	if q.orderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(q.orderBy)
+	}
+
+	args := q.args
+	switch {
+	case q.limit > 0:
+		sb.WriteString(" LIMIT ? OFFSET ?")
+		args = append(args, q.limit, q.offset)
+	case q.offset > 0:
+		// SQLite only accepts OFFSET together with LIMIT; -1 means unbounded
+		sb.WriteString(" LIMIT -1 OFFSET ?")
+		args = append(args, q.offset)
+	}
+
+	return sb.String(), args
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// seedFilterTransactions stores transactions of two addresses that differ in every filtered field
+func seedFilterTransactions(t *testing.T, repo *SQLiteRepository) {
+	t.Helper()
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	txs := []models.Transaction{
+		{Hash: "a1", Address: "addr-a", Amount: 5000, BlockHeight: 800001, Confirmations: 6, Timestamp: day(1), Type: "received"},
+		{Hash: "a2", Address: "addr-a", Amount: -2000, BlockHeight: 800003, Confirmations: 6, Timestamp: day(2), Type: "sent"},
+		{Hash: "a3", Address: "addr-a", Amount: 1000, BlockHeight: 800002, Confirmations: 6, Timestamp: day(3), Type: "received"},
+		{Hash: "b1", Address: "addr-b", Amount: 9000, BlockHeight: 800004, Confirmations: 6, Timestamp: day(4), Type: "received"},
+		{Hash: "b2", Address: "addr-b", Amount: -500, Timestamp: day(5), Type: "sent"},
+	}
+	trackAddresses(t, repo, "addr-a", "addr-b")
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+}
+
+// queryHashes returns the hashes QueryTransactions finds for filter, in order
+func queryHashes(t *testing.T, repo *SQLiteRepository, filter models.TransactionFilter) []string {
+	t.Helper()
+
+	txs, err := repo.QueryTransactions(filter)
+	if err != nil {
+		t.Fatalf("QueryTransactions(%+v) failed: %v", filter, err)
+	}
+	hashes := []string{}
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes
+}
+
+func TestQueryTransactionsFilters(t *testing.T) {
+	repo := newTestRepository(t)
+	seedFilterTransactions(t, repo)
+
+	at := func(d int) *time.Time {
+		t := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	amount := func(a int64) *int64 { return &a }
+
+	testCases := []struct {
+		name   string
+		filter models.TransactionFilter
+		want   []string
+	}{
+		{"no filter, newest first", models.TransactionFilter{SortDesc: true}, []string{"b2", "b1", "a3", "a2", "a1"}},
+		{"address", models.TransactionFilter{Address: "addr-a"}, []string{"a1", "a2", "a3"}},
+		{"hash", models.TransactionFilter{Hash: "b1"}, []string{"b1"}},
+		{"received", models.TransactionFilter{Type: "received"}, []string{"a1", "a3", "b1"}},
+		{"sent", models.TransactionFilter{Type: "sent"}, []string{"a2", "b2"}},
+		{"from is inclusive", models.TransactionFilter{From: at(4)}, []string{"b1", "b2"}},
+		{"to is inclusive", models.TransactionFilter{To: at(2)}, []string{"a1", "a2"}},
+		{"from and to", models.TransactionFilter{From: at(2), To: at(4)}, []string{"a2", "a3", "b1"}},
+		{"from in another zone", models.TransactionFilter{From: func() *time.Time {
+			// 02:00 at UTC+2 is midnight UTC
+			t := time.Date(2024, 1, 5, 2, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+			return &t
+		}()}, []string{"b2"}},
+		{"min amount", models.TransactionFilter{MinAmount: amount(1000)}, []string{"a1", "a3", "b1"}},
+		{"max amount", models.TransactionFilter{MaxAmount: amount(-500)}, []string{"a2", "b2"}},
+		{"amount range", models.TransactionFilter{MinAmount: amount(0), MaxAmount: amount(5000)}, []string{"a1", "a3"}},
+		{"combined", models.TransactionFilter{Address: "addr-a", Type: "received", MinAmount: amount(2000)}, []string{"a1"}},
+		{"no match", models.TransactionFilter{Address: "addr-c"}, []string{}},
+		{"sort by amount", models.TransactionFilter{SortBy: "amount"}, []string{"a2", "b2", "a3", "a1", "b1"}},
+		{"sort by amount descending", models.TransactionFilter{SortBy: "amount", SortDesc: true}, []string{"b1", "a1", "a3", "b2", "a2"}},
+		{"sort by block height", models.TransactionFilter{SortBy: "block_height"}, []string{"b2", "a1", "a3", "a2", "b1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := queryHashes(t, repo, tc.filter); !slices.Equal(got, tc.want) {
+				t.Errorf("QueryTransactions() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := repo.QueryTransactions(models.TransactionFilter{SortBy: "hash"}); err == nil {
+		t.Error("Expected an unsupported sort field to be rejected")
+	}
+}
+
+func TestQueryTransactionsPagination(t *testing.T) {
+	repo := newTestRepository(t)
+	seedFilterTransactions(t, repo)
+
+	testCases := []struct {
+		limit, offset int
+		want          []string
+	}{
+		{2, 0, []string{"a1", "a2"}},
+		{2, 2, []string{"a3", "b1"}},
+		{2, 4, []string{"b2"}},
+		{2, 5, []string{}},
+		{10, 3, []string{"b1", "b2"}},
+	}
+	for _, tc := range testCases {
+		filter := models.TransactionFilter{Limit: tc.limit, Offset: tc.offset}
+		if got := queryHashes(t, repo, filter); !slices.Equal(got, tc.want) {
+			t.Errorf("QueryTransactions(limit %d, offset %d) = %v; want %v", tc.limit, tc.offset, got, tc.want)
+		}
+	}
+
+	// Filters are applied before the page is cut
+	filter := models.TransactionFilter{Type: "received", Limit: 1, Offset: 1}
+	if got := queryHashes(t, repo, filter); !slices.Equal(got, []string{"a3"}) {
+		t.Errorf("QueryTransactions(received, limit 1, offset 1) = %v; want [a3]", got)
+	}
+}
+
+func TestQueryBuilderPage(t *testing.T) {
+	testCases := []struct {
+		name          string
+		limit, offset int
+		wantSQL       string
+		wantArgs      []interface{}
+	}{
+		{"unbounded", 0, 0, "SELECT * FROM t WHERE a = ?", []interface{}{1}},
+		{"limit", 10, 0, "SELECT * FROM t WHERE a = ? LIMIT ? OFFSET ?", []interface{}{1, 10, 0}},
+		{"limit and offset", 10, 20, "SELECT * FROM t WHERE a = ? LIMIT ? OFFSET ?", []interface{}{1, 10, 20}},
+		{"offset only", 0, 20, "SELECT * FROM t WHERE a = ? LIMIT -1 OFFSET ?", []interface{}{1, 20}},
+	}
+
+	for _, tc := range testCases {
+		query, args := newQueryBuilder("SELECT * FROM t").where("a = ?", 1).page(tc.limit, tc.offset).build()
+		if query != tc.wantSQL || !slices.Equal(args, tc.wantArgs) {
+			t.Errorf("%s: build() = %q %v; want %q %v", tc.name, query, args, tc.wantSQL, tc.wantArgs)
+		}
+	}
+
+	query, _ := newQueryBuilder("SELECT * FROM t").where("a = ?", 1).where("b = ?", 2).order("c DESC").build()
+	if !strings.HasSuffix(query, " WHERE a = ? AND b = ? ORDER BY c DESC") {
+		t.Errorf("build() = %q; want conditions joined with AND before the ORDER BY", query)
+	}
+}
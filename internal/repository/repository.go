@@ -3,66 +3,197 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ihladush/bitcoin/internal/models"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // Repository interface defines the contract for data access
 type Repository interface {
 	// Address operations
-	AddAddress(address, label string) (*models.Address, error)
+	AddAddress(addr models.Address) (*models.Address, error)
 	RemoveAddress(address string) error
+	RemoveAddresses(addresses []string, soft bool) ([]string, error)
+	ArchiveInactiveAddresses(inactiveSince time.Time) ([]string, error)
+	RestoreAddress(address string) error
 	GetAddress(address string) (*models.Address, error)
 	GetAllAddresses() ([]models.Address, error)
+	UpdateAddress(address string, update models.UpdateAddressRequest) (*models.Address, error)
+	MarkOwnershipVerified(address string, verifiedAt time.Time) (*models.Address, error)
+	SaveOwnershipChallenge(challenge models.OwnershipChallenge, now time.Time) error
+	ConsumeOwnershipChallenge(address, message string, now time.Time) (bool, error)
+	SetSyncEnabled(address string, enabled bool) (*models.Address, error)
 	UpdateLastSynced(address string, syncTime time.Time) error
+	SetHistoryTruncated(address string) error
+	ResetLastSynced(address string, syncTime *time.Time) error
+	UpdateAddressStats(address string, stats models.AddressStats) error
+	GetAddressStats(address string) (*models.AddressStats, error)
+	ListAddressesAfter(afterID, limit int) ([]models.Address, error)
+	SetAddressType(address, addrType string) (bool, error)
+	SearchAddresses(fragment string, limit int) ([]models.Address, error)
 
 	// Transaction operations
 	SaveTransaction(tx *models.Transaction) error
+	SaveTransactions(txs []models.Transaction) error
 	GetTransactionsByAddress(address string, limit, offset int) ([]models.Transaction, error)
+	GetLatestTransaction(address string) (*models.Transaction, error)
+	QueryTransactions(filter models.TransactionFilter) ([]models.Transaction, error)
+	StreamTransactions(filter models.TransactionFilter, fn func(*models.Transaction) error) error
+	CountTransactions(filter models.TransactionFilter) (int, error)
+	GetTransaction(hash, address string) (*models.Transaction, error)
 	TransactionExists(hash, address string) (bool, error)
+	GetExistingHashes(address string) (map[string]bool, error)
+	GetNonFinalTransactions(address string, finalDepth, tip int) ([]models.Transaction, error)
+	PruneTransactions(address string, keep int) (int64, error)
+	ArchiveOldTransactions(olderThan time.Time) (int64, error)
 
 	// Balance operations
 	GetBalance(address string) (*models.Balance, error)
 	CalculateBalance(address string) (*models.Balance, error)
+	GetBalances() (map[string]*models.Balance, error)
+	RefreshBalance(address string) (*models.Balance, error)
+
+	// Analytics
+	GetActivity(address string) (*models.AddressActivity, error)
+	GetSharedTransactions(a, b string) ([]models.SharedTransaction, error)
+	GetCoSpends() ([]models.CoSpend, error)
+	GetTypeCounts(address string) ([]models.TypeCount, error)
+	GetVolume(address, bucket string, from, to *time.Time) ([]models.VolumeBucket, error)
+	GetMaxBlockHeight() (int, error)
+
+	// Tags
+	TagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error)
+	UntagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error)
+	GetTaggedAddresses(tag string) ([]models.Address, error)
+
+	// Sync run checkpointing
+	GetSyncCheckpoint() (*models.SyncCheckpoint, error)
+	SaveSyncCheckpoint(checkpoint models.SyncCheckpoint) error
+	ClearSyncCheckpoint() error
+	GetAddressesSyncedBefore(t time.Time) ([]models.Address, error)
+
+	// Per-address sync history
+	AddSyncLogEntry(entry models.SyncLogEntry) error
+	GetSyncLog(address string, limit int) ([]models.SyncLogEntry, error)
+	PruneSyncLog(cutoff time.Time) (int64, error)
+
+	// Maintenance operations
+	CheckIntegrity() (*models.IntegrityReport, error)
 }
 
-// SQLiteRepository implements Repository interface using SQLite
+// SQLiteRepository implements Repository interface using SQLite.
+// Every address and transaction it reads or writes belongs to its network, so deployments
+// tracking different networks can share one database file.
 type SQLiteRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	reads   *sql.DB // the read connection; db unless WithReadDSN is set
+	stmts   statements
+	network string
+	readDSN string
+
+	balanceCache    bool
+	finalDepth      int
+	preserveHistory bool
+
+	pageLimit    int
+	maxPageLimit int
+}
+
+// ErrAddressNotFound is returned when an address is not tracked on the repository's network
+var ErrAddressNotFound = errors.New("address not found")
+
+// DefaultNetwork is the network a repository is scoped to unless WithNetwork says otherwise
+const DefaultNetwork = "mainnet"
+
+// Option configures optional SQLiteRepository behavior
+type Option func(*SQLiteRepository)
+
+// WithNetwork scopes the repository to the addresses and transactions of network
+func WithNetwork(network string) Option {
+	return func(r *SQLiteRepository) {
+		r.network = network
+	}
+}
+
+// WithReadDSN sends dashboard reads (address lookups and listings, transaction listings, balances and analytics) to a
+// separate read-only connection, such as a replicated copy of the database, while writes and the
+// reads that sync decisions depend on stay on the primary. Reads from a lagging copy may be stale.
+func WithReadDSN(dsn string) Option {
+	return func(r *SQLiteRepository) {
+		r.readDSN = dsn
+	}
+}
+
+// WithPreservedHistory sets whether AddAddress keeps the stored transactions and original created_at of
+// an archived address it revives, so the next sync merges new activity into the existing history, or
+// starts the address over like a new one. History is kept by default, so archiving stays reversible.
+func WithPreservedHistory(enabled bool) Option {
+	return func(r *SQLiteRepository) {
+		r.preserveHistory = enabled
+	}
 }
 
 // NewSQLiteRepository creates a new SQLite repository
-func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+func NewSQLiteRepository(dbPath string, opts ...Option) (*SQLiteRepository, error) {
+	db, err := openSQLite(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	repo := &SQLiteRepository{db: db}
+	repo := &SQLiteRepository{
+		db:           db,
+		reads:        db,
+		network:         DefaultNetwork,
+		preserveHistory: true,
+		pageLimit:       DefaultPageLimit,
+		maxPageLimit:    DefaultMaxPageLimit,
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
 	if err := repo.createTables(); err != nil {
+		db.Close()
+		if isLockError(err) {
+			return nil, lockedError(dbPath)
+		}
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
+	if repo.readDSN != "" {
+		if repo.reads, err = openReadOnlySQLite(repo.readDSN); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := repo.prepareStatements(); err != nil {
+		repo.closeConnections()
+		return nil, err
+	}
 
 	return repo, nil
 }
 
-// createTables creates the necessary database tables
-func (r *SQLiteRepository) createTables() error {
-	// Create addresses table
-	addressTable := `
+// addressTable is the current addresses schema; columns added later are applied by addLaterColumns
+const addressTable = `
 	CREATE TABLE IF NOT EXISTS addresses (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		address TEXT UNIQUE NOT NULL,
+		address TEXT NOT NULL,
+		network TEXT NOT NULL DEFAULT 'mainnet',
 		label TEXT,
+		address_type TEXT,
+		owned INTEGER NOT NULL DEFAULT 0,
+		history_truncated INTEGER NOT NULL DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_synced DATETIME
+		last_synced DATETIME,
+		archived_at DATETIME,
+		UNIQUE(address, network)
 	);`
 
-	// Create transactions table
-	transactionTable := `
+// transactionTable is the current transactions schema; columns added later are applied by addLaterColumns
+const transactionTable = `
 	CREATE TABLE IF NOT EXISTS transactions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		hash TEXT NOT NULL,
@@ -72,13 +203,16 @@ func (r *SQLiteRepository) createTables() error {
 		block_height INTEGER NOT NULL,
 		timestamp DATETIME NOT NULL,
 		type TEXT NOT NULL,
-		UNIQUE(hash, address),
-		FOREIGN KEY(address) REFERENCES addresses(address) ON DELETE CASCADE
+		network TEXT NOT NULL DEFAULT 'mainnet',
+		UNIQUE(hash, address, network),
+		FOREIGN KEY(address, network) REFERENCES addresses(address, network) ON DELETE CASCADE
 	);`
 
+// createTables creates the necessary database tables
+func (r *SQLiteRepository) createTables() error {
 	// Create indexes for better performance
 	indexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_transactions_address ON transactions(address);",
+		"CREATE INDEX IF NOT EXISTS idx_transactions_address ON transactions(address, network);",
 		"CREATE INDEX IF NOT EXISTS idx_transactions_timestamp ON transactions(timestamp);",
 		"CREATE INDEX IF NOT EXISTS idx_transactions_hash ON transactions(hash);",
 	}
@@ -92,6 +226,37 @@ func (r *SQLiteRepository) createTables() error {
 		return fmt.Errorf("failed to create transactions table: %w", err)
 	}
 
+	if err := addLaterColumns(r.db); err != nil {
+		return err
+	}
+
+	if err := r.migrateNetworkScope(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(syncCheckpointTable); err != nil {
+		return fmt.Errorf("failed to create sync checkpoint table: %w", err)
+	}
+
+	if _, err := r.db.Exec(syncLogTable); err != nil {
+		return fmt.Errorf("failed to create sync log table: %w", err)
+	}
+	indexes = append(indexes, syncLogIndexes...)
+
+	if _, err := r.db.Exec(addressTagsTable); err != nil {
+		return fmt.Errorf("failed to create address tags table: %w", err)
+	}
+	indexes = append(indexes, addressTagsIndexes...)
+
+	if _, err := r.db.Exec(transactionArchiveTable); err != nil {
+		return fmt.Errorf("failed to create transaction archive table: %w", err)
+	}
+	indexes = append(indexes, transactionArchiveIndexes...)
+
+	if _, err := r.db.Exec(ownershipChallengesTable); err != nil {
+		return fmt.Errorf("failed to create ownership challenges table: %w", err)
+	}
+
 	// Create indexes
 	for _, index := range indexes {
 		if _, err := r.db.Exec(index); err != nil {
@@ -102,26 +267,234 @@ func (r *SQLiteRepository) createTables() error {
 	return nil
 }
 
-// AddAddress adds a new address to track
-func (r *SQLiteRepository) AddAddress(address, label string) (*models.Address, error) {
-	query := `INSERT INTO addresses (address, label) VALUES (?, ?) RETURNING id, created_at`
-	
-	var addr models.Address
-	addr.Address = address
-	addr.Label = label
-	
-	err := r.db.QueryRow(query, address, label).Scan(&addr.ID, &addr.CreatedAt)
+// schemaExecer is implemented by both *sql.DB and *sql.Tx
+type schemaExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// addLaterColumns adds the columns introduced after the initial schema
+func addLaterColumns(db schemaExecer) error {
+	if err := addColumnIfMissing(db, "addresses", "address_type", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "archived_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "owned", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "history_truncated", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "ownership_verified_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "sync_enabled", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "addresses", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	for _, column := range addressStatsColumns {
+		if err := addColumnIfMissing(db, "addresses", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	for _, column := range balanceCacheColumns {
+		if err := addColumnIfMissing(db, "addresses", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	if err := addColumnIfMissing(db, "transactions", "coinbase", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	for _, column := range finalBalanceColumns {
+		if err := addColumnIfMissing(db, "addresses", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	if err := addColumnIfMissing(db, "transactions", "final", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	for _, column := range archiveBalanceColumns {
+		if err := addColumnIfMissing(db, "addresses", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateNetworkScope upgrades databases created before addresses were scoped by network.
+// SQLite cannot change a UNIQUE constraint in place, so both tables are rebuilt and their
+// existing rows are assigned the repository's network. The old single-row sync checkpoint
+// is dropped; an interrupted run simply starts over.
+func (r *SQLiteRepository) migrateNetworkScope() error {
+	columns, err := tableColumns(r.db, "addresses")
+	if err != nil {
+		return err
+	}
+	if slices.Contains(columns, "network") {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin network migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Rename transactions first so its foreign key follows addresses to the old table
+	steps := []string{
+		`DROP INDEX IF EXISTS idx_transactions_address`,
+		`DROP TABLE IF EXISTS sync_checkpoint`,
+		`ALTER TABLE transactions RENAME TO transactions_old`,
+		`ALTER TABLE addresses RENAME TO addresses_old`,
+		addressTable,
+		transactionTable,
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step); err != nil {
+			return fmt.Errorf("failed to migrate to network-scoped tables: %w", err)
+		}
+	}
+	if err := addLaterColumns(tx); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"addresses", "transactions"} {
+		columns, err := tableColumns(tx, table+"_old")
+		if err != nil {
+			return err
+		}
+		list := strings.Join(columns, ", ")
+		copyRows := fmt.Sprintf(`INSERT INTO %s (%s, network) SELECT %s, ? FROM %s_old`, table, list, list, table)
+		if _, err := tx.Exec(copyRows, r.network); err != nil {
+			return fmt.Errorf("failed to copy %s into the network-scoped table: %w", table, err)
+		}
+	}
+
+	for _, table := range []string{"transactions_old", "addresses_old"} {
+		if _, err := tx.Exec(`DROP TABLE ` + table); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit network migration: %w", err)
+	}
+
+	return nil
+}
+
+// tableColumns returns the column names of table in schema order
+func tableColumns(db schemaExecer, table string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return nil, fmt.Errorf("failed to scan %s table info: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+
+	return columns, nil
+}
+
+// addColumnIfMissing adds a column to an existing table so older databases pick up schema changes
+func addColumnIfMissing(db schemaExecer, table, column, definition string) error {
+	columns, err := tableColumns(db, table)
 	if err != nil {
+		return err
+	}
+	if slices.Contains(columns, column) {
+		return nil
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+
+	return nil
+}
+
+// AddAddress adds a new address to track, reviving it if it was archived. A revived address starts
+// with a clean history unless WithPreservedHistory is set.
+func (r *SQLiteRepository) AddAddress(addr models.Address) (*models.Address, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reviveQuery := `
+	UPDATE addresses 
+	SET label = ?, address_type = ?, owned = ?, history_truncated = 0, sync_enabled = 1, priority = 0, created_at = CURRENT_TIMESTAMP, last_synced = NULL, archived_at = NULL, balance_updated_at = NULL, archive_balance = 0, archive_count = 0 
+	WHERE address = ? AND network = ? AND archived_at IS NOT NULL 
+	RETURNING id, created_at`
+	if r.preserveHistory {
+		// last_synced is still cleared so the revived address is synced as soon as possible
+		reviveQuery = `
+		UPDATE addresses 
+		SET label = ?, address_type = ?, owned = ?, sync_enabled = 1, priority = 0, last_synced = NULL, archived_at = NULL, balance_updated_at = NULL 
+		WHERE address = ? AND network = ? AND archived_at IS NOT NULL 
+		RETURNING id, created_at`
+	}
+
+	addr.Network = r.network
+	err = tx.QueryRow(reviveQuery, addr.Label, addr.Type, addr.Owned, addr.Address, r.network).Scan(&addr.ID, &addr.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		query := `INSERT INTO addresses (address, network, label, address_type, owned) VALUES (?, ?, ?, ?, ?) RETURNING id, created_at`
+		if err := tx.QueryRow(query, addr.Address, r.network, addr.Label, addr.Type, addr.Owned).Scan(&addr.ID, &addr.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to add address: %w", err)
+		}
+	case err != nil:
 		return nil, fmt.Errorf("failed to add address: %w", err)
+	case !r.preserveHistory:
+		// A revived address starts over, matching a freshly added one
+		if _, err := tx.Exec(`DELETE FROM transactions WHERE address = ? AND network = ?`, addr.Address, r.network); err != nil {
+			return nil, fmt.Errorf("failed to clear archived transactions: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM transaction_archive WHERE address = ? AND network = ?`, addr.Address, r.network); err != nil {
+			return nil, fmt.Errorf("failed to clear the transaction archive: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit address: %w", err)
 	}
 
 	return &addr, nil
 }
 
-// RemoveAddress removes an address from tracking
+// RemoveAddress removes an address from tracking together with its transactions, archive and tags
 func (r *SQLiteRepository) RemoveAddress(address string) error {
-	query := `DELETE FROM addresses WHERE address = ?`
-	result, err := r.db.Exec(query, address)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM addresses WHERE address = ? AND network = ?`, address, r.network)
 	if err != nil {
 		return fmt.Errorf("failed to remove address: %w", err)
 	}
@@ -132,29 +505,182 @@ func (r *SQLiteRepository) RemoveAddress(address string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("address not found: %s", address)
+		return fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	if err := deleteAddressData(tx, "?", []interface{}{r.network, address}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address removal: %w", err)
 	}
 
 	return nil
 }
 
-// GetAddress retrieves a specific address
-func (r *SQLiteRepository) GetAddress(address string) (*models.Address, error) {
-	query := `SELECT id, address, label, created_at, last_synced FROM addresses WHERE address = ?`
-	
+// deleteAddressData deletes the transactions, archived transactions and tags of the addresses matched by
+// placeholders, with args holding the network followed by the addresses. Only transactions declare a
+// cascading foreign key, and rows written before foreign keys were enforced may not follow it, so every
+// hard removal has to go through here.
+func deleteAddressData(tx *sql.Tx, placeholders string, args []interface{}) error {
+	deleteTxs := fmt.Sprintf(`DELETE FROM transactions WHERE network = ? AND address IN (%s)`, placeholders)
+	if _, err := tx.Exec(deleteTxs, args...); err != nil {
+		return fmt.Errorf("failed to remove transactions: %w", err)
+	}
+	deleteTags := fmt.Sprintf(`DELETE FROM address_tags WHERE network = ? AND address IN (%s)`, placeholders)
+	if _, err := tx.Exec(deleteTags, args...); err != nil {
+		return fmt.Errorf("failed to remove tags: %w", err)
+	}
+	deleteArchive := fmt.Sprintf(`DELETE FROM transaction_archive WHERE network = ? AND address IN (%s)`, placeholders)
+	if _, err := tx.Exec(deleteArchive, args...); err != nil {
+		return fmt.Errorf("failed to remove archived transactions: %w", err)
+	}
+	return nil
+}
+
+// RemoveAddresses removes several addresses in one database transaction and returns the ones removed.
+// A soft removal archives the addresses and keeps their history; a hard removal deletes them and their transactions.
+func (r *SQLiteRepository) RemoveAddresses(addresses []string, soft bool) ([]string, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(addresses)), ",")
+	args := make([]interface{}, 0, len(addresses)+1)
+	args = append(args, r.network)
+	for _, address := range addresses {
+		args = append(args, address)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var query string
+	if soft {
+		query = fmt.Sprintf(`UPDATE addresses SET archived_at = ? WHERE archived_at IS NULL AND network = ? AND address IN (%s) RETURNING address`, placeholders)
+		args = append([]interface{}{time.Now().UTC()}, args...)
+	} else {
+		if err := deleteAddressData(tx, placeholders, args); err != nil {
+			return nil, err
+		}
+		query = fmt.Sprintf(`DELETE FROM addresses WHERE network = ? AND address IN (%s) RETURNING address`, placeholders)
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove addresses: %w", err)
+	}
+
+	var removed []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan removed address: %w", err)
+		}
+		removed = append(removed, address)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to remove addresses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit address removal: %w", err)
+	}
+
+	return removed, nil
+}
+
+// ArchiveInactiveAddresses soft-deletes addresses with a zero balance whose last activity
+// (newest transaction, or creation if there are none) is before inactiveSince
+func (r *SQLiteRepository) ArchiveInactiveAddresses(inactiveSince time.Time) ([]string, error) {
+	query := `
+	UPDATE addresses 
+	SET archived_at = ? 
+	WHERE archived_at IS NULL 
+	AND network = ? 
+	AND address IN (
+		SELECT a.address 
+		FROM addresses a 
+		LEFT JOIN transactions t ON t.address = a.address AND t.network = a.network 
+		WHERE a.network = ? 
+		GROUP BY a.address 
+		HAVING COALESCE(SUM(t.amount), 0) + a.archive_balance = 0 
+		AND COALESCE(MAX(t.timestamp), a.created_at) < ?
+	) 
+	RETURNING address`
+
+	rows, err := r.db.Query(query, time.Now().UTC(), r.network, r.network, inactiveSince.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive inactive addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var archived []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("failed to scan archived address: %w", err)
+		}
+		archived = append(archived, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to archive inactive addresses: %w", err)
+	}
+
+	return archived, nil
+}
+
+// RestoreAddress reverses the archiving of an address, keeping its stored history
+func (r *SQLiteRepository) RestoreAddress(address string) error {
+	query := `UPDATE addresses SET archived_at = NULL WHERE address = ? AND network = ? AND archived_at IS NOT NULL`
+	result, err := r.db.Exec(query, address, r.network)
+	if err != nil {
+		return fmt.Errorf("failed to restore address: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("archived %w: %s", ErrAddressNotFound, address)
+	}
+
+	return nil
+}
+
+// addressColumns lists the columns read by scanAddress, in order
+const addressColumns = `id, address, network, label, address_type, owned, ownership_verified_at, history_truncated, sync_enabled, priority, created_at, last_synced`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAddress reads an address row selected with addressColumns
+func scanAddress(row rowScanner) (*models.Address, error) {
 	var addr models.Address
-	var lastSynced sql.NullTime
-	
-	err := r.db.QueryRow(query, address).Scan(
-		&addr.ID, &addr.Address, &addr.Label, &addr.CreatedAt, &lastSynced,
+	var label, addressType sql.NullString
+	var verifiedAt, lastSynced sql.NullTime
+
+	err := row.Scan(
+		&addr.ID, &addr.Address, &addr.Network, &label, &addressType, &addr.Owned, &verifiedAt, &addr.HistoryTruncated, &addr.SyncEnabled, &addr.Priority, &addr.CreatedAt, &lastSynced,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("address not found: %s", address)
-		}
-		return nil, fmt.Errorf("failed to get address: %w", err)
+		return nil, err
 	}
 
+	addr.Label = label.String
+	addr.Type = addressType.String
+	addr.OwnershipVerifiedAt = nullTimePtr(verifiedAt)
 	if lastSynced.Valid {
 		addr.LastSynced = &lastSynced.Time
 	}
@@ -162,11 +688,30 @@ func (r *SQLiteRepository) GetAddress(address string) (*models.Address, error) {
 	return &addr, nil
 }
 
+// GetAddress retrieves a specific address from the read connection. An address the read connection
+// does not have yet is looked up on the primary, so one added moments ago is found while a replica lags.
+func (r *SQLiteRepository) GetAddress(address string) (*models.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE address = ? AND network = ? AND archived_at IS NULL`
+
+	addr, err := scanAddress(r.reads.QueryRow(query, address, r.network))
+	if err == sql.ErrNoRows && r.reads != r.db {
+		addr, err = scanAddress(r.db.QueryRow(query, address, r.network))
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+		}
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	return addr, nil
+}
+
 // GetAllAddresses retrieves all tracked addresses
 func (r *SQLiteRepository) GetAllAddresses() ([]models.Address, error) {
-	query := `SELECT id, address, label, created_at, last_synced FROM addresses ORDER BY created_at DESC`
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE network = ? AND archived_at IS NULL ORDER BY created_at DESC`
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.reads.Query(query, r.network)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get addresses: %w", err)
 	}
@@ -174,28 +719,135 @@ func (r *SQLiteRepository) GetAllAddresses() ([]models.Address, error) {
 
 	var addresses []models.Address
 	for rows.Next() {
-		var addr models.Address
-		var lastSynced sql.NullTime
-		
-		err := rows.Scan(&addr.ID, &addr.Address, &addr.Label, &addr.CreatedAt, &lastSynced)
+		addr, err := scanAddress(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan address: %w", err)
 		}
 
-		if lastSynced.Valid {
-			addr.LastSynced = &lastSynced.Time
+		addresses = append(addresses, *addr)
+	}
+
+	return addresses, nil
+}
+
+// UpdateAddress applies a partial update to an address's user-editable fields
+func (r *SQLiteRepository) UpdateAddress(address string, update models.UpdateAddressRequest) (*models.Address, error) {
+	var sets []string
+	var args []interface{}
+	if update.Label != nil {
+		sets = append(sets, "label = ?")
+		args = append(args, *update.Label)
+	}
+	if update.Owned != nil {
+		sets = append(sets, "owned = ?")
+		args = append(args, *update.Owned)
+		// Disowning an address withdraws its proof of ownership
+		if !*update.Owned {
+			sets = append(sets, "ownership_verified_at = NULL")
 		}
+	}
+	if update.Priority != nil {
+		sets = append(sets, "priority = ?")
+		args = append(args, *update.Priority)
+	}
 
-		addresses = append(addresses, addr)
+	if len(sets) > 0 {
+		query := fmt.Sprintf(`UPDATE addresses SET %s WHERE address = ? AND network = ? AND archived_at IS NULL`, strings.Join(sets, ", "))
+		result, err := r.db.Exec(query, append(args, address, r.network)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update address: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+		}
 	}
 
-	return addresses, nil
+	return r.GetAddress(address)
+}
+
+// MarkOwnershipVerified flags an address as owned after its ownership was proven at verifiedAt
+func (r *SQLiteRepository) MarkOwnershipVerified(address string, verifiedAt time.Time) (*models.Address, error) {
+	query := `UPDATE addresses SET owned = 1, ownership_verified_at = ? WHERE address = ? AND network = ? AND archived_at IS NULL`
+	result, err := r.db.Exec(query, verifiedAt.UTC(), address, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark ownership verified: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	return r.GetAddress(address)
+}
+
+// SetSyncEnabled pauses or resumes syncing of a tracked address
+func (r *SQLiteRepository) SetSyncEnabled(address string, enabled bool) (*models.Address, error) {
+	query := `UPDATE addresses SET sync_enabled = ? WHERE address = ? AND network = ? AND archived_at IS NULL`
+	result, err := r.db.Exec(query, enabled, address, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sync enabled: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	return r.GetAddress(address)
+}
+
+// ResetLastSynced clears the last sync time of a tracked address, or backdates it when syncTime is set.
+// Like UpdateLastSynced it stores UTC, so sync times compare correctly as stored.
+func (r *SQLiteRepository) ResetLastSynced(address string, syncTime *time.Time) error {
+	var lastSynced any
+	if syncTime != nil {
+		lastSynced = syncTime.UTC()
+	}
+
+	query := `UPDATE addresses SET last_synced = ? WHERE address = ? AND network = ? AND archived_at IS NULL`
+	result, err := r.db.Exec(query, lastSynced, address, r.network)
+	if err != nil {
+		return fmt.Errorf("failed to reset last synced: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	return nil
+}
+
+// SetHistoryTruncated marks an address whose stored history no longer covers everything upstream
+func (r *SQLiteRepository) SetHistoryTruncated(address string) error {
+	query := `UPDATE addresses SET history_truncated = 1 WHERE address = ? AND network = ?`
+	_, err := r.db.Exec(query, address, r.network)
+	if err != nil {
+		return fmt.Errorf("failed to mark history truncated: %w", err)
+	}
+	return nil
 }
 
 // UpdateLastSynced updates the last sync time for an address
 func (r *SQLiteRepository) UpdateLastSynced(address string, syncTime time.Time) error {
-	query := `UPDATE addresses SET last_synced = ? WHERE address = ?`
-	_, err := r.db.Exec(query, syncTime, address)
+	query := `UPDATE addresses SET last_synced = ? WHERE address = ? AND network = ?`
+	_, err := r.db.Exec(query, syncTime.UTC(), address, r.network)
 	if err != nil {
 		return fmt.Errorf("failed to update last synced: %w", err)
 	}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestRemoveAddressDeletesHistory(t *testing.T) {
+	testCases := []struct {
+		name   string
+		remove func(repo *SQLiteRepository, address string) error
+	}{
+		{"single", func(repo *SQLiteRepository, address string) error {
+			return repo.RemoveAddress(address)
+		}},
+		{"batch", func(repo *SQLiteRepository, address string) error {
+			_, err := repo.RemoveAddresses([]string{address}, false)
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+			if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+				t.Fatalf("AddAddress failed: %v", err)
+			}
+
+			txs := makeTransactions(address, 3)
+			txs[0].Confirmations = 200
+			if err := repo.SaveTransactions(txs); err != nil {
+				t.Fatalf("SaveTransactions failed: %v", err)
+			}
+			if _, err := repo.ArchiveOldTransactions(txs[1].Timestamp); err != nil {
+				t.Fatalf("ArchiveOldTransactions failed: %v", err)
+			}
+			if _, err := repo.TagAddresses("cold", []string{address}); err != nil {
+				t.Fatalf("TagAddresses failed: %v", err)
+			}
+
+			if err := tc.remove(repo, address); err != nil {
+				t.Fatalf("Remove failed: %v", err)
+			}
+
+			report, err := repo.CheckIntegrity()
+			if err != nil {
+				t.Fatalf("CheckIntegrity failed: %v", err)
+			}
+			if report.OrphanedTransactions != 0 {
+				t.Errorf("Expected no orphaned transactions after removal, got %d", report.OrphanedTransactions)
+			}
+
+			// Re-adding the address starts from an empty history
+			if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+				t.Fatalf("AddAddress failed: %v", err)
+			}
+			full := models.TransactionFilter{Address: address, IncludeArchive: true}
+			if count, err := repo.CountTransactions(full); err != nil || count != 0 {
+				t.Errorf("Expected no transactions after re-adding, got %d (err %v)", count, err)
+			}
+			if tagged, err := repo.GetTaggedAddresses("cold"); err != nil || len(tagged) != 0 {
+				t.Errorf("Expected no tagged addresses after re-adding, got %+v (err %v)", tagged, err)
+			}
+			balance, err := repo.CalculateBalance(address)
+			if err != nil || balance.TotalBalance != 0 {
+				t.Errorf("Expected a zero balance after re-adding, got %+v (err %v)", balance, err)
+			}
+		})
+	}
+}
+
+func TestRemoveUnknownAddress(t *testing.T) {
+	repo := newTestRepository(t)
+	if err := repo.RemoveAddress("missing"); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("RemoveAddress(missing) = %v; want ErrAddressNotFound", err)
+	}
+}
+
+// saveSpentHistory stores a receive and a spend that leave address with a zero balance
+func saveSpentHistory(t *testing.T, repo *SQLiteRepository, address string) {
+	t.Helper()
+
+	txs := makeTransactions(address, 2)
+	txs[1].Amount = -txs[0].Amount
+	txs[1].Type = "sent"
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+}
+
+func TestArchiveInactiveAddresses(t *testing.T) {
+	repo := newTestRepository(t)
+	trackAddresses(t, repo, "spent", "funded", "recent", "empty-old", "empty-new")
+
+	saveSpentHistory(t, repo, "spent")
+	if err := repo.SaveTransactions(makeTransactions("funded", 1)); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+	recent := makeTransactions("recent", 2)
+	recent[1].Amount = -recent[0].Amount
+	for i := range recent {
+		recent[i].Timestamp = time.Now().UTC()
+	}
+	if err := repo.SaveTransactions(recent); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+	if _, err := repo.db.Exec(`UPDATE addresses SET created_at = ? WHERE address = 'empty-old'`, time.Now().UTC().Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Backdating created_at failed: %v", err)
+	}
+
+	archived, err := repo.ArchiveInactiveAddresses(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("ArchiveInactiveAddresses failed: %v", err)
+	}
+	slices.Sort(archived)
+	if want := []string{"empty-old", "spent"}; !slices.Equal(archived, want) {
+		t.Errorf("Archived %v, want %v", archived, want)
+	}
+
+	addresses, err := repo.GetAllAddresses()
+	if err != nil {
+		t.Fatalf("GetAllAddresses failed: %v", err)
+	}
+	var tracked []string
+	for _, addr := range addresses {
+		tracked = append(tracked, addr.Address)
+	}
+	slices.Sort(tracked)
+	if want := []string{"empty-new", "funded", "recent"}; !slices.Equal(tracked, want) {
+		t.Errorf("Tracked %v after archiving, want %v", tracked, want)
+	}
+
+	// Archived addresses are not archived again
+	if again, err := repo.ArchiveInactiveAddresses(time.Now().Add(-24 * time.Hour)); err != nil || len(again) != 0 {
+		t.Errorf("Expected nothing left to archive, got %v (err %v)", again, err)
+	}
+}
+
+func TestArchivedAddressRoundTrips(t *testing.T) {
+	testCases := []struct {
+		name   string
+		revive func(repo *SQLiteRepository, address string) error
+	}{
+		{"restore", func(repo *SQLiteRepository, address string) error {
+			return repo.RestoreAddress(address)
+		}},
+		{"re-add", func(repo *SQLiteRepository, address string) error {
+			_, err := repo.AddAddress(models.Address{Address: address, Label: "revived"})
+			return err
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+			original, err := repo.AddAddress(models.Address{Address: address})
+			if err != nil {
+				t.Fatalf("AddAddress failed: %v", err)
+			}
+			saveSpentHistory(t, repo, address)
+
+			archived, err := repo.ArchiveInactiveAddresses(time.Now())
+			if err != nil || !slices.Equal(archived, []string{address}) {
+				t.Fatalf("ArchiveInactiveAddresses = %v (err %v); want %s", archived, err, address)
+			}
+			if _, err := repo.GetAddress(address); !errors.Is(err, ErrAddressNotFound) {
+				t.Errorf("Expected an archived address to be hidden, got %v", err)
+			}
+
+			if err := tc.revive(repo, address); err != nil {
+				t.Fatalf("Reviving failed: %v", err)
+			}
+
+			revived, err := repo.GetAddress(address)
+			if err != nil {
+				t.Fatalf("GetAddress after reviving failed: %v", err)
+			}
+			if !revived.CreatedAt.Equal(original.CreatedAt) {
+				t.Errorf("Expected created_at %v to be kept, got %v", original.CreatedAt, revived.CreatedAt)
+			}
+			if count, err := repo.CountTransactions(models.TransactionFilter{Address: address}); err != nil || count != 2 {
+				t.Errorf("Expected the 2 stored transactions to be kept, got %d (err %v)", count, err)
+			}
+		})
+	}
+
+	repo := newTestRepository(t)
+	trackAddresses(t, repo, "active")
+	if err := repo.RestoreAddress("active"); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected restoring an address that is not archived to fail with ErrAddressNotFound, got %v", err)
+	}
+}
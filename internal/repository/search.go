@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// likeEscaper escapes LIKE wildcards so a search fragment matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchAddresses returns tracked addresses whose label or address contains fragment, ignoring ASCII
+// case, ordered by label and then address. The limit is clamped like other listings.
+func (r *SQLiteRepository) SearchAddresses(fragment string, limit int) ([]models.Address, error) {
+	limit, _ = r.clampPage(limit, 0)
+	pattern := "%" + likeEscaper.Replace(fragment) + "%"
+	query := `SELECT ` + addressColumns + ` FROM addresses 
+	WHERE network = ? AND archived_at IS NULL AND (label LIKE ? ESCAPE '\' OR address LIKE ? ESCAPE '\') 
+	ORDER BY label, address LIMIT ?`
+
+	rows, err := r.reads.Query(query, r.network, pattern, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+	for rows.Next() {
+		addr, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, *addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search addresses: %w", err)
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// SnapshotStore persists balance snapshots as a time series.
+// It is separate from Repository so history can live in a dedicated store.
+type SnapshotStore interface {
+	SaveSnapshot(snapshot models.BalanceSnapshot) error
+	LatestSnapshot(address string) (*models.BalanceSnapshot, error)
+	GetSnapshots(address string, from, to time.Time) ([]models.BalanceSnapshot, error)
+	Close() error
+}
+
+// SQLiteSnapshotStore implements SnapshotStore with its own SQLite table
+type SQLiteSnapshotStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteSnapshotStore opens a snapshot store; dbPath may be the main database or a separate file
+func NewSQLiteSnapshotStore(dbPath string) (*SQLiteSnapshotStore, error) {
+	db, err := openSQLite(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database: %w", err)
+	}
+
+	store := &SQLiteSnapshotStore{db: db}
+	if err := store.createTables(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create snapshot tables: %w", err)
+	}
+
+	return store, nil
+}
+
+// createTables creates the snapshot table and its time-ordered index
+func (s *SQLiteSnapshotStore) createTables() error {
+	snapshotTable := `
+	CREATE TABLE IF NOT EXISTS balance_snapshots (
+		address TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		confirmed_balance INTEGER NOT NULL,
+		unconfirmed_balance INTEGER NOT NULL,
+		total_balance INTEGER NOT NULL,
+		PRIMARY KEY(address, timestamp)
+	) WITHOUT ROWID;`
+
+	if _, err := s.db.Exec(snapshotTable); err != nil {
+		return fmt.Errorf("failed to create balance_snapshots table: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSnapshot stores a snapshot, replacing any existing one for the same address and time
+func (s *SQLiteSnapshotStore) SaveSnapshot(snapshot models.BalanceSnapshot) error {
+	query := `
+	INSERT OR REPLACE INTO balance_snapshots 
+	(address, timestamp, confirmed_balance, unconfirmed_balance, total_balance) 
+	VALUES (?, ?, ?, ?, ?)`
+
+	_, err := s.db.Exec(query,
+		snapshot.Address, snapshot.Timestamp.UTC(), snapshot.ConfirmedBalance,
+		snapshot.UnconfirmedBalance, snapshot.TotalBalance,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LatestSnapshot returns the newest snapshot for an address, or nil if there is none
+func (s *SQLiteSnapshotStore) LatestSnapshot(address string) (*models.BalanceSnapshot, error) {
+	query := `
+	SELECT address, timestamp, confirmed_balance, unconfirmed_balance, total_balance 
+	FROM balance_snapshots 
+	WHERE address = ? 
+	ORDER BY timestamp DESC 
+	LIMIT 1`
+
+	var snapshot models.BalanceSnapshot
+	err := s.db.QueryRow(query, address).Scan(
+		&snapshot.Address, &snapshot.Timestamp, &snapshot.ConfirmedBalance,
+		&snapshot.UnconfirmedBalance, &snapshot.TotalBalance,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// GetSnapshots returns an address's snapshots between from and to, oldest first
+func (s *SQLiteSnapshotStore) GetSnapshots(address string, from, to time.Time) ([]models.BalanceSnapshot, error) {
+	query := `
+	SELECT address, timestamp, confirmed_balance, unconfirmed_balance, total_balance 
+	FROM balance_snapshots 
+	WHERE address = ? AND timestamp >= ? AND timestamp <= ? 
+	ORDER BY timestamp ASC`
+
+	rows, err := s.db.Query(query, address, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []models.BalanceSnapshot{}
+	for rows.Next() {
+		var snapshot models.BalanceSnapshot
+		err := rows.Scan(
+			&snapshot.Address, &snapshot.Timestamp, &snapshot.ConfirmedBalance,
+			&snapshot.UnconfirmedBalance, &snapshot.TotalBalance,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// Close closes the snapshot database connection
+func (s *SQLiteSnapshotStore) Close() error {
+	return s.db.Close()
+}
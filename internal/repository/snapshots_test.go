@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestSQLiteSnapshotStore(t *testing.T) {
+	store, err := NewSQLiteSnapshotStore(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotStore failed: %v", err)
+	}
+	defer store.Close()
+
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	other := "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd"
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	if latest, err := store.LatestSnapshot(address); err != nil || latest != nil {
+		t.Fatalf("LatestSnapshot with no history = %+v (err %v); want nil", latest, err)
+	}
+
+	// Saved out of order, with one snapshot replaced and one for another address in range
+	for _, snapshot := range []models.BalanceSnapshot{
+		{Address: address, Timestamp: day(3), ConfirmedBalance: 3000, TotalBalance: 3000},
+		{Address: address, Timestamp: day(1), ConfirmedBalance: 1000, TotalBalance: 1000},
+		{Address: address, Timestamp: day(5), ConfirmedBalance: 5000, TotalBalance: 5000},
+		{Address: address, Timestamp: day(2), ConfirmedBalance: 2000, TotalBalance: 2000},
+		{Address: address, Timestamp: day(2), ConfirmedBalance: 2000, UnconfirmedBalance: 500, TotalBalance: 2500},
+		{Address: other, Timestamp: day(2), ConfirmedBalance: 9000, TotalBalance: 9000},
+	} {
+		if err := store.SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("SaveSnapshot failed: %v", err)
+		}
+	}
+
+	latest, err := store.LatestSnapshot(address)
+	if err != nil {
+		t.Fatalf("LatestSnapshot failed: %v", err)
+	}
+	if latest == nil || !latest.Timestamp.Equal(day(5)) || latest.TotalBalance != 5000 {
+		t.Errorf("LatestSnapshot = %+v; want the day 5 snapshot", latest)
+	}
+
+	// The range is inclusive at both ends and ordered oldest first
+	snapshots, err := store.GetSnapshots(address, day(2), day(3))
+	if err != nil {
+		t.Fatalf("GetSnapshots failed: %v", err)
+	}
+	want := []int64{2500, 3000}
+	if len(snapshots) != len(want) {
+		t.Fatalf("Expected %d snapshots, got %+v", len(want), snapshots)
+	}
+	for i, snapshot := range snapshots {
+		if snapshot.Address != address || snapshot.TotalBalance != want[i] {
+			t.Errorf("Snapshot %d = %+v; want total %d for %s", i, snapshot, want[i], address)
+		}
+	}
+
+	if snapshots, err := store.GetSnapshots(address, day(6), day(9)); err != nil || len(snapshots) != 0 {
+		t.Errorf("GetSnapshots outside the history = %+v (err %v); want none", snapshots, err)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SQL for the hot queries that are prepared once per repository
+const (
+	saveTransactionSQL = `
+	INSERT OR REPLACE INTO transactions 
+	(hash, address, amount, confirmations, block_height, timestamp, type, coinbase, network) 
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Point lookups also check the archive; their arguments are passed once per table
+	transactionExistsSQL = `
+	SELECT (SELECT COUNT(*) FROM transactions WHERE hash = ? AND address = ? AND network = ?) 
+		+ (SELECT COUNT(*) FROM transaction_archive WHERE hash = ? AND address = ? AND network = ?)`
+
+	getTransactionSQL = `
+	SELECT ` + transactionColumns + ` 
+	FROM transactions 
+	WHERE hash = ? AND address = ? AND network = ? 
+	UNION ALL 
+	SELECT ` + transactionColumns + ` 
+	FROM transaction_archive 
+	WHERE hash = ? AND address = ? AND network = ? 
+	LIMIT 1`
+
+	transactionsByAddressSQL = `
+	SELECT ` + transactionColumns + ` 
+	FROM transactions 
+	WHERE address = ? AND network = ? 
+	ORDER BY timestamp DESC, block_height DESC, id DESC 
+	LIMIT ? OFFSET ?`
+)
+
+// statements holds the prepared statements reused across calls
+type statements struct {
+	saveTransaction       *sql.Stmt
+	transactionExists     *sql.Stmt
+	getTransaction        *sql.Stmt
+	transactionsByAddress *sql.Stmt
+}
+
+// prepareStatements prepares the hot queries; it must run after the schema exists
+func (r *SQLiteRepository) prepareStatements() error {
+	targets := []struct {
+		db    *sql.DB
+		stmt  **sql.Stmt
+		query string
+	}{
+		{r.db, &r.stmts.saveTransaction, saveTransactionSQL},
+		{r.db, &r.stmts.transactionExists, transactionExistsSQL},
+		{r.reads, &r.stmts.getTransaction, getTransactionSQL},
+		{r.reads, &r.stmts.transactionsByAddress, transactionsByAddressSQL},
+	}
+
+	for _, target := range targets {
+		stmt, err := target.db.Prepare(target.query)
+		if err != nil {
+			r.closeStatements()
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		*target.stmt = stmt
+	}
+
+	return nil
+}
+
+// closeStatements releases every prepared statement
+func (r *SQLiteRepository) closeStatements() {
+	for _, stmt := range []*sql.Stmt{
+		r.stmts.saveTransaction,
+		r.stmts.transactionExists,
+		r.stmts.getTransaction,
+		r.stmts.transactionsByAddress,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// addressStatsColumns are the provider statistics cached on the addresses table
+var addressStatsColumns = []struct {
+	name       string
+	definition string
+}{
+	{"transaction_count", "INTEGER"},
+	{"output_count", "INTEGER"},
+	{"unspent_output_count", "INTEGER"},
+	{"first_seen_receiving", "DATETIME"},
+	{"last_seen_receiving", "DATETIME"},
+	{"first_seen_spending", "DATETIME"},
+	{"last_seen_spending", "DATETIME"},
+	{"provider_fiat_value", "REAL"},
+	{"stats_updated_at", "DATETIME"},
+}
+
+// UpdateAddressStats stores the provider statistics for an address
+func (r *SQLiteRepository) UpdateAddressStats(address string, stats models.AddressStats) error {
+	query := `
+	UPDATE addresses SET 
+		transaction_count = ?, output_count = ?, unspent_output_count = ?,
+		first_seen_receiving = ?, last_seen_receiving = ?,
+		first_seen_spending = ?, last_seen_spending = ?,
+		provider_fiat_value = ?, stats_updated_at = ?
+	WHERE address = ? AND network = ?`
+
+	_, err := r.db.Exec(query,
+		stats.TransactionCount, stats.OutputCount, stats.UnspentOutputCount,
+		stats.FirstSeenReceiving, stats.LastSeenReceiving,
+		stats.FirstSeenSpending, stats.LastSeenSpending,
+		stats.ProviderFiatValue, stats.UpdatedAt, address, r.network,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update address stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetAddressStats retrieves the stored provider statistics, returning nil if none were fetched yet
+func (r *SQLiteRepository) GetAddressStats(address string) (*models.AddressStats, error) {
+	query := `
+	SELECT transaction_count, output_count, unspent_output_count,
+		first_seen_receiving, last_seen_receiving,
+		first_seen_spending, last_seen_spending,
+		provider_fiat_value, stats_updated_at
+	FROM addresses 
+	WHERE address = ? AND network = ? AND archived_at IS NULL`
+
+	var (
+		stats                                      models.AddressStats
+		txCount, outputCount, unspentCount         sql.NullInt64
+		firstRecv, lastRecv, firstSpent, lastSpent sql.NullTime
+		fiatValue                                  sql.NullFloat64
+		updatedAt                                  sql.NullTime
+	)
+	err := r.reads.QueryRow(query, address, r.network).Scan(
+		&txCount, &outputCount, &unspentCount,
+		&firstRecv, &lastRecv, &firstSpent, &lastSpent,
+		&fiatValue, &updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+		}
+		return nil, fmt.Errorf("failed to get address stats: %w", err)
+	}
+
+	if !updatedAt.Valid {
+		return nil, nil
+	}
+
+	stats.TransactionCount = int(txCount.Int64)
+	stats.OutputCount = int(outputCount.Int64)
+	stats.UnspentOutputCount = int(unspentCount.Int64)
+	stats.FirstSeenReceiving = nullTimePtr(firstRecv)
+	stats.LastSeenReceiving = nullTimePtr(lastRecv)
+	stats.FirstSeenSpending = nullTimePtr(firstSpent)
+	stats.LastSeenSpending = nullTimePtr(lastSpent)
+	if fiatValue.Valid {
+		stats.ProviderFiatValue = &fiatValue.Float64
+	}
+	stats.UpdatedAt = updatedAt.Time
+
+	return &stats, nil
+}
+
+// nullTimePtr converts a nullable column into an optional time
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// syncLogTable holds one row per address sync. Rows outlive the address they describe and are
+// only removed by PruneSyncLog.
+const syncLogTable = `
+	CREATE TABLE IF NOT EXISTS sync_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		address TEXT NOT NULL,
+		network TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		new_tx INTEGER NOT NULL DEFAULT 0,
+		updated_tx INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		error TEXT
+	);`
+
+// syncLogIndexes serve the per-address timeline and pruning by age
+var syncLogIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_sync_log_address ON sync_log(address, network, started_at);",
+	"CREATE INDEX IF NOT EXISTS idx_sync_log_started_at ON sync_log(network, started_at);",
+}
+
+// AddSyncLogEntry records the outcome of one address sync
+func (r *SQLiteRepository) AddSyncLogEntry(entry models.SyncLogEntry) error {
+	query := `
+	INSERT INTO sync_log (address, network, started_at, finished_at, new_tx, updated_tx, status, error) 
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var syncErr sql.NullString
+	if entry.Error != "" {
+		syncErr = sql.NullString{String: entry.Error, Valid: true}
+	}
+
+	_, err := r.db.Exec(query,
+		entry.Address, r.network, entry.StartedAt.UTC(), entry.FinishedAt.UTC(),
+		entry.NewTransactions, entry.UpdatedTransactions, entry.Status, syncErr,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add sync log entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetSyncLog returns up to limit sync log entries for an address, newest first
+func (r *SQLiteRepository) GetSyncLog(address string, limit int) ([]models.SyncLogEntry, error) {
+	query := `
+	SELECT address, started_at, finished_at, new_tx, updated_tx, status, error 
+	FROM sync_log 
+	WHERE address = ? AND network = ? 
+	ORDER BY started_at DESC, id DESC 
+	LIMIT ?`
+
+	rows, err := r.reads.Query(query, address, r.network, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.SyncLogEntry{}
+	for rows.Next() {
+		var entry models.SyncLogEntry
+		var syncErr sql.NullString
+		err := rows.Scan(
+			&entry.Address, &entry.StartedAt, &entry.FinishedAt,
+			&entry.NewTransactions, &entry.UpdatedTransactions, &entry.Status, &syncErr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync log entry: %w", err)
+		}
+		entry.Error = syncErr.String
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sync log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PruneSyncLog deletes sync log entries started before cutoff and returns how many were removed
+func (r *SQLiteRepository) PruneSyncLog(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM sync_log WHERE network = ? AND started_at < ?`, r.network, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune sync log: %w", err)
+	}
+
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return pruned, nil
+}
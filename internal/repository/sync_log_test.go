@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestSyncLog(t *testing.T) {
+	repo := newTestRepository(t)
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []models.SyncLogEntry{
+		{Address: addr, StartedAt: start, FinishedAt: start.Add(time.Second), NewTransactions: 3, Status: models.SyncStatusSynced},
+		{Address: addr, StartedAt: start.Add(time.Hour), FinishedAt: start.Add(time.Hour + time.Second), Status: models.SyncStatusFailed, Error: "provider unavailable"},
+		{Address: addr, StartedAt: start.Add(2 * time.Hour), FinishedAt: start.Add(2*time.Hour + time.Second), UpdatedTransactions: 1, Status: models.SyncStatusSynced},
+		{Address: "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", StartedAt: start, FinishedAt: start, Status: models.SyncStatusSynced},
+	}
+	for _, entry := range entries {
+		if err := repo.AddSyncLogEntry(entry); err != nil {
+			t.Fatalf("AddSyncLogEntry failed: %v", err)
+		}
+	}
+
+	got, err := repo.GetSyncLog(addr, 10)
+	if err != nil {
+		t.Fatalf("GetSyncLog failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("GetSyncLog returned %d entries; want 3", len(got))
+	}
+	for i, want := range []models.SyncLogEntry{entries[2], entries[1], entries[0]} {
+		if got[i].Address != want.Address || !got[i].StartedAt.Equal(want.StartedAt) || !got[i].FinishedAt.Equal(want.FinishedAt) ||
+			got[i].NewTransactions != want.NewTransactions || got[i].UpdatedTransactions != want.UpdatedTransactions ||
+			got[i].Status != want.Status || got[i].Error != want.Error {
+			t.Errorf("entry %d = %+v; want %+v", i, got[i], want)
+		}
+	}
+
+	if got, err := repo.GetSyncLog(addr, 1); err != nil || len(got) != 1 {
+		t.Fatalf("GetSyncLog with limit 1 = %d entries, %v; want 1", len(got), err)
+	}
+
+	pruned, err := repo.PruneSyncLog(start.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("PruneSyncLog failed: %v", err)
+	}
+	if pruned != 3 {
+		t.Errorf("PruneSyncLog removed %d entries; want 3", pruned)
+	}
+	if got, err := repo.GetSyncLog(addr, 10); err != nil || len(got) != 1 || got[0].Status != models.SyncStatusSynced {
+		t.Errorf("GetSyncLog after pruning = %+v, %v; want only the newest entry", got, err)
+	}
+}
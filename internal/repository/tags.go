@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// addressTagsTable holds one row per tag assigned to an address. Rows are removed together with
+// the address when it is deleted; archived addresses keep their tags.
+const addressTagsTable = `
+	CREATE TABLE IF NOT EXISTS address_tags (
+		tag TEXT NOT NULL,
+		address TEXT NOT NULL,
+		network TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(tag, address, network)
+	);`
+
+// addressTagsIndexes serve looking up the tags of an address
+var addressTagsIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_address_tags_address ON address_tags(address, network);",
+}
+
+// TagAddresses assigns tag to the tracked addresses among addresses in one database transaction.
+// Addresses that already carry the tag are reported as unchanged.
+func (r *SQLiteRepository) TagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error) {
+	insert := `
+	INSERT INTO address_tags (tag, address, network)
+	SELECT ?, address, network FROM addresses
+	WHERE archived_at IS NULL AND network = ? AND address IN (%s)
+	ON CONFLICT DO NOTHING
+	RETURNING address`
+
+	return r.assignTag(tag, addresses, insert, []interface{}{tag, r.network})
+}
+
+// UntagAddresses removes tag from the tracked addresses among addresses in one database transaction.
+// Tracked addresses without the tag are reported as unchanged.
+func (r *SQLiteRepository) UntagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error) {
+	remove := `
+	DELETE FROM address_tags
+	WHERE tag = ? AND network = ? AND address IN (%s)
+	RETURNING address`
+
+	return r.assignTag(tag, addresses, remove, []interface{}{tag, r.network})
+}
+
+// assignTag runs a set-based tag change and sorts addresses into updated, unchanged and not found.
+// query must take the address list as its last placeholder group and return the changed addresses.
+func (r *SQLiteRepository) assignTag(tag string, addresses []string, query string, args []interface{}) (*models.TagAssignmentResult, error) {
+	result := &models.TagAssignmentResult{
+		Tag:       tag,
+		Updated:   []string{},
+		Unchanged: []string{},
+		NotFound:  []string{},
+	}
+	if len(addresses) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(addresses)), ",")
+	addressArgs := make([]interface{}, len(addresses))
+	for i, address := range addresses {
+		addressArgs[i] = address
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tracked, err := queryAddresses(tx,
+		fmt.Sprintf(`SELECT address FROM addresses WHERE archived_at IS NULL AND network = ? AND address IN (%s)`, placeholders),
+		append([]interface{}{r.network}, addressArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up addresses: %w", err)
+	}
+
+	changed, err := queryAddresses(tx, fmt.Sprintf(query, placeholders), append(args, addressArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tag %s: %w", tag, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tag update: %w", err)
+	}
+
+	for _, address := range addresses {
+		switch {
+		case changed[address]:
+			result.Updated = append(result.Updated, address)
+		case tracked[address]:
+			result.Unchanged = append(result.Unchanged, address)
+		default:
+			result.NotFound = append(result.NotFound, address)
+		}
+	}
+
+	return result, nil
+}
+
+// queryAddresses runs a query returning a single address column and collects the addresses into a set
+func queryAddresses(tx *sql.Tx, query string, args ...interface{}) (map[string]bool, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]bool)
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		addresses[address] = true
+	}
+
+	return addresses, rows.Err()
+}
+
+// GetTaggedAddresses returns the tracked addresses carrying tag, newest first
+func (r *SQLiteRepository) GetTaggedAddresses(tag string) ([]models.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses
+	WHERE network = ? AND archived_at IS NULL
+	AND address IN (SELECT address FROM address_tags WHERE tag = ? AND network = ?)
+	ORDER BY created_at DESC`
+
+	rows, err := r.reads.Query(query, r.network, tag, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tagged addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.Address{}
+	for rows.Next() {
+		addr, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, *addr)
+	}
+
+	return addresses, rows.Err()
+}
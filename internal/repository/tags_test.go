@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+func TestBulkTagAssignment(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, address := range []string{"addr-a", "addr-b", "addr-c"} {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+
+	if _, err := repo.TagAddresses("cold-2024", []string{"addr-a"}); err != nil {
+		t.Fatalf("TagAddresses failed: %v", err)
+	}
+
+	result, err := repo.TagAddresses("cold-2024", []string{"addr-a", "addr-b", "addr-c", "missing"})
+	if err != nil {
+		t.Fatalf("TagAddresses failed: %v", err)
+	}
+	if !slices.Equal(result.Updated, []string{"addr-b", "addr-c"}) || !slices.Equal(result.Unchanged, []string{"addr-a"}) || !slices.Equal(result.NotFound, []string{"missing"}) {
+		t.Errorf("Unexpected tag result: %+v", result)
+	}
+
+	result, err = repo.UntagAddresses("cold-2024", []string{"addr-b", "addr-b2", "addr-c"})
+	if err != nil {
+		t.Fatalf("UntagAddresses failed: %v", err)
+	}
+	if !slices.Equal(result.Updated, []string{"addr-b", "addr-c"}) || len(result.Unchanged) != 0 || !slices.Equal(result.NotFound, []string{"addr-b2"}) {
+		t.Errorf("Unexpected untag result: %+v", result)
+	}
+
+	tagged, err := repo.GetTaggedAddresses("cold-2024")
+	if err != nil {
+		t.Fatalf("GetTaggedAddresses failed: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].Address != "addr-a" {
+		t.Errorf("Expected only addr-a to keep the tag, got %+v", tagged)
+	}
+
+	// Deleting an address drops its tags, so re-adding it starts untagged
+	if err := repo.RemoveAddress("addr-a"); err != nil {
+		t.Fatalf("RemoveAddress failed: %v", err)
+	}
+	if _, err := repo.AddAddress(models.Address{Address: "addr-a"}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	if tagged, err := repo.GetTaggedAddresses("cold-2024"); err != nil || len(tagged) != 0 {
+		t.Errorf("Expected no tagged addresses after deletion, got %+v (err %v)", tagged, err)
+	}
+}
@@ -1,21 +1,34 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/ihladush/bitcoin/internal/models"
 )
 
+// transactionColumns lists the columns read by scanTransaction, in order
+const transactionColumns = `id, hash, address, amount, confirmations, block_height, timestamp, type, coinbase`
+
+// scanTransaction reads a transaction row selected with transactionColumns
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	var tx models.Transaction
+	err := row.Scan(
+		&tx.ID, &tx.Hash, &tx.Address, &tx.Amount,
+		&tx.Confirmations, &tx.BlockHeight, &tx.Timestamp, &tx.Type, &tx.Coinbase,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
 // SaveTransaction saves a transaction to the database
 func (r *SQLiteRepository) SaveTransaction(tx *models.Transaction) error {
-	query := `
-	INSERT OR REPLACE INTO transactions 
-	(hash, address, amount, confirmations, block_height, timestamp, type) 
-	VALUES (?, ?, ?, ?, ?, ?, ?)`
-
-	_, err := r.db.Exec(query,
+	_, err := r.stmts.saveTransaction.Exec(
 		tx.Hash, tx.Address, tx.Amount, tx.Confirmations,
-		tx.BlockHeight, tx.Timestamp, tx.Type,
+		tx.BlockHeight, tx.Timestamp, tx.Type, tx.Coinbase, r.network,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save transaction: %w", err)
@@ -24,43 +37,284 @@ func (r *SQLiteRepository) SaveTransaction(tx *models.Transaction) error {
 	return nil
 }
 
-// GetTransactionsByAddress retrieves transactions for a specific address with pagination
+// SaveTransactions saves several transactions in one database transaction, reusing the prepared insert
+func (r *SQLiteRepository) SaveTransactions(txs []models.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	stmt := dbTx.Stmt(r.stmts.saveTransaction)
+	defer stmt.Close()
+
+	for _, tx := range txs {
+		_, err := stmt.Exec(
+			tx.Hash, tx.Address, tx.Amount, tx.Confirmations,
+			tx.BlockHeight, tx.Timestamp, tx.Type, tx.Coinbase, r.network,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save transaction %s: %w", tx.Hash, err)
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transactions: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactionsByAddress retrieves transactions for a specific address with pagination. The limit
+// is clamped to the repository's page bounds, so no caller can read an unbounded page.
 func (r *SQLiteRepository) GetTransactionsByAddress(address string, limit, offset int) ([]models.Transaction, error) {
+	limit, offset = r.clampPage(limit, offset)
+	rows, err := r.stmts.transactionsByAddress.Query(address, r.network, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, *tx)
+	}
+
+	return transactions, nil
+}
+
+// GetLatestTransaction returns the newest stored transaction for an address, or nil if it has none.
+// Ties are broken the same way as GetTransactionsByAddress.
+func (r *SQLiteRepository) GetLatestTransaction(address string) (*models.Transaction, error) {
+	tx, err := scanTransaction(r.stmts.transactionsByAddress.QueryRow(address, r.network, 1, 0))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// transactionSortColumns maps the sortable fields to their columns
+var transactionSortColumns = map[string]string{
+	models.TransactionSortTimestamp:   "timestamp",
+	models.TransactionSortAmount:      "amount",
+	models.TransactionSortBlockHeight: "block_height",
+}
+
+// QueryTransactions retrieves a page of transactions across all addresses matching the filter. Its
+// limit is clamped to the repository's page bounds like GetTransactionsByAddress; use
+// StreamTransactions to read beyond them.
+func (r *SQLiteRepository) QueryTransactions(filter models.TransactionFilter) ([]models.Transaction, error) {
+	filter.Limit, filter.Offset = r.clampPage(filter.Limit, filter.Offset)
+
+	var transactions []models.Transaction
+	err := r.StreamTransactions(filter, func(tx *models.Transaction) error {
+		transactions = append(transactions, *tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// StreamTransactions calls fn for each transaction matching the filter without buffering the result set.
+// A zero limit streams every match; callers bound exports themselves.
+func (r *SQLiteRepository) StreamTransactions(filter models.TransactionFilter, fn func(*models.Transaction) error) error {
+	query, args, err := buildTransactionQuery(r.network, filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.reads.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate transactions: %w", err)
+	}
+
+	return nil
+}
+
+// transactionSource returns the table a filter reads from: the transactions table, or the
+// transactions merged with the archive when the filter asks for the full history
+func transactionSource(filter models.TransactionFilter) string {
+	if filter.IncludeArchive {
+		return allTransactions
+	}
+	return "transactions"
+}
+
+// buildTransactionQuery translates a filter on the transactions of network into SQL
+func buildTransactionQuery(network string, filter models.TransactionFilter) (string, []interface{}, error) {
+	q := newQueryBuilder(`
+	SELECT ` + transactionColumns + ` 
+	FROM ` + transactionSource(filter))
+
+	filterTransactions(q, network, filter)
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = models.TransactionSortTimestamp
+	}
+	column, ok := transactionSortColumns[sortBy]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported sort field: %s", sortBy)
+	}
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+	q.order(fmt.Sprintf("%s %s, id %s", column, direction, direction))
+
+	q.page(filter.Limit, filter.Offset)
+
+	query, args := q.build()
+	return query, args, nil
+}
+
+// filterTransactions adds the conditions selecting the transactions matching filter
+func filterTransactions(q *queryBuilder, network string, filter models.TransactionFilter) {
+	q.where("network = ?", network)
+
+	if filter.Address != "" {
+		q.where("address = ?", filter.Address)
+	}
+	if filter.Hash != "" {
+		q.where("hash = ?", filter.Hash)
+	}
+	if filter.Type != "" {
+		q.where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		q.where("timestamp >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		q.where("timestamp <= ?", filter.To.UTC())
+	}
+	if filter.MinAmount != nil {
+		q.where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		q.where("amount <= ?", *filter.MaxAmount)
+	}
+}
+
+// CountTransactions returns how many transactions match the filter, ignoring its sorting and pagination
+func (r *SQLiteRepository) CountTransactions(filter models.TransactionFilter) (int, error) {
+	q := newQueryBuilder(`SELECT COUNT(*) FROM ` + transactionSource(filter))
+	filterTransactions(q, r.network, filter)
+	query, args := q.build()
+
+	var count int
+	if err := r.reads.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetTransaction retrieves a stored or archived transaction for an address, returning nil if it is not stored
+func (r *SQLiteRepository) GetTransaction(hash, address string) (*models.Transaction, error) {
+	tx, err := scanTransaction(r.stmts.getTransaction.QueryRow(hash, address, r.network, hash, address, r.network))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// GetExistingHashes returns the set of transaction hashes stored or archived for an address, so
+// archived transactions are not stored again by a sync
+func (r *SQLiteRepository) GetExistingHashes(address string) (map[string]bool, error) {
+	query := `
+	SELECT hash FROM transactions WHERE address = ? AND network = ? 
+	UNION ALL 
+	SELECT hash FROM transaction_archive WHERE address = ? AND network = ?`
+	rows, err := r.db.Query(query, address, r.network, address, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction hash: %w", err)
+		}
+		hashes[hash] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transaction hashes: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// GetNonFinalTransactions retrieves the transactions of an address that are unconfirmed or
+// have fewer than finalDepth confirmations relative to the chain tip
+func (r *SQLiteRepository) GetNonFinalTransactions(address string, finalDepth, tip int) ([]models.Transaction, error) {
 	query := `
-	SELECT id, hash, address, amount, confirmations, block_height, timestamp, type 
+	SELECT ` + transactionColumns + ` 
 	FROM transactions 
-	WHERE address = ? 
-	ORDER BY timestamp DESC 
-	LIMIT ? OFFSET ?`
+	WHERE address = ? AND network = ? AND (block_height = 0 OR ? - block_height + 1 < ?)`
 
-	rows, err := r.db.Query(query, address, limit, offset)
+	rows, err := r.db.Query(query, address, r.network, tip, finalDepth)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+		return nil, fmt.Errorf("failed to get non-final transactions: %w", err)
 	}
 	defer rows.Close()
 
 	var transactions []models.Transaction
 	for rows.Next() {
-		var tx models.Transaction
-		err := rows.Scan(
-			&tx.ID, &tx.Hash, &tx.Address, &tx.Amount,
-			&tx.Confirmations, &tx.BlockHeight, &tx.Timestamp, &tx.Type,
-		)
+		tx, err := scanTransaction(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
-		transactions = append(transactions, tx)
+		transactions = append(transactions, *tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transactions: %w", err)
 	}
 
 	return transactions, nil
 }
 
-// TransactionExists checks if a transaction already exists for an address
+// TransactionExists checks if a transaction is already stored or archived for an address
 func (r *SQLiteRepository) TransactionExists(hash, address string) (bool, error) {
-	query := `SELECT COUNT(*) FROM transactions WHERE hash = ? AND address = ?`
-	
 	var count int
-	err := r.db.QueryRow(query, hash, address).Scan(&count)
+	err := r.stmts.transactionExists.QueryRow(hash, address, r.network, hash, address, r.network).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check transaction existence: %w", err)
 	}
@@ -68,39 +322,154 @@ func (r *SQLiteRepository) TransactionExists(hash, address string) (bool, error)
 	return count > 0, nil
 }
 
-// GetBalance retrieves the calculated balance for an address
+// PruneTransactions moves all but the newest keep transactions of an address to the archive in one
+// database transaction and returns how many were moved. Archived amounts still count towards the
+// balance and archived hashes are not fetched as new again, so only confirmed transactions past
+// coinbase maturity are moved; newer-looking unconfirmed or immature rows stay until they settle.
+func (r *SQLiteRepository) PruneTransactions(address string, keep int) (int64, error) {
+	eligible := `address = ? AND network = ? AND block_height > 0 AND confirmations >= 1
+		AND (coinbase = 0 OR confirmations >= ?) AND id NOT IN (
+		SELECT id FROM transactions
+		WHERE address = ? AND network = ?
+		ORDER BY timestamp DESC, block_height DESC, id DESC
+		LIMIT ?
+	)`
+	args := []interface{}{address, r.network, models.CoinbaseMaturity, address, r.network, keep}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var amount, count int64
+	err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0), COUNT(*) FROM transactions WHERE `+eligible, args...).Scan(&amount, &count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum pruned transactions: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	copyRows := `
+	INSERT INTO transaction_archive (` + transactionColumns + `, network)
+	SELECT ` + transactionColumns + `, network FROM transactions WHERE ` + eligible
+	if _, err := tx.Exec(copyRows, args...); err != nil {
+		return 0, fmt.Errorf("failed to copy pruned transactions to the archive: %w", err)
+	}
+
+	addTotals := `
+	UPDATE addresses SET archive_balance = archive_balance + ?, archive_count = archive_count + ?
+	WHERE address = ? AND network = ?`
+	if _, err := tx.Exec(addTotals, amount, count, address, r.network); err != nil {
+		return 0, fmt.Errorf("failed to update archived totals: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM transactions WHERE `+eligible, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune transactions: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit pruned transactions: %w", err)
+	}
+
+	return pruned, nil
+}
+
+// GetMaxBlockHeight returns the highest block height among stored transactions, or 0 when none are mined
+func (r *SQLiteRepository) GetMaxBlockHeight() (int, error) {
+	var height int
+	err := r.reads.QueryRow(`SELECT COALESCE(MAX(block_height), 0) FROM transactions WHERE network = ?`, r.network).Scan(&height)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get max block height: %w", err)
+	}
+
+	return height, nil
+}
+
+// GetBalance retrieves the balance for an address, served from the balance cache when it is enabled
+// and populated, and calculated from transactions otherwise
 func (r *SQLiteRepository) GetBalance(address string) (*models.Balance, error) {
+	if r.balanceCache {
+		balance, err := r.cachedBalance(address)
+		if err != nil || balance != nil {
+			return balance, err
+		}
+	}
 	return r.CalculateBalance(address)
 }
 
-// CalculateBalance calculates the balance based on transactions
+// CalculateBalance calculates the balance based on transactions. Archived transactions contribute
+// their stored sum, and with a final depth configured so do final transactions; only the rest are aggregated.
+// It only reads, from the read connection: transactions become final when RefreshBalance settles them.
 func (r *SQLiteRepository) CalculateBalance(address string) (*models.Balance, error) {
+	return r.calculateBalance(r.reads, address)
+}
+
+// calculateBalance calculates the balance of an address from the given connection in one read transaction
+func (r *SQLiteRepository) calculateBalance(db *sql.DB, address string) (*models.Balance, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var finalBalance int64
+	nonFinal := ""
+	if r.finalDepth > 0 {
+		if finalBalance, err = r.finalBalance(tx, address); err != nil {
+			return nil, err
+		}
+		nonFinal = " AND final = 0"
+	}
+
 	// Calculate confirmed balance (transactions with confirmations >= 1)
 	confirmedQuery := `
 	SELECT COALESCE(SUM(amount), 0) 
 	FROM transactions 
-	WHERE address = ? AND confirmations >= 1`
+	WHERE address = ? AND network = ? AND confirmations >= 1` + nonFinal
 
 	// Calculate unconfirmed balance (transactions with confirmations = 0)
 	unconfirmedQuery := `
 	SELECT COALESCE(SUM(amount), 0) 
 	FROM transactions 
-	WHERE address = ? AND confirmations = 0`
+	WHERE address = ? AND network = ? AND confirmations = 0` + nonFinal
 
-	var confirmedBalance, unconfirmedBalance int64
+	// Immature coinbase rewards are part of the confirmed balance and reported alongside it
+	immatureQuery := `
+	SELECT COALESCE(SUM(amount), 0) 
+	FROM transactions 
+	WHERE address = ? AND network = ? AND coinbase = 1 AND confirmations >= 1 AND confirmations < ?` + nonFinal
 
-	err := r.db.QueryRow(confirmedQuery, address).Scan(&confirmedBalance)
+	var confirmedBalance, unconfirmedBalance, immatureBalance int64
+
+	err = tx.QueryRow(confirmedQuery, address, r.network).Scan(&confirmedBalance)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate confirmed balance: %w", err)
 	}
+	archived, err := r.archiveBalance(tx, address)
+	if err != nil {
+		return nil, err
+	}
+	confirmedBalance += finalBalance + archived
 
-	err = r.db.QueryRow(unconfirmedQuery, address).Scan(&unconfirmedBalance)
+	err = tx.QueryRow(unconfirmedQuery, address, r.network).Scan(&unconfirmedBalance)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate unconfirmed balance: %w", err)
 	}
 
+	err = tx.QueryRow(immatureQuery, address, r.network, models.CoinbaseMaturity).Scan(&immatureBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate immature balance: %w", err)
+	}
+
 	totalBalance := confirmedBalance + unconfirmedBalance
-	balanceBTC := float64(totalBalance) / 100000000 // Convert satoshis to BTC
+	balanceBTC := models.SatoshisToBTC(totalBalance)
 
 	return &models.Balance{
 		Address:            address,
@@ -108,10 +477,111 @@ func (r *SQLiteRepository) CalculateBalance(address string) (*models.Balance, er
 		UnconfirmedBalance: unconfirmedBalance,
 		TotalBalance:       totalBalance,
 		BalanceBTC:         balanceBTC,
+		ImmatureBalance:    immatureBalance,
 	}, nil
 }
 
-// Close closes the database connection
+// GetBalances returns the balance of every address with stored transactions. Cached balances are
+// used when balance caching is enabled; the rest are calculated in a single grouped query.
+func (r *SQLiteRepository) GetBalances() (map[string]*models.Balance, error) {
+	query := `
+	SELECT address, 
+		COALESCE(SUM(CASE WHEN confirmations >= 1 THEN amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN confirmations = 0 THEN amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN coinbase = 1 AND confirmations >= 1 AND confirmations < ? THEN amount ELSE 0 END), 0)
+	FROM transactions 
+	WHERE network = ? %s
+	GROUP BY address`
+
+	balances := make(map[string]*models.Balance)
+	uncached := ""
+	if r.balanceCache {
+		var err error
+		if balances, err = r.cachedBalances(); err != nil {
+			return nil, err
+		}
+		uncached = "AND address NOT IN (SELECT address FROM addresses WHERE network = transactions.network AND balance_updated_at IS NOT NULL)"
+	}
+
+	rows, err := r.reads.Query(fmt.Sprintf(query, uncached), models.CoinbaseMaturity, r.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var confirmedBalance, unconfirmedBalance, immatureBalance int64
+		if err := rows.Scan(&address, &confirmedBalance, &unconfirmedBalance, &immatureBalance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+
+		totalBalance := confirmedBalance + unconfirmedBalance
+		balances[address] = &models.Balance{
+			Address:            address,
+			ConfirmedBalance:   confirmedBalance,
+			UnconfirmedBalance: unconfirmedBalance,
+			TotalBalance:       totalBalance,
+			BalanceBTC:         models.SatoshisToBTC(totalBalance),
+			ImmatureBalance:    immatureBalance,
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate balances: %w", err)
+	}
+
+	if err := r.addArchiveBalances(balances); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// addArchiveBalances adds the archived sums of the addresses whose balances were calculated to their
+// confirmed balances, including addresses whose transactions are all archived
+func (r *SQLiteRepository) addArchiveBalances(balances map[string]*models.Balance) error {
+	query := `SELECT address, archive_balance FROM addresses WHERE network = ? AND archive_count > 0`
+	if r.balanceCache {
+		query += ` AND balance_updated_at IS NULL`
+	}
+
+	rows, err := r.reads.Query(query, r.network)
+	if err != nil {
+		return fmt.Errorf("failed to get archived balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var archived int64
+		if err := rows.Scan(&address, &archived); err != nil {
+			return fmt.Errorf("failed to scan archived balance: %w", err)
+		}
+
+		balance := balances[address]
+		if balance == nil {
+			balance = &models.Balance{Address: address}
+			balances[address] = balance
+		}
+		balance.ConfirmedBalance += archived
+		balance.TotalBalance += archived
+		balance.BalanceBTC = models.SatoshisToBTC(balance.TotalBalance)
+	}
+
+	return rows.Err()
+}
+
+// Close releases the prepared statements and closes the database connections
 func (r *SQLiteRepository) Close() error {
+	r.closeStatements()
+	return r.closeConnections()
+}
+
+// closeConnections closes the primary connection and the read connection when it is separate
+func (r *SQLiteRepository) closeConnections() error {
+	if r.reads != r.db {
+		r.reads.Close()
+	}
 	return r.db.Close()
 }
@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// newTestRepository opens a repository backed by a temporary database file
+func newTestRepository(tb testing.TB) *SQLiteRepository {
+	tb.Helper()
+
+	repo, err := NewSQLiteRepository(filepath.Join(tb.TempDir(), "test.db"))
+	if err != nil {
+		tb.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	tb.Cleanup(func() { repo.Close() })
+
+	return repo
+}
+
+// trackAddresses adds addresses so transactions can be saved for them
+func trackAddresses(tb testing.TB, repo *SQLiteRepository, addresses ...string) {
+	tb.Helper()
+
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address}); err != nil {
+			tb.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+}
+
+// makeTransactions builds n distinct transactions for an address
+func makeTransactions(address string, n int) []models.Transaction {
+	txs := make([]models.Transaction, n)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range txs {
+		txs[i] = models.Transaction{
+			Hash:          fmt.Sprintf("%064x", i),
+			Address:       address,
+			Amount:        int64(1000 + i),
+			Confirmations: 6,
+			BlockHeight:   800000 + i,
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+			Type:          "received",
+		}
+	}
+	return txs
+}
+
+func TestSaveTransactions(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address)
+
+	txs := makeTransactions(address, 10)
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	// Saving again replaces rather than duplicates
+	txs[0].Confirmations = 7
+	if err := repo.SaveTransactions(txs[:1]); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	stored, err := repo.GetTransactionsByAddress(address, 100, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionsByAddress failed: %v", err)
+	}
+	if len(stored) != len(txs) {
+		t.Errorf("Expected %d stored transactions, got %d", len(txs), len(stored))
+	}
+
+	updated, err := repo.GetTransaction(txs[0].Hash, address)
+	if err != nil || updated == nil || updated.Confirmations != 7 {
+		t.Errorf("Expected the re-saved transaction to be updated, got %+v (%v)", updated, err)
+	}
+}
+
+func TestGetTransactionsByAddressStableOrder(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address)
+
+	// Transactions mined together share a timestamp; two of them also share a block
+	txs := makeTransactions(address, 6)
+	for i := range txs {
+		txs[i].Timestamp = txs[0].Timestamp
+		txs[i].BlockHeight = 800000 + i/2
+	}
+	if err := repo.SaveTransactions([]models.Transaction{txs[3], txs[0], txs[5], txs[1], txs[4], txs[2]}); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	all, err := repo.GetTransactionsByAddress(address, 100, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionsByAddress failed: %v", err)
+	}
+	for i := 1; i < len(all); i++ {
+		prev, cur := all[i-1], all[i]
+		if cur.BlockHeight > prev.BlockHeight || (cur.BlockHeight == prev.BlockHeight && cur.ID > prev.ID) {
+			t.Fatalf("transactions %d and %d are not ordered by block height then id descending", i-1, i)
+		}
+	}
+
+	// Paging through repeatedly yields the same sequence with no duplicates or gaps
+	for run := 0; run < 3; run++ {
+		var paged []models.Transaction
+		for offset := 0; offset < len(txs); offset += 2 {
+			page, err := repo.GetTransactionsByAddress(address, 2, offset)
+			if err != nil {
+				t.Fatalf("GetTransactionsByAddress failed: %v", err)
+			}
+			paged = append(paged, page...)
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("run %d: paged %d transactions; want %d", run, len(paged), len(all))
+		}
+		for i := range paged {
+			if paged[i].Hash != all[i].Hash {
+				t.Errorf("run %d: position %d has %s; want %s", run, i, paged[i].Hash, all[i].Hash)
+			}
+		}
+	}
+}
+
+func TestGetLatestTransaction(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address, "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd")
+
+	latest, err := repo.GetLatestTransaction(address)
+	if err != nil {
+		t.Fatalf("GetLatestTransaction failed: %v", err)
+	}
+	if latest != nil {
+		t.Fatalf("GetLatestTransaction with no history = %+v; want nil", latest)
+	}
+
+	txs := makeTransactions(address, 3)
+	if err := repo.SaveTransactions([]models.Transaction{txs[2], txs[0], txs[1]}); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+	if err := repo.SaveTransactions(makeTransactions("3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", 5)[4:]); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	latest, err = repo.GetLatestTransaction(address)
+	if err != nil {
+		t.Fatalf("GetLatestTransaction failed: %v", err)
+	}
+	if latest == nil || latest.Hash != txs[2].Hash {
+		t.Fatalf("GetLatestTransaction = %+v; want %s", latest, txs[2].Hash)
+	}
+}
+
+func TestGetExistingHashes(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address, "3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd")
+
+	txs := makeTransactions(address, 3)
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+	if err := repo.SaveTransactions(makeTransactions("3E8ociqZa9mZUSwGdSmAEMAoAxBK3FNDcd", 5)[3:]); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	hashes, err := repo.GetExistingHashes(address)
+	if err != nil {
+		t.Fatalf("GetExistingHashes failed: %v", err)
+	}
+	if len(hashes) != len(txs) {
+		t.Errorf("Expected %d hashes, got %d", len(txs), len(hashes))
+	}
+	for _, tx := range txs {
+		if !hashes[tx.Hash] {
+			t.Errorf("Expected hash %s in the set", tx.Hash)
+		}
+	}
+}
+
+func TestImmatureCoinbaseBalance(t *testing.T) {
+	repo := newTestRepository(t)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	trackAddresses(t, repo, address)
+
+	txs := makeTransactions(address, 3)
+	txs[0].Coinbase = true // 6 confirmations, immature
+	txs[1].Coinbase = true
+	txs[1].Confirmations = 100 // mature
+	if err := repo.SaveTransactions(txs); err != nil {
+		t.Fatalf("SaveTransactions failed: %v", err)
+	}
+
+	balance, err := repo.GetBalance(address)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.ImmatureBalance != txs[0].Amount {
+		t.Errorf("Expected immature balance %d, got %d", txs[0].Amount, balance.ImmatureBalance)
+	}
+
+	balances, err := repo.GetBalances()
+	if err != nil {
+		t.Fatalf("GetBalances failed: %v", err)
+	}
+	if *balances[address] != *balance {
+		t.Errorf("GetBalances = %+v; want %+v", balances[address], balance)
+	}
+
+	stored, err := repo.GetTransaction(txs[0].Hash, address)
+	if err != nil || stored == nil || !stored.Coinbase {
+		t.Errorf("Expected the coinbase flag to be stored, got %+v (%v)", stored, err)
+	}
+}
+
+func BenchmarkSaveTransactionOneByOne(b *testing.B) {
+	txs := makeTransactions("bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", 1000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		repo := newTestRepository(b)
+		b.StartTimer()
+
+		for j := range txs {
+			if err := repo.SaveTransaction(&txs[j]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSaveTransactionsBatch(b *testing.B) {
+	txs := makeTransactions("bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5", 1000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		repo := newTestRepository(b)
+		b.StartTimer()
+
+		if err := repo.SaveTransactions(txs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransactionExistsUnprepared(b *testing.B) {
+	repo := newTestRepository(b)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	txs := makeTransactions(address, 1000)
+	if err := repo.SaveTransactions(txs); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var count int
+		if err := repo.db.QueryRow(transactionExistsSQL, txs[i%len(txs)].Hash, address).Scan(&count); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransactionExistsPrepared(b *testing.B) {
+	repo := newTestRepository(b)
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	txs := makeTransactions(address, 1000)
+	if err := repo.SaveTransactions(txs); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.TransactionExists(txs[i%len(txs)].Hash, address); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestReAddArchivedAddressHistory(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"), WithPreservedHistory(preserve))
+		if err != nil {
+			t.Fatalf("NewSQLiteRepository failed: %v", err)
+		}
+		defer repo.Close()
+
+		original, err := repo.AddAddress(models.Address{Address: "addr"})
+		if err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+		if err := repo.SaveTransactions(makeTransactions("addr", 3)); err != nil {
+			t.Fatalf("SaveTransactions failed: %v", err)
+		}
+		if _, err := repo.RemoveAddresses([]string{"addr"}, true); err != nil {
+			t.Fatalf("RemoveAddresses failed: %v", err)
+		}
+		// created_at has second precision, so make a reset observable
+		if _, err := repo.db.Exec(`UPDATE addresses SET created_at = ? WHERE address = 'addr'`, original.CreatedAt.Add(-time.Hour)); err != nil {
+			t.Fatalf("Backdating created_at failed: %v", err)
+		}
+
+		revived, err := repo.AddAddress(models.Address{Address: "addr"})
+		if err != nil {
+			t.Fatalf("AddAddress of an archived address failed: %v", err)
+		}
+
+		count, err := repo.CountTransactions(models.TransactionFilter{Address: "addr"})
+		if err != nil {
+			t.Fatalf("CountTransactions failed: %v", err)
+		}
+		wantCount := 0
+		if preserve {
+			wantCount = 3
+		}
+		if count != wantCount {
+			t.Errorf("preserve=%v: expected %d transactions after re-adding, got %d", preserve, wantCount, count)
+		}
+		if kept := revived.CreatedAt.Before(original.CreatedAt); kept != preserve {
+			t.Errorf("preserve=%v: created_at %v, originally %v", preserve, revived.CreatedAt, original.CreatedAt)
+		}
+
+		balance, err := repo.GetBalance("addr")
+		if err != nil {
+			t.Fatalf("GetBalance failed: %v", err)
+		}
+		wantBalance := int64(0)
+		if preserve {
+			wantBalance = 1000 + 1001 + 1002
+		}
+		if balance.TotalBalance != wantBalance {
+			t.Errorf("preserve=%v: unexpected balance %+v", preserve, balance)
+		}
+	}
+}
+
+func TestGetBalancesMatchesGetBalance(t *testing.T) {
+	repo := newTestRepository(t)
+	trackAddresses(t, repo, "confirmed", "mixed", "coinbase", "empty")
+
+	mixed := makeTransactions("mixed", 3)
+	mixed[1].Amount = -500
+	mixed[1].Type = "sent"
+	mixed[2].Confirmations = 0
+	coinbase := makeTransactions("coinbase", 2)
+	coinbase[0].Coinbase = true
+	coinbase[0].Confirmations = 10
+	for _, txs := range [][]models.Transaction{makeTransactions("confirmed", 2), mixed, coinbase} {
+		if err := repo.SaveTransactions(txs); err != nil {
+			t.Fatalf("SaveTransactions failed: %v", err)
+		}
+	}
+
+	balances, err := repo.GetBalances()
+	if err != nil {
+		t.Fatalf("GetBalances failed: %v", err)
+	}
+	if len(balances) != 3 || balances["empty"] != nil {
+		t.Errorf("Expected balances for the 3 addresses with transactions only, got %v", balances)
+	}
+
+	for _, address := range []string{"confirmed", "mixed", "coinbase"} {
+		want, err := repo.CalculateBalance(address)
+		if err != nil {
+			t.Fatalf("CalculateBalance failed: %v", err)
+		}
+		if got := balances[address]; got == nil || *got != *want {
+			t.Errorf("GetBalances[%s] = %+v; want %+v", address, got, want)
+		}
+	}
+	if got := balances["mixed"]; got.ConfirmedBalance != 500 || got.UnconfirmedBalance != 1002 {
+		t.Errorf("Expected confirmed and unconfirmed amounts to be split, got %+v", got)
+	}
+	if got := balances["coinbase"]; got.ImmatureBalance != 1000 {
+		t.Errorf("Expected the immature coinbase reward to be reported, got %+v", got)
+	}
+}
@@ -0,0 +1,61 @@
+// Package requestid carries the ID of the HTTP request being served through contexts, so log lines
+// written by the handlers, the service, the provider clients and the repository can be correlated.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// DefaultHeader is the header a request ID is read from and echoed in unless configured otherwise
+const DefaultHeader = "X-Request-ID"
+
+// maxLength bounds caller-supplied IDs so they cannot flood the logs
+const maxLength = 128
+
+// key is the context key of the request ID
+type key struct{}
+
+// NewContext returns ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or ""
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// New generates a random request ID
+func New() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// Valid reports whether a caller-supplied ID is short and made of characters that are safe to log
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Prefix returns "[request <id>] " for contexts carrying a request ID, and "" otherwise, for
+// prepending to log lines
+func Prefix(ctx context.Context) string {
+	if id := FromContext(ctx); id != "" {
+		return "[request " + id + "] "
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextRoundTrip(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("Expected no ID in a bare context, got %q", id)
+	}
+	if prefix := Prefix(context.Background()); prefix != "" {
+		t.Errorf("Expected no prefix without an ID, got %q", prefix)
+	}
+
+	ctx := NewContext(context.Background(), "abc-123")
+	if id := FromContext(ctx); id != "abc-123" {
+		t.Errorf("FromContext = %q; want abc-123", id)
+	}
+	if prefix := Prefix(ctx); prefix != "[request abc-123] " {
+		t.Errorf("Prefix = %q", prefix)
+	}
+}
+
+func TestValid(t *testing.T) {
+	testCases := map[string]bool{
+		"":                       false,
+		New():                    true,
+		"3f2a-req_1.retry:2":     true,
+		"has space":              false,
+		"line\nbreak":            false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+	}
+
+	for id, want := range testCases {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v; want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/notify"
+)
+
+// defaultFailureThreshold is how many consecutive full sync runs may fail before sync is degraded
+const defaultFailureThreshold = 3
+
+// syncHealth counts consecutive failed full sync runs and notifies when they cross the threshold
+type syncHealth struct {
+	mu          sync.Mutex
+	threshold   int
+	notifier    notify.Notifier
+	consecutive int
+	lastError   string
+}
+
+// record updates the failure streak with the outcome of a full sync run, notifying when sync
+// becomes degraded and when it recovers
+func (h *syncHealth) record(runErr error) {
+	h.mu.Lock()
+	wasDegraded := h.degraded()
+	if runErr != nil {
+		h.consecutive++
+		h.lastError = runErr.Error()
+	} else {
+		h.consecutive = 0
+		h.lastError = ""
+	}
+	isDegraded := h.degraded()
+	consecutive := h.consecutive
+	h.mu.Unlock()
+
+	var n notify.Notification
+	switch {
+	case isDegraded && !wasDegraded:
+		n = notify.Notification{
+			Event:   notify.EventSyncDegraded,
+			Message: fmt.Sprintf("%d consecutive sync runs failed, last error: %v", consecutive, runErr),
+		}
+	case wasDegraded && !isDegraded:
+		n = notify.Notification{Event: notify.EventSyncRecovered, Message: "sync run succeeded after repeated failures"}
+	default:
+		return
+	}
+
+	if h.notifier == nil {
+		return
+	}
+	n.Time = time.Now().UTC()
+	// The run's context may be what failed it, so the notification gets its own
+	if err := h.notifier.Notify(context.Background(), n); err != nil {
+		fmt.Printf("Warning: failed to send %s notification: %v\n", n.Event, err)
+	}
+}
+
+// degraded reports whether the failure streak has reached the threshold; callers hold mu
+func (h *syncHealth) degraded() bool {
+	return h.threshold > 0 && h.consecutive >= h.threshold
+}
+
+// SyncHealth reports the consecutive full sync failures and whether they make sync degraded
+func (s *BitcoinService) SyncHealth() models.SyncHealth {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	return models.SyncHealth{
+		ConsecutiveFailures: s.health.consecutive,
+		Degraded:            s.health.degraded(),
+		LastError:           s.health.lastError,
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/tracing"
+)
+
+// typeBackfillBatchSize is how many addresses the type backfill reads per query
+const typeBackfillBatchSize = 500
+
+// BackfillAddressTypes detects the type of every stored address, archived ones included, from the
+// address string and stores it where it differs. Addresses are walked in ID order and each update
+// stands alone, so an interrupted run can simply be started again; a complete run changes nothing.
+func (s *BitcoinService) BackfillAddressTypes(ctx context.Context) (result *models.TypeBackfillResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.BackfillAddressTypes")
+	defer func() { tracing.End(span, err) }()
+
+	result = &models.TypeBackfillResult{}
+	afterID := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		batch, err := traceRepo(ctx, s.tracer, "ListAddressesAfter", func() ([]models.Address, error) {
+			return s.repo.ListAddressesAfter(afterID, typeBackfillBatchSize)
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to get addresses: %w", err)
+		}
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		for _, addr := range batch {
+			afterID = addr.ID
+			result.Checked++
+
+			detected := address.DetectType(addr.Address)
+			if detected == address.TypeUnknown {
+				result.Unknown++
+				continue
+			}
+
+			updated, err := s.repo.SetAddressType(addr.Address, string(detected))
+			if err != nil {
+				return result, fmt.Errorf("failed to update type of %s: %w", addr.Address, err)
+			}
+			if updated {
+				result.Updated++
+			} else {
+				result.Unchanged++
+			}
+		}
+	}
+}
@@ -2,33 +2,368 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ihladush/bitcoin/internal/address"
 	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/metrics"
 	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/notify"
 	"github.com/ihladush/bitcoin/internal/repository"
+	"github.com/ihladush/bitcoin/internal/requestid"
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BitcoinService handles business logic for Bitcoin tracking
 type BitcoinService struct {
 	repo   repository.Repository
 	client clients.BitcoinClient
+
+	network      address.Network
+	allowedTypes []address.Type
+	cleanupAfter time.Duration
+	archiveAfter time.Duration
+	limiter      *syncLimiter
+	snapshots    repository.SnapshotStore
+	broadcaster  *balanceBroadcaster
+	maxHistory   int
+	finalDepth   int
+	// confirmedDepth is the confirmation count past which a transaction's depth no longer matters to sync
+	confirmedDepth int
+
+	excludeImmature bool
+	initialSync     InitialSyncMode
+	labelTemplate   LabelTemplate
+	fees            clients.FeeEstimator
+	tipCache        chainTipCache
+	syncs           syncCoalescer
+	health          syncHealth
+	confirmations   confirmationAlerts
+	balanceGauges   *metrics.AddressBalances
+	tracer          trace.Tracer
+	recalculation   recalculationJob
+
+	// lifecycle bounds the work requests leave running in the background; background counts it
+	lifecycle  context.Context
+	background sync.WaitGroup
+
+	insertBatchSize  int
+	syncLogRetention time.Duration
+	maxExportRows    int
+
+	syncRunTimeout    time.Duration
+	syncFailureBudget time.Duration
+	syncBatchSize     int
+}
+
+// InitialSyncMode controls how AddAddress performs the first sync of a new address
+type InitialSyncMode string
+
+// Supported initial sync modes
+const (
+	InitialSyncBlocking   InitialSyncMode = "sync"
+	InitialSyncBackground InitialSyncMode = "async"
+)
+
+// ParseInitialSyncMode converts a configuration value into an InitialSyncMode
+func ParseInitialSyncMode(value string) (InitialSyncMode, error) {
+	switch mode := InitialSyncMode(value); mode {
+	case "":
+		return InitialSyncBlocking, nil
+	case InitialSyncBlocking, InitialSyncBackground:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown initial sync mode: %q", value)
+	}
+}
+
+// ErrHistoryDisabled is returned when balance history is requested without a snapshot store
+var ErrHistoryDisabled = errors.New("balance history is not enabled")
+
+// ErrFeesDisabled is returned when fee estimates are requested without a fee source
+var ErrFeesDisabled = errors.New("fee estimates are not enabled")
+
+// ErrInvalidSignature is returned when a signed message does not prove ownership of an address
+var ErrInvalidSignature = errors.New("ownership not verified")
+
+// ErrInvalidChallenge is returned when a signed message is not an unexpired, unused challenge issued for the address
+var ErrInvalidChallenge = errors.New("unknown, expired or already used ownership challenge")
+
+// OwnershipChallengeTTL is how long an ownership challenge can be signed and verified after it is issued
+const OwnershipChallengeTTL = 10 * time.Minute
+
+// ErrImportUnsupported is returned when the provider cannot map raw transaction JSON
+var ErrImportUnsupported = errors.New("provider does not support transaction import")
+
+// ErrInvalidImport is returned when imported transaction JSON cannot be mapped or breaks the client contract
+var ErrInvalidImport = errors.New("invalid transaction import")
+
+// ErrCleanupDisabled is returned when a cleanup is requested without an inactivity period
+var ErrCleanupDisabled = errors.New("inactive address cleanup is not configured")
+
+// ErrNetworkNotServed is returned when a request names a network other than the configured one
+var ErrNetworkNotServed = errors.New("network is not served by this instance")
+
+// ErrAddressNotFound is returned when an address is not tracked
+var ErrAddressNotFound = repository.ErrAddressNotFound
+
+// ErrBalanceUnavailable is returned when a tracked address's balance cannot be computed
+var ErrBalanceUnavailable = errors.New("failed to compute balance")
+
+// ErrProviderUnavailable is returned when a live request to the blockchain data provider fails
+var ErrProviderUnavailable = errors.New("provider request failed")
+
+// ErrRateLimited is returned when the provider rate-limits a request
+var ErrRateLimited = clients.ErrRateLimited
+
+// RateLimitError carries the provider's retry hint and remaining quota; use errors.As to read it
+type RateLimitError = clients.RateLimitError
+
+// ErrArchiveDisabled is returned when transactions are archived without an age threshold
+var ErrArchiveDisabled = errors.New("transaction archiving is not configured")
+
+// ErrInvalidPriority is returned for sync priorities outside models.MinSyncPriority to models.MaxSyncPriority
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// ErrExportTooLarge is returned when a transaction export would exceed the configured row cap
+var ErrExportTooLarge = errors.New("export too large")
+
+// Option configures optional BitcoinService behavior
+type Option func(*BitcoinService)
+
+// WithAllowedAddressTypes restricts AddAddress to the given address types
+func WithAllowedAddressTypes(types ...address.Type) Option {
+	return func(s *BitcoinService) {
+		s.allowedTypes = types
+	}
+}
+
+// WithInactiveCleanup enables archiving of empty addresses that have had no activity for the given period
+func WithInactiveCleanup(after time.Duration) Option {
+	return func(s *BitcoinService) {
+		s.cleanupAfter = after
+	}
+}
+
+// WithTransactionArchive enables moving deeply confirmed transactions older than after to the archive
+func WithTransactionArchive(after time.Duration) Option {
+	return func(s *BitcoinService) {
+		s.archiveAfter = after
+	}
+}
+
+// WithSyncLimits caps how many provider calls sync may run at once and how many it may start per second.
+// The limits are shared by every sync path, so overlapping manual and background syncs stay within them.
+func WithSyncLimits(concurrency int, ratePerSecond float64) Option {
+	return func(s *BitcoinService) {
+		s.limiter = newSyncLimiter(concurrency, ratePerSecond)
+	}
+}
+
+// WithSyncBudget bounds a full sync run: it stops once the run takes longer than timeout or once
+// failed address syncs have used more than failureBudget in total. Zero disables either bound.
+func WithSyncBudget(timeout, failureBudget time.Duration) Option {
+	return func(s *BitcoinService) {
+		s.syncRunTimeout = timeout
+		s.syncFailureBudget = failureBudget
+	}
+}
+
+// WithSyncBatchSize limits a full sync run to the n addresses that most need syncing, ranked by
+// priority and staleness. Zero syncs every stale address in each run.
+func WithSyncBatchSize(n int) Option {
+	return func(s *BitcoinService) {
+		s.syncBatchSize = n
+	}
+}
+
+// WithInsertBatchSize sets how many transactions sync writes per database transaction
+func WithInsertBatchSize(n int) Option {
+	return func(s *BitcoinService) {
+		if n > 0 {
+			s.insertBatchSize = n
+		}
+	}
+}
+
+// WithMaxExportRows caps how many transactions a single export may stream; zero allows any size
+func WithMaxExportRows(n int) Option {
+	return func(s *BitcoinService) {
+		s.maxExportRows = n
+	}
+}
+
+// WithLabelTemplate labels addresses added without a label using template
+func WithLabelTemplate(template LabelTemplate) Option {
+	return func(s *BitcoinService) {
+		s.labelTemplate = template
+	}
+}
+
+// WithSyncCooldown lets manual sync requests reuse the result of a sync of the same address that
+// finished less than d ago; syncs still running are always shared
+func WithSyncCooldown(d time.Duration) Option {
+	return func(s *BitcoinService) {
+		s.syncs.cooldown = d
+	}
+}
+
+// WithSyncLogRetention prunes sync log entries older than d; zero keeps them forever
+func WithSyncLogRetention(d time.Duration) Option {
+	return func(s *BitcoinService) {
+		s.syncLogRetention = d
+	}
+}
+
+// WithNetwork sets the network tracked addresses must belong to
+func WithNetwork(network address.Network) Option {
+	return func(s *BitcoinService) {
+		s.network = network
+	}
+}
+
+// WithTracer records spans for service operations and the provider and repository calls they make
+func WithTracer(tracer trace.Tracer) Option {
+	return func(s *BitcoinService) {
+		if tracer != nil {
+			s.tracer = tracer
+		}
+	}
+}
+
+// WithSnapshotStore records a balance snapshot whenever a sync changes an address's balance
+func WithSnapshotStore(store repository.SnapshotStore) Option {
+	return func(s *BitcoinService) {
+		s.snapshots = store
+	}
+}
+
+// WithMaxHistory keeps only the newest n transactions per address; zero keeps everything
+func WithMaxHistory(n int) Option {
+	return func(s *BitcoinService) {
+		s.maxHistory = n
+	}
+}
+
+// WithFinalDepth treats transactions with at least depth confirmations as immutable during sync; zero refreshes everything
+func WithFinalDepth(depth int) Option {
+	return func(s *BitcoinService) {
+		s.finalDepth = depth
+	}
+}
+
+// WithStatusThresholds sets the confirmed depth past which syncs stop refreshing a transaction whose
+// only change is its confirmation count; deeper thresholds of the alerts, final depth and coinbase maturity still apply
+func WithStatusThresholds(thresholds models.StatusThresholds) Option {
+	return func(s *BitcoinService) {
+		s.confirmedDepth = thresholds.Confirmed
+	}
+}
+
+// WithImmatureCoinbaseExcluded leaves coinbase rewards that have not reached maturity out of confirmed and total balances
+func WithImmatureCoinbaseExcluded(exclude bool) Option {
+	return func(s *BitcoinService) {
+		s.excludeImmature = exclude
+	}
+}
+
+// WithInitialSyncMode sets whether AddAddress waits for the first sync of a new address or runs it in the background
+func WithInitialSyncMode(mode InitialSyncMode) Option {
+	return func(s *BitcoinService) {
+		s.initialSync = mode
+	}
+}
+
+// WithFeeEstimator sets the source of current fee rates
+func WithFeeEstimator(fees clients.FeeEstimator) Option {
+	return func(s *BitcoinService) {
+		s.fees = fees
+	}
+}
+
+// WithConfirmationAlerts notifies when a stored transaction reaches target confirmations during a sync;
+// a target of zero disables the notifications
+func WithConfirmationAlerts(target int, notifier notify.Notifier) Option {
+	return func(s *BitcoinService) {
+		s.confirmations.target = target
+		s.confirmations.notifier = notifier
+	}
+}
+
+// WithNotificationBatching delivers the confirmation notifications of a sync run together, in
+// batches of at most maxSize, once the run ends; zero delivers each notification on its own
+func WithNotificationBatching(maxSize int) Option {
+	return func(s *BitcoinService) {
+		s.confirmations.batchSize = maxSize
+	}
+}
+
+// WithFailureAlerts marks sync degraded and notifies after threshold consecutive failed full sync runs;
+// a threshold of zero disables alerting
+func WithFailureAlerts(threshold int, notifier notify.Notifier) Option {
+	return func(s *BitcoinService) {
+		s.health.threshold = threshold
+		s.health.notifier = notifier
+	}
+}
+
+// WithBalanceGauges publishes each address's balance to gauges after every sync
+func WithBalanceGauges(gauges *metrics.AddressBalances) Option {
+	return func(s *BitcoinService) {
+		s.balanceGauges = gauges
+	}
+}
+
+// WithLifecycle runs the work requests leave running in the background under ctx, so cancelling
+// it on shutdown stops that work
+func WithLifecycle(ctx context.Context) Option {
+	return func(s *BitcoinService) {
+		s.lifecycle = ctx
+	}
 }
 
 // NewBitcoinService creates a new Bitcoin service
-func NewBitcoinService(repo repository.Repository, client clients.BitcoinClient) *BitcoinService {
-	return &BitcoinService{
-		repo:   repo,
-		client: client,
+func NewBitcoinService(repo repository.Repository, client clients.BitcoinClient, opts ...Option) *BitcoinService {
+	s := &BitcoinService{
+		repo:            repo,
+		client:          client,
+		network:         address.Mainnet,
+		limiter:         newSyncLimiter(defaultSyncConcurrency, 0),
+		insertBatchSize: defaultInsertBatchSize,
+		broadcaster:     newBalanceBroadcaster(),
+		confirmedDepth:  models.DefaultStatusThresholds.Confirmed,
+		initialSync:     InitialSyncBlocking,
+		health:          syncHealth{threshold: defaultFailureThreshold, notifier: notify.LogNotifier{}},
+		tracer:          tracing.NoopTracer(),
+		lifecycle:       context.Background(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// AddAddress adds a new Bitcoin address for tracking
-func (s *BitcoinService) AddAddress(address, label string) (*models.Address, error) {
-	// Validate address format
-	if !s.client.IsValidAddress(address) {
-		return nil, fmt.Errorf("invalid Bitcoin address: %s", address)
+// AddAddress adds a new Bitcoin address for tracking and runs its initial sync.
+// In blocking mode a successful sync is reflected in the returned address's LastSynced.
+func (s *BitcoinService) AddAddress(ctx context.Context, req models.AddAddressRequest) (*models.Address, error) {
+	address := req.Address
+
+	if err := s.CheckNetwork(req.Network); err != nil {
+		return nil, err
+	}
+
+	// Validate address format and network before any provider call
+	addrType, err := s.validateAddress(address)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if address already exists
@@ -37,57 +372,407 @@ func (s *BitcoinService) AddAddress(address, label string) (*models.Address, err
 		return nil, fmt.Errorf("address already being tracked: %s", address)
 	}
 
+	label := req.Label
+	if label == "" {
+		if label, err = s.defaultLabel(address, addrType); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add address to repository
-	addr, err := s.repo.AddAddress(address, label)
+	addr, err := s.repo.AddAddress(models.Address{
+		Address: address,
+		Label:   label,
+		Type:    string(addrType),
+		Owned:   req.Owned,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to add address: %w", err)
 	}
 
 	// Perform initial sync
-	if err := s.SyncAddress(address); err != nil {
-		// Log the error but don't fail the add operation
-		fmt.Printf("Warning: initial sync failed for address %s: %v\n", address, err)
+	if s.initialSync == InitialSyncBackground {
+		// The sync outlives the request but keeps its request ID for logging, and stops on shutdown
+		s.goBackground(ctx, func(ctx context.Context) { s.initialSyncAddress(ctx, address) })
+		return addr, nil
+	}
+	if s.initialSyncAddress(ctx, address) {
+		if synced, err := s.repo.GetAddress(address); err == nil {
+			addr = synced
+		}
 	}
 
 	return addr, nil
 }
 
-// RemoveAddress removes a Bitcoin address from tracking
-func (s *BitcoinService) RemoveAddress(address string) error {
-	return s.repo.RemoveAddress(address)
+// goBackground runs work in a goroutine that outlives the request ctx but keeps its values, such as the
+// request ID for logging, and is cancelled with the service lifecycle
+func (s *BitcoinService) goBackground(ctx context.Context, work func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(s.lifecycle, cancel)
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer cancel()
+		defer stop()
+		work(ctx)
+	}()
+}
+
+// Wait blocks until the work requests left running in the background has stopped
+func (s *BitcoinService) Wait() {
+	s.background.Wait()
+}
+
+// initialSyncAddress runs the first sync of a new address, reporting whether it succeeded
+func (s *BitcoinService) initialSyncAddress(ctx context.Context, address string) bool {
+	if err := s.SyncAddress(ctx, address); err != nil {
+		// Log the error but don't fail the add operation
+		fmt.Printf("%sWarning: initial sync failed for address %s: %v\n", requestid.Prefix(ctx), address, err)
+		return false
+	}
+	return true
+}
+
+// AddAddresses adds each requested address independently and reports a result per entry
+func (s *BitcoinService) AddAddresses(ctx context.Context, reqs []models.AddAddressRequest) []models.BulkResult {
+	results := make([]models.BulkResult, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+
+	for i, req := range reqs {
+		result := models.BulkResult{Index: i, Address: req.Address, Status: models.BulkStatusFailed}
+
+		switch {
+		case req.Address == "":
+			result.Error = "address is required"
+		case seen[req.Address]:
+			result.Error = "duplicate address in request"
+		default:
+			seen[req.Address] = true
+			if _, err := s.AddAddress(ctx, req); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Status = models.BulkStatusCreated
+			}
+		}
+
+		results[i] = result
+	}
+
+	return results
+}
+
+// CheckNetwork accepts an empty network, meaning the configured one, or the configured network itself.
+// Each instance talks to a single provider network, so addresses of other networks are tracked by
+// instances configured for them, which may share the same database.
+func (s *BitcoinService) CheckNetwork(network string) error {
+	if network == "" {
+		return nil
+	}
+	if parsed, err := address.ParseNetwork(network); err != nil || parsed != s.network {
+		return fmt.Errorf("%w: %q (configured network is %s)", ErrNetworkNotServed, network, s.network)
+	}
+	return nil
+}
+
+// validateAddress checks the address against the configured network and rejects types outside the allowlist
+func (s *BitcoinService) validateAddress(addr string) (address.Type, error) {
+	addrType, err := address.Validate(addr, s.network)
+	if err != nil {
+		return "", err
+	}
+	if len(s.allowedTypes) == 0 {
+		return addrType, nil
+	}
+
+	allowed := make([]string, len(s.allowedTypes))
+	for i, t := range s.allowedTypes {
+		if t == addrType {
+			return addrType, nil
+		}
+		allowed[i] = string(t)
+	}
+
+	return "", fmt.Errorf("address type %s is not allowed (allowed types: %s)", addrType, strings.Join(allowed, ", "))
+}
+
+// RemoveAddress removes a Bitcoin address from tracking; a soft removal archives it instead
+func (s *BitcoinService) RemoveAddress(address string, soft bool) error {
+	if !soft {
+		if err := s.repo.RemoveAddress(address); err != nil {
+			return err
+		}
+		s.dropBalanceGauge(address)
+		return nil
+	}
+
+	removed, err := s.repo.RemoveAddresses([]string{address}, true)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	s.dropBalanceGauge(address)
+	return nil
+}
+
+// dropBalanceGauge removes the gauge series of an address that is no longer tracked
+func (s *BitcoinService) dropBalanceGauge(address string) {
+	if s.balanceGauges != nil {
+		s.balanceGauges.Delete(address)
+	}
+}
+
+// RemoveAddresses removes several addresses at once and reports which were not found
+func (s *BitcoinService) RemoveAddresses(addresses []string, soft bool) (*models.BatchRemoveResult, error) {
+	unique := uniqueAddresses(addresses)
+	removed, err := s.repo.RemoveAddresses(unique, soft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove addresses: %w", err)
+	}
+
+	wasRemoved := make(map[string]bool, len(removed))
+	for _, addr := range removed {
+		wasRemoved[addr] = true
+		s.dropBalanceGauge(addr)
+	}
+
+	result := &models.BatchRemoveResult{
+		Removed:  []string{},
+		NotFound: []string{},
+	}
+	for _, addr := range unique {
+		if wasRemoved[addr] {
+			result.Removed = append(result.Removed, addr)
+		} else {
+			result.NotFound = append(result.NotFound, addr)
+		}
+	}
+
+	return result, nil
+}
+
+// CleanupInactiveAddresses archives addresses with zero balance and no activity for longer than after.
+// A zero duration falls back to the configured period.
+func (s *BitcoinService) CleanupInactiveAddresses(ctx context.Context, after time.Duration) (archived []string, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.CleanupInactiveAddresses")
+	defer func() { tracing.End(span, err) }()
+
+	if after <= 0 {
+		after = s.cleanupAfter
+	}
+	if after <= 0 {
+		return nil, ErrCleanupDisabled
+	}
+
+	archived, err = traceRepo(ctx, s.tracer, "ArchiveInactiveAddresses", func() ([]string, error) {
+		return s.repo.ArchiveInactiveAddresses(time.Now().Add(-after))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive inactive addresses: %w", err)
+	}
+
+	return archived, nil
+}
+
+// AutoCleanup runs the configured cleanup policy; it does nothing when the policy is disabled
+func (s *BitcoinService) AutoCleanup(ctx context.Context) ([]string, error) {
+	if s.cleanupAfter <= 0 {
+		return nil, nil
+	}
+	return s.CleanupInactiveAddresses(ctx, s.cleanupAfter)
+}
+
+// ArchiveOldTransactions moves deeply confirmed transactions older than after out of the working set
+// and returns how many were moved. A zero duration falls back to the configured age.
+func (s *BitcoinService) ArchiveOldTransactions(ctx context.Context, after time.Duration) (moved int64, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.ArchiveOldTransactions")
+	defer func() { tracing.End(span, err) }()
+
+	if after <= 0 {
+		after = s.archiveAfter
+	}
+	if after <= 0 {
+		return 0, ErrArchiveDisabled
+	}
+
+	moved, err = traceRepo(ctx, s.tracer, "ArchiveOldTransactions", func() (int64, error) {
+		return s.repo.ArchiveOldTransactions(time.Now().Add(-after))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive transactions: %w", err)
+	}
+
+	return moved, nil
+}
+
+// AutoArchive runs the configured transaction archiving; it does nothing when archiving is disabled
+func (s *BitcoinService) AutoArchive(ctx context.Context) (int64, error) {
+	if s.archiveAfter <= 0 {
+		return 0, nil
+	}
+	return s.ArchiveOldTransactions(ctx, s.archiveAfter)
+}
+
+// RestoreAddress brings an archived address back into tracking
+func (s *BitcoinService) RestoreAddress(ctx context.Context, address string) error {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.RestoreAddress", addressAttribute(address))
+	_, err := traceRepo(ctx, s.tracer, "RestoreAddress", func() (struct{}, error) {
+		return struct{}{}, s.repo.RestoreAddress(address)
+	})
+	tracing.End(span, err)
+	return err
+}
+
+// ResetLastSynced clears or backdates an address's last sync time so schedulers treat it as stale
+func (s *BitcoinService) ResetLastSynced(ctx context.Context, address string, syncTime *time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.ResetLastSynced", addressAttribute(address))
+	_, err := traceRepo(ctx, s.tracer, "ResetLastSynced", func() (struct{}, error) {
+		return struct{}{}, s.repo.ResetLastSynced(address, syncTime)
+	})
+	tracing.End(span, err)
+	return err
 }
 
 // GetAllAddresses returns all tracked addresses with their balances
-func (s *BitcoinService) GetAllAddresses() ([]models.AddressWithBalance, error) {
-	addresses, err := s.repo.GetAllAddresses()
+func (s *BitcoinService) GetAllAddresses(ctx context.Context) (_ []models.AddressWithBalance, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.GetAllAddresses")
+	defer func() { tracing.End(span, err) }()
+
+	addresses, err := traceRepo(ctx, s.tracer, "GetAllAddresses", s.repo.GetAllAddresses)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get addresses: %w", err)
 	}
 
+	// Fall back to per-address queries so one bad row cannot hide every balance
+	balances, err := traceRepo(ctx, s.tracer, "GetBalances", s.repo.GetBalances)
+	if err != nil {
+		fmt.Printf("Warning: batched balance query failed, computing balances one by one: %v\n", err)
+		balances = nil
+	}
+
 	var addressesWithBalance []models.AddressWithBalance
 	for _, addr := range addresses {
-		balance, err := s.repo.GetBalance(addr.Address)
-		if err != nil {
-			// Return zero balance if calculation fails
-			balance = &models.Balance{
-				Address:            addr.Address,
-				ConfirmedBalance:   0,
-				UnconfirmedBalance: 0,
-				TotalBalance:       0,
-				BalanceBTC:         0,
+		addressWithBalance := models.AddressWithBalance{Address: addr}
+
+		balance, ok := balances[addr.Address]
+		switch {
+		case balances == nil:
+			if balance, err = s.repo.GetBalance(addr.Address); err != nil {
+				balance = &models.Balance{Address: addr.Address}
+				addressWithBalance.BalanceError = err.Error()
 			}
+		case !ok:
+			// Addresses without transactions have a zero balance
+			balance = &models.Balance{Address: addr.Address}
 		}
+		s.applyMaturity(balance)
 
-		addressWithBalance := models.AddressWithBalance{
-			Address: addr,
-			Balance: *balance,
-		}
+		addressWithBalance.Balance = *balance
 		addressesWithBalance = append(addressesWithBalance, addressWithBalance)
 	}
 
 	return addressesWithBalance, nil
 }
 
+// ListAddresses returns all tracked addresses without computing balances
+func (s *BitcoinService) ListAddresses(ctx context.Context) (_ []models.Address, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.ListAddresses")
+	defer func() { tracing.End(span, err) }()
+
+	addresses, err := traceRepo(ctx, s.tracer, "GetAllAddresses", s.repo.GetAllAddresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// UpdateAddress changes the user-editable fields of a tracked address
+func (s *BitcoinService) UpdateAddress(address string, update models.UpdateAddressRequest) (*models.Address, error) {
+	if p := update.Priority; p != nil && (*p < models.MinSyncPriority || *p > models.MaxSyncPriority) {
+		return nil, fmt.Errorf("%w: must be %d to %d", ErrInvalidPriority, models.MinSyncPriority, models.MaxSyncPriority)
+	}
+	return s.repo.UpdateAddress(address, update)
+}
+
+// CreateOwnershipChallenge issues a message for the owner of a tracked address to sign. The message names
+// the address, the network and a random nonce, and is accepted by VerifyOwnership once, within OwnershipChallengeTTL.
+func (s *BitcoinService) CreateOwnershipChallenge(addr string) (*models.OwnershipChallenge, error) {
+	if _, err := s.repo.GetAddress(addr); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	now := time.Now()
+	challenge := models.OwnershipChallenge{
+		Address:   addr,
+		ExpiresAt: now.Add(OwnershipChallengeTTL).UTC().Truncate(time.Second),
+	}
+	challenge.Message = fmt.Sprintf("Prove ownership of %s on %s\nNonce: %s\nExpires: %s",
+		addr, s.network, hex.EncodeToString(nonce), challenge.ExpiresAt.Format(time.RFC3339))
+
+	if err := s.repo.SaveOwnershipChallenge(challenge, now); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// VerifyOwnership checks a challenge from CreateOwnershipChallenge signed with the address's key and marks the
+// address as owned when it matches. The challenge is used up by a successful verification; a message that is not
+// an outstanding challenge for the address returns ErrInvalidChallenge. Signature problems wrap ErrInvalidSignature;
+// a valid signature from another key also wraps address.ErrSignatureMismatch.
+func (s *BitcoinService) VerifyOwnership(addr string, req models.VerifyOwnershipRequest) (*models.Address, error) {
+	if _, err := s.repo.GetAddress(addr); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	if err := address.VerifyMessage(addr, req.Message, req.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	now := time.Now()
+	consumed, err := s.repo.ConsumeOwnershipChallenge(addr, req.Message, now)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidChallenge
+	}
+
+	return s.repo.MarkOwnershipVerified(addr, now)
+}
+
+// GetPortfolio sums balances across tracked addresses; ownedOnly leaves watch-only addresses out of the totals
+func (s *BitcoinService) GetPortfolio(ctx context.Context, ownedOnly bool) (*models.Portfolio, error) {
+	addresses, err := s.GetAllAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &models.Portfolio{OwnedOnly: ownedOnly}
+	for _, addr := range addresses {
+		if ownedOnly && !addr.Owned {
+			continue
+		}
+		// A missing balance would make the totals silently wrong
+		if addr.BalanceError != "" {
+			return nil, fmt.Errorf("failed to get balance for %s: %s", addr.Address.Address, addr.BalanceError)
+		}
+		portfolio.AddressCount++
+		portfolio.ConfirmedBalance += addr.Balance.ConfirmedBalance
+		portfolio.UnconfirmedBalance += addr.Balance.UnconfirmedBalance
+		portfolio.TotalBalance += addr.Balance.TotalBalance
+	}
+	portfolio.BalanceBTC = models.SatoshisToBTC(portfolio.TotalBalance)
+
+	return portfolio, nil
+}
+
 // GetAddress returns a specific address with its balance
 func (s *BitcoinService) GetAddress(address string) (*models.AddressWithBalance, error) {
 	addr, err := s.repo.GetAddress(address)
@@ -95,7 +780,7 @@ func (s *BitcoinService) GetAddress(address string) (*models.AddressWithBalance,
 		return nil, fmt.Errorf("address not found: %w", err)
 	}
 
-	balance, err := s.repo.GetBalance(address)
+	balance, err := s.balance(address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -107,92 +792,323 @@ func (s *BitcoinService) GetAddress(address string) (*models.AddressWithBalance,
 }
 
 // GetBalance returns the current balance for an address
-func (s *BitcoinService) GetBalance(address string) (*models.Balance, error) {
+func (s *BitcoinService) GetBalance(ctx context.Context, address string) (_ *models.Balance, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.GetBalance", addressAttribute(address))
+	defer func() { tracing.End(span, err) }()
+
 	// Verify address exists in our tracking
-	_, err := s.repo.GetAddress(address)
+	if err := s.checkTracked(ctx, address); err != nil {
+		return nil, err
+	}
+
+	balance, err := traceRepo(ctx, s.tracer, "GetBalance", func() (*models.Balance, error) {
+		return s.balance(address)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("address not being tracked: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrBalanceUnavailable, err)
 	}
+	return balance, nil
+}
 
-	return s.repo.GetBalance(address)
+// checkTracked returns an error wrapping ErrAddressNotFound unless address is tracked
+func (s *BitcoinService) checkTracked(ctx context.Context, address string) error {
+	if _, err := traceRepo(ctx, s.tracer, "GetAddress", func() (*models.Address, error) {
+		return s.repo.GetAddress(address)
+	}); err != nil {
+		return fmt.Errorf("address not being tracked: %w", err)
+	}
+	return nil
 }
 
-// GetTransactions returns transactions for an address with pagination
-func (s *BitcoinService) GetTransactions(address string, limit, offset int) ([]models.Transaction, error) {
-	// Verify address exists in our tracking
-	_, err := s.repo.GetAddress(address)
+// balance calculates an address's stored balance, applying the coinbase maturity setting
+func (s *BitcoinService) balance(address string) (*models.Balance, error) {
+	balance, err := s.repo.GetBalance(address)
 	if err != nil {
-		return nil, fmt.Errorf("address not being tracked: %w", err)
+		return nil, err
 	}
+	s.applyMaturity(balance)
+	return balance, nil
+}
 
-	// Set default limit if not provided
-	if limit <= 0 {
-		limit = 50
+// applyMaturity removes immature coinbase rewards from a balance when they are configured as unspendable
+func (s *BitcoinService) applyMaturity(balance *models.Balance) {
+	if s.excludeImmature {
+		balance.ExcludeImmature()
+	}
+}
+
+// GetDetailedBalance returns the current balance together with the provider statistics from the last sync
+func (s *BitcoinService) GetDetailedBalance(ctx context.Context, address string) (*models.DetailedBalance, error) {
+	balance, err := s.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
 	}
-	if limit > 100 {
-		limit = 100 // Maximum limit
+
+	stats, err := s.repo.GetAddressStats(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider stats: %w", err)
 	}
 
-	return s.repo.GetTransactionsByAddress(address, limit, offset)
+	return &models.DetailedBalance{Balance: *balance, Provider: stats}, nil
 }
 
-// SyncAddress synchronizes transaction data for a specific address
-func (s *BitcoinService) SyncAddress(address string) error {
-	// Verify address exists in our tracking
-	_, err := s.repo.GetAddress(address)
+// CompareBalance fetches the provider's balance for a tracked address and compares it with the
+// balance derived from stored transactions. Immature coinbase rewards are counted on both sides,
+// as the provider does, so the delta only reflects missing or stale transactions.
+func (s *BitcoinService) CompareBalance(ctx context.Context, address string) (*models.BalanceComparison, error) {
+	tracked, err := s.repo.GetAddress(address)
 	if err != nil {
-		return fmt.Errorf("address not being tracked: %w", err)
+		return nil, fmt.Errorf("address not being tracked: %w", err)
 	}
 
-	// Fetch transactions from blockchain API
-	transactions, err := s.client.GetTransactions(address, 100)
+	derived, err := s.repo.GetBalance(address)
 	if err != nil {
-		return fmt.Errorf("failed to fetch transactions from API: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrBalanceUnavailable, err)
+	}
+
+	if err := s.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	provider, err := s.client.GetBalance(ctx, address)
+	s.limiter.release(err)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch balance from API: %w", ErrProviderUnavailable, err)
+	}
+
+	delta := models.BalanceDelta{
+		Confirmed:   provider.ConfirmedBalance - derived.ConfirmedBalance,
+		Unconfirmed: provider.UnconfirmedBalance - derived.UnconfirmedBalance,
+		Total:       provider.TotalBalance - derived.TotalBalance,
 	}
+	return &models.BalanceComparison{
+		Address:          address,
+		Derived:          *derived,
+		Provider:         *provider,
+		Delta:            delta,
+		Matches:          delta == models.BalanceDelta{},
+		HistoryTruncated: tracked.HistoryTruncated,
+	}, nil
+}
 
-	// Save new transactions to database
-	var savedCount int
-	for _, tx := range transactions {
-		// Check if transaction already exists
-		exists, err := s.repo.TransactionExists(tx.Hash, address)
+// CompareAddresses builds a side-by-side view of two tracked addresses and the transactions they share
+func (s *BitcoinService) CompareAddresses(ctx context.Context, a, b string) (*models.AddressComparison, error) {
+	comparison := &models.AddressComparison{}
+	for _, side := range []struct {
+		address  string
+		activity *models.AddressActivity
+	}{{a, &comparison.A}, {b, &comparison.B}} {
+		balance, err := s.GetBalance(ctx, side.address)
 		if err != nil {
-			return fmt.Errorf("failed to check transaction existence: %w", err)
+			return nil, err
 		}
 
-		if !exists {
-			if err := s.repo.SaveTransaction(&tx); err != nil {
-				return fmt.Errorf("failed to save transaction: %w", err)
-			}
-			savedCount++
+		activity, err := s.repo.GetActivity(side.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get activity: %w", err)
 		}
+		activity.Balance = *balance
+		*side.activity = *activity
 	}
 
-	// Update last synced time
-	if err := s.repo.UpdateLastSynced(address, time.Now()); err != nil {
-		return fmt.Errorf("failed to update last synced time: %w", err)
+	shared, err := s.repo.GetSharedTransactions(a, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared transactions: %w", err)
 	}
+	comparison.SharedTransactions = shared
 
-	fmt.Printf("Synced %d new transactions for address %s\n", savedCount, address)
-	return nil
+	return comparison, nil
 }
 
-// SyncAllAddresses synchronizes all tracked addresses
-func (s *BitcoinService) SyncAllAddresses() error {
-	addresses, err := s.repo.GetAllAddresses()
+// GetFeeEstimates returns the current recommended fee rates
+func (s *BitcoinService) GetFeeEstimates() (*models.FeeEstimates, error) {
+	if s.fees == nil {
+		return nil, ErrFeesDisabled
+	}
+
+	fees, err := s.fees.GetFeeEstimates()
 	if err != nil {
-		return fmt.Errorf("failed to get addresses for sync: %w", err)
+		return nil, fmt.Errorf("failed to get fee estimates: %w", err)
 	}
 
-	var errors []error
-	for _, addr := range addresses {
-		if err := s.SyncAddress(addr.Address); err != nil {
-			errors = append(errors, fmt.Errorf("sync failed for %s: %w", addr.Address, err))
-		}
+	return &fees, nil
+}
+
+// Capabilities reports the optional features the active provider and fee source can back
+func (s *BitcoinService) Capabilities() models.Capabilities {
+	return models.Capabilities{
+		ClientCapabilities: s.client.Capabilities(),
+		FeeEstimates:       s.fees != nil,
 	}
+}
+
+// WaitForBalanceChange blocks until a sync changes the address's balance and returns the new balance.
+// If ctx ends first it returns ctx.Err().
+func (s *BitcoinService) WaitForBalanceChange(ctx context.Context, address string) (*models.Balance, error) {
+	// Subscribe before checking tracking so a concurrent change can't be missed
+	changed, unsubscribe := s.broadcaster.subscribe(address)
+	defer unsubscribe()
 
-	if len(errors) > 0 {
-		return fmt.Errorf("sync completed with %d errors", len(errors))
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
 	}
 
-	return nil
+	select {
+	case <-changed:
+		// The published balance comes from the primary, which a read replica may not have caught up with
+		balance, _ := s.broadcaster.latest(address)
+		s.applyMaturity(&balance)
+		return &balance, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetBalanceHistory returns the recorded balance snapshots for an address between from and to
+func (s *BitcoinService) GetBalanceHistory(address string, from, to time.Time) ([]models.BalanceSnapshot, error) {
+	if s.snapshots == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	// Verify address exists in our tracking
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	return s.snapshots.GetSnapshots(address, from, to)
+}
+
+// GetTransactions returns transactions for an address with pagination; the repository applies the
+// default page size and the cap
+func (s *BitcoinService) GetTransactions(ctx context.Context, address string, limit, offset int) (_ []models.Transaction, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.GetTransactions", addressAttribute(address))
+	defer func() { tracing.End(span, err) }()
+
+	// Verify address exists in our tracking
+	if err := s.checkTracked(ctx, address); err != nil {
+		return nil, err
+	}
+
+	return traceRepo(ctx, s.tracer, "GetTransactionsByAddress", func() ([]models.Transaction, error) {
+		return s.repo.GetTransactionsByAddress(address, limit, offset)
+	})
+}
+
+// GetTransactionHistory returns transactions for an address with pagination like GetTransactions,
+// including archived transactions
+func (s *BitcoinService) GetTransactionHistory(ctx context.Context, address string, limit, offset int) (_ []models.Transaction, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.GetTransactionHistory", addressAttribute(address))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkTracked(ctx, address); err != nil {
+		return nil, err
+	}
+
+	return traceRepo(ctx, s.tracer, "QueryTransactions", func() ([]models.Transaction, error) {
+		return s.repo.QueryTransactions(models.TransactionFilter{
+			Address:        address,
+			SortDesc:       true,
+			Limit:          limit,
+			Offset:         offset,
+			IncludeArchive: true,
+		})
+	})
+}
+
+// GetLatestTransaction returns the newest stored transaction for an address, or nil if it has none
+func (s *BitcoinService) GetLatestTransaction(ctx context.Context, address string) (_ *models.Transaction, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.GetLatestTransaction", addressAttribute(address))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.checkTracked(ctx, address); err != nil {
+		return nil, err
+	}
+
+	return traceRepo(ctx, s.tracer, "GetLatestTransaction", func() (*models.Transaction, error) {
+		return s.repo.GetLatestTransaction(address)
+	})
+}
+
+// GetSyncHistory returns the newest sync log entries for an address
+func (s *BitcoinService) GetSyncHistory(address string, limit int) ([]models.SyncLogEntry, error) {
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	return s.repo.GetSyncLog(address, limit)
+}
+
+// GetTransactionTypes returns the count and total amount of each transaction type stored for an address
+func (s *BitcoinService) GetTransactionTypes(address string) ([]models.TypeCount, error) {
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	return s.repo.GetTypeCounts(address)
+}
+
+// GetVolume returns the transaction volume of a tracked address in day, week or month buckets
+func (s *BitcoinService) GetVolume(address, bucket string, from, to *time.Time) ([]models.VolumeBucket, error) {
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	return s.repo.GetVolume(address, bucket, from, to)
+}
+
+// QueryTransactions returns transactions across all tracked addresses matching the filter
+func (s *BitcoinService) QueryTransactions(ctx context.Context, filter models.TransactionFilter) (_ []models.Transaction, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.QueryTransactions")
+	defer func() { tracing.End(span, err) }()
+
+	return traceRepo(ctx, s.tracer, "QueryTransactions", func() ([]models.Transaction, error) {
+		return s.repo.QueryTransactions(filter)
+	})
+}
+
+// BoundExport checks that an export of the transactions matching filter stays within the row cap
+// and returns the filter limited to it. Exports over the cap fail with ErrExportTooLarge before
+// anything is streamed, with guidance on narrowing them.
+func (s *BitcoinService) BoundExport(filter models.TransactionFilter) (models.TransactionFilter, error) {
+	if s.maxExportRows <= 0 || (filter.Limit > 0 && filter.Limit <= s.maxExportRows) {
+		return filter, nil
+	}
+
+	count, err := s.repo.CountTransactions(filter)
+	if err != nil {
+		return filter, err
+	}
+	rows := max(count-filter.Offset, 0)
+	if filter.Limit > 0 {
+		rows = min(rows, filter.Limit)
+	}
+
+	if rows > s.maxExportRows {
+		guidance := "narrow the from/to date range"
+		if filter.From == nil || filter.To == nil {
+			guidance = "export a date range with from and to"
+		}
+		return filter, fmt.Errorf("%w: %d transactions match but at most %d can be exported at once; %s, or page with limit and offset",
+			ErrExportTooLarge, rows, s.maxExportRows, guidance)
+	}
+
+	// Rows stored after the count must not push the stream past the cap
+	filter.Limit = s.maxExportRows
+	return filter, nil
+}
+
+// StreamTransactions calls fn for every transaction matching the filter, for exports
+func (s *BitcoinService) StreamTransactions(ctx context.Context, filter models.TransactionFilter, fn func(*models.Transaction) error) error {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.StreamTransactions")
+	_, err := traceRepo(ctx, s.tracer, "StreamTransactions", func() (struct{}, error) {
+		return struct{}{}, s.repo.StreamTransactions(filter, fn)
+	})
+	tracing.End(span, err)
+	return err
 }
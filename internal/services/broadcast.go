@@ -0,0 +1,74 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// balanceBroadcaster wakes goroutines waiting for an address's balance to change
+type balanceBroadcaster struct {
+	mu        sync.Mutex
+	waiters   map[string]map[chan struct{}]struct{}
+	published map[string]models.Balance
+}
+
+// newBalanceBroadcaster creates an empty broadcaster
+func newBalanceBroadcaster() *balanceBroadcaster {
+	return &balanceBroadcaster{
+		waiters:   make(map[string]map[chan struct{}]struct{}),
+		published: make(map[string]models.Balance),
+	}
+}
+
+// subscribe returns a channel closed on the next change to address, and a function to unsubscribe
+func (b *balanceBroadcaster) subscribe(address string) (<-chan struct{}, func()) {
+	ch := make(chan struct{})
+
+	b.mu.Lock()
+	if b.waiters[address] == nil {
+		b.waiters[address] = make(map[chan struct{}]struct{})
+	}
+	b.waiters[address][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.waiters[address][ch]; ok {
+			delete(b.waiters[address], ch)
+			if len(b.waiters[address]) == 0 {
+				delete(b.waiters, address)
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// publish wakes every current subscriber of address when balance differs from the last one published
+// for it, and reports whether it did
+func (b *balanceBroadcaster) publish(address string, balance models.Balance) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if last, ok := b.published[address]; ok && last == balance {
+		return false
+	}
+	b.published[address] = balance
+
+	for ch := range b.waiters[address] {
+		close(ch)
+	}
+	delete(b.waiters, address)
+	return true
+}
+
+// latest returns the last balance published for address
+func (b *balanceBroadcaster) latest(address string) (models.Balance, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	balance, ok := b.published[address]
+	return balance, ok
+}
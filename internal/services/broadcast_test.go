@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// subscribers returns how many goroutines are waiting for a change to address
+func (b *balanceBroadcaster) subscribers(address string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.waiters[address])
+}
+
+// woken reports whether ch has been closed
+func woken(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBalanceBroadcasterPublish(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const other = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	b := newBalanceBroadcaster()
+
+	first, unsubscribeFirst := b.subscribe(addr)
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := b.subscribe(addr)
+	defer unsubscribeSecond()
+	elsewhere, unsubscribeElsewhere := b.subscribe(other)
+	defer unsubscribeElsewhere()
+
+	balance := models.Balance{Address: addr, ConfirmedBalance: 1000, TotalBalance: 1000}
+	if !b.publish(addr, balance) {
+		t.Fatal("Expected the first balance published to wake watchers")
+	}
+	if !woken(first) || !woken(second) {
+		t.Error("Expected every subscriber of the address to be woken")
+	}
+	if woken(elsewhere) {
+		t.Error("Expected subscribers of other addresses to keep waiting")
+	}
+	if n := b.subscribers(addr); n != 0 {
+		t.Errorf("Expected woken subscribers to be dropped, got %d", n)
+	}
+	if latest, ok := b.latest(addr); !ok || latest != balance {
+		t.Errorf("latest = %+v, %v; want %+v", latest, ok, balance)
+	}
+
+	// Publishing the same balance again wakes no one
+	third, unsubscribeThird := b.subscribe(addr)
+	defer unsubscribeThird()
+	if b.publish(addr, balance) || woken(third) {
+		t.Error("Expected an unchanged balance not to wake watchers")
+	}
+	unsubscribeThird()
+	if n := b.subscribers(addr); n != 0 {
+		t.Errorf("Expected unsubscribing to remove the subscriber, got %d", n)
+	}
+}
+
+func TestWaitForBalanceChangeWakesOnSync(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	client := &fakeClient{}
+	s := NewBitcoinService(repo, client)
+
+	type outcome struct {
+		balance *models.Balance
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		balance, err := s.WaitForBalanceChange(context.Background(), addr)
+		done <- outcome{balance, err}
+	}()
+	for s.broadcaster.subscribers(addr) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	client.setTransactions(addr, minedAt(800010,
+		models.Transaction{Hash: "aa", Address: addr, Amount: 150000, BlockHeight: 800000, Timestamp: now, Type: "received"}))
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+
+	select {
+	case got := <-done:
+		if got.err != nil || got.balance.TotalBalance != 150000 {
+			t.Errorf("WaitForBalanceChange = %+v (err %v); want the synced balance", got.balance, got.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the sync to wake the watcher")
+	}
+}
+
+func TestWaitForBalanceChangeReleasesSubscriber(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	s := NewBitcoinService(repo, &fakeClient{})
+
+	// A client that goes away leaves nothing subscribed
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.WaitForBalanceChange(ctx, addr)
+		done <- err
+	}()
+	for s.broadcaster.subscribers(addr) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if n := s.broadcaster.subscribers(addr); n != 0 {
+		t.Errorf("Expected the cancelled watcher to unsubscribe, got %d subscribers", n)
+	}
+
+	const untracked = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	if _, err := s.WaitForBalanceChange(context.Background(), untracked); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for an untracked address, got %v", err)
+	}
+	if n := s.broadcaster.subscribers(untracked); n != 0 {
+		t.Errorf("Expected a rejected watcher to unsubscribe, got %d subscribers", n)
+	}
+}
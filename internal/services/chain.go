@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// chainTipTTL is how long a fetched chain tip is reused before asking the provider again
+const chainTipTTL = 30 * time.Second
+
+// ErrChainTipUnavailable is returned when the provider does not report a chain tip
+var ErrChainTipUnavailable = errors.New("provider does not report the chain tip")
+
+// chainTipCache holds the most recently fetched chain tip
+type chainTipCache struct {
+	mu        sync.Mutex
+	tip       *models.ChainTip
+	fetchedAt time.Time
+}
+
+// GetChainStatus returns the provider's chain tip together with how far stored data lags behind it
+func (s *BitcoinService) GetChainStatus(ctx context.Context) (*models.ChainStatus, error) {
+	tip, fetchedAt, err := s.chainTip(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.GetMaxBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ChainStatus{
+		Tip:          *tip,
+		StoredHeight: stored,
+		Lag:          max(tip.Height-stored, 0),
+		CheckedAt:    fetchedAt,
+	}, nil
+}
+
+// chainTip returns the cached chain tip, refreshing it from the provider once it is older than chainTipTTL
+func (s *BitcoinService) chainTip(ctx context.Context) (*models.ChainTip, time.Time, error) {
+	s.tipCache.mu.Lock()
+	defer s.tipCache.mu.Unlock()
+
+	if s.tipCache.tip != nil && time.Since(s.tipCache.fetchedAt) < chainTipTTL {
+		return s.tipCache.tip, s.tipCache.fetchedAt, nil
+	}
+
+	provider, ok := s.client.(clients.ChainTipProvider)
+	if !ok || !s.client.Capabilities().ChainTip {
+		return nil, time.Time{}, ErrChainTipUnavailable
+	}
+
+	tip, err := provider.GetChainTip(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get chain tip: %w", err)
+	}
+	if tip == nil {
+		return nil, time.Time{}, ErrChainTipUnavailable
+	}
+
+	s.tipCache.tip = tip
+	s.tipCache.fetchedAt = time.Now()
+	return tip, s.tipCache.fetchedAt, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// GetClusters groups tracked addresses that probably belong to the same wallet using the
+// common-input-ownership heuristic: addresses spending together in one transaction share an owner,
+// and the relation is transitive. Only co-spends visible in stored history are considered, so
+// addresses that never spent together with another tracked address are not reported.
+func (s *BitcoinService) GetClusters() ([]models.AddressCluster, error) {
+	coSpends, err := s.repo.GetCoSpends()
+	if err != nil {
+		return nil, err
+	}
+
+	sets := newDisjointSets()
+	for _, coSpend := range coSpends {
+		for _, address := range coSpend.Addresses[1:] {
+			sets.union(coSpend.Addresses[0], address)
+		}
+	}
+
+	byRoot := make(map[string]*models.AddressCluster)
+	for _, coSpend := range coSpends {
+		if len(coSpend.Addresses) < 2 {
+			continue
+		}
+		root := sets.find(coSpend.Addresses[0])
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &models.AddressCluster{}
+			byRoot[root] = cluster
+		}
+		cluster.Transactions = append(cluster.Transactions, coSpend.Hash)
+	}
+	for address := range sets.parent {
+		if cluster, ok := byRoot[sets.find(address)]; ok {
+			cluster.Addresses = append(cluster.Addresses, address)
+		}
+	}
+
+	clusters := make([]models.AddressCluster, 0, len(byRoot))
+	for _, cluster := range byRoot {
+		sort.Strings(cluster.Addresses)
+		clusters = append(clusters, *cluster)
+	}
+
+	// Largest clusters first, ties in address order
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Addresses) != len(clusters[j].Addresses) {
+			return len(clusters[i].Addresses) > len(clusters[j].Addresses)
+		}
+		return clusters[i].Addresses[0] < clusters[j].Addresses[0]
+	})
+
+	return clusters, nil
+}
+
+// disjointSets is a union-find structure over addresses
+type disjointSets struct {
+	parent map[string]string
+	size   map[string]int
+}
+
+func newDisjointSets() *disjointSets {
+	return &disjointSets{parent: make(map[string]string), size: make(map[string]int)}
+}
+
+// find returns the representative of the set containing x, adding x as a singleton if unseen
+func (d *disjointSets) find(x string) string {
+	if _, ok := d.parent[x]; !ok {
+		d.parent[x] = x
+		d.size[x] = 1
+	}
+	for d.parent[x] != x {
+		d.parent[x] = d.parent[d.parent[x]] // path halving
+		x = d.parent[x]
+	}
+	return x
+}
+
+// union merges the sets containing a and b, attaching the smaller set to the larger
+func (d *disjointSets) union(a, b string) {
+	ra, rb := d.find(a), d.find(b)
+	if ra == rb {
+		return
+	}
+	if d.size[ra] < d.size[rb] {
+		ra, rb = rb, ra
+	}
+	d.parent[rb] = ra
+	d.size[ra] += d.size[rb]
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// syncCoalescer shares one sync per address between requests that arrive while it is running
+// or within the cooldown after it finished
+type syncCoalescer struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	calls    map[string]*syncCall
+}
+
+// syncCall is a running or recently finished address sync
+type syncCall struct {
+	done     chan struct{}
+	err      error
+	finished time.Time
+}
+
+// RequestSync syncs an address on behalf of a client, reusing a sync of the same address that is
+// already running or finished within the cooldown instead of calling the provider again.
+// It reports whether the result came from such a shared sync. The sync itself is detached from
+// ctx so that one caller giving up does not fail it for the others; ctx only bounds the wait.
+func (s *BitcoinService) RequestSync(ctx context.Context, address string) (coalesced bool, err error) {
+	call, leader := s.syncs.join(address)
+	if leader {
+		go func() {
+			s.syncs.finish(address, call, s.SyncAddress(context.WithoutCancel(ctx), address))
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return !leader, call.err
+	case <-ctx.Done():
+		return !leader, ctx.Err()
+	}
+}
+
+// join returns the sync to wait for and whether the caller must start it
+func (c *syncCoalescer) join(address string) (*syncCall, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.calls[address]; ok {
+		select {
+		case <-call.done:
+			if time.Since(call.finished) < c.cooldown {
+				return call, false
+			}
+		default:
+			return call, false
+		}
+	}
+
+	if c.calls == nil {
+		c.calls = make(map[string]*syncCall)
+	}
+	call := &syncCall{done: make(chan struct{})}
+	c.calls[address] = call
+	return call, true
+}
+
+// finish records the result of a sync and releases its waiters
+func (c *syncCoalescer) finish(address string, call *syncCall, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call.err = err
+	call.finished = time.Now()
+	close(call.done)
+
+	if c.cooldown <= 0 && c.calls[address] == call {
+		delete(c.calls, address)
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/notify"
+	"github.com/ihladush/bitcoin/internal/requestid"
+)
+
+// confirmationAlerts notifies when stored transactions reach a target number of confirmations
+type confirmationAlerts struct {
+	target   int
+	notifier notify.Notifier
+	// batchSize, when positive, delivers the notifications of a sync run together in batches of at
+	// most this many instead of one delivery per notification
+	batchSize int
+}
+
+// reached reports whether a sync moves a stored transaction from below the target to at least the target.
+// Transactions first seen already past it are not announced, so adding an address does not replay its history.
+func (a *confirmationAlerts) reached(stored, fetched *models.Transaction) bool {
+	if a.target <= 0 || a.notifier == nil {
+		return false
+	}
+	return stored.Confirmations < a.target && fetched.Confirmations >= a.target
+}
+
+// notify sends one notification per confirmed transaction once the sync has saved them. Inside a
+// batched sync run they are held until the run ends. Delivery is best effort: a failed notification
+// is logged and not retried by later syncs.
+func (a *confirmationAlerts) notify(ctx context.Context, alerts []models.ConfirmationAlert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	ns := make([]notify.Notification, len(alerts))
+	for i, alert := range alerts {
+		ns[i] = notify.Notification{
+			Event: notify.EventTransactionConfirmed,
+			Message: fmt.Sprintf("transaction %s of address %s reached %d confirmations",
+				alert.Transaction.Hash, alert.Address, alert.Transaction.Confirmations),
+			Time: time.Now().UTC(),
+			Data: alert,
+		}
+	}
+
+	if batch, ok := ctx.Value(alertBatchKey{}).(*alertBatch); ok {
+		batch.add(ns)
+		return
+	}
+	a.deliver(ctx, ns)
+}
+
+// deliver sends notifications one by one, or in batches when batching is enabled
+func (a *confirmationAlerts) deliver(ctx context.Context, ns []notify.Notification) {
+	// The sync may be near its deadline; delivery gets its own
+	deliveryCtx := context.WithoutCancel(ctx)
+
+	if a.batchSize > 0 {
+		if err := notify.SendBatch(deliveryCtx, a.notifier, ns, a.batchSize); err != nil {
+			fmt.Printf("%sWarning: failed to send %s notifications: %v\n", requestid.Prefix(ctx), notify.EventTransactionConfirmed, err)
+		}
+		return
+	}
+
+	for _, n := range ns {
+		if err := a.notifier.Notify(deliveryCtx, n); err != nil {
+			fmt.Printf("%sWarning: failed to send %s notification: %v\n", requestid.Prefix(ctx), n.Event, err)
+		}
+	}
+}
+
+// alertBatchKey is the context key of a sync run's alertBatch
+type alertBatchKey struct{}
+
+// alertBatch collects the notifications raised by the address syncs of one run
+type alertBatch struct {
+	mu sync.Mutex
+	ns []notify.Notification
+}
+
+// add appends notifications; it is safe for concurrent use by sync workers
+func (b *alertBatch) add(ns []notify.Notification) {
+	b.mu.Lock()
+	b.ns = append(b.ns, ns...)
+	b.mu.Unlock()
+}
+
+// collect returns a context whose syncs hold their notifications for a single delivery, and the
+// function that delivers them. Without batching the context is returned unchanged and flushing does nothing.
+func (a *confirmationAlerts) collect(ctx context.Context) (context.Context, func()) {
+	if a.batchSize <= 0 || a.notifier == nil {
+		return ctx, func() {}
+	}
+
+	batch := &alertBatch{}
+	return context.WithValue(ctx, alertBatchKey{}, batch), func() {
+		batch.mu.Lock()
+		ns := batch.ns
+		batch.ns = nil
+		batch.mu.Unlock()
+		if len(ns) > 0 {
+			a.deliver(ctx, ns)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// ErrFeeLookupUnsupported is returned when the provider cannot report the fee of a transaction
+var ErrFeeLookupUnsupported = errors.New("provider does not report transaction fees")
+
+// ErrTransactionNotFound is returned when the provider does not know a transaction
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// blockMinutes is the average time between blocks
+const blockMinutes = 10
+
+// feeBrackets maps the recommended fee rates to the confirmation targets they are meant for, highest rate first.
+// Transactions paying less than the economy rate get no estimate.
+var feeBrackets = []struct {
+	name   string
+	rate   func(models.FeeEstimates) float64
+	blocks int
+}{
+	{"fastest", func(f models.FeeEstimates) float64 { return f.FastestFee }, 1},
+	{"half_hour", func(f models.FeeEstimates) float64 { return f.HalfHourFee }, 3},
+	{"hour", func(f models.FeeEstimates) float64 { return f.HourFee }, 6},
+	{"economy", func(f models.FeeEstimates) float64 { return f.EconomyFee }, 144},
+}
+
+// EstimateConfirmation estimates when a transaction will confirm by comparing its fee rate with the
+// current recommended rates. Confirmed transactions are reported as such without an estimate.
+func (s *BitcoinService) EstimateConfirmation(ctx context.Context, hash string) (*models.TransactionETA, error) {
+	if s.fees == nil {
+		return nil, ErrFeesDisabled
+	}
+
+	provider, ok := s.client.(clients.TransactionFeeProvider)
+	if !ok || !s.client.Capabilities().TransactionFees {
+		return nil, ErrFeeLookupUnsupported
+	}
+
+	if err := s.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	fee, err := provider.GetTransactionFee(ctx, hash)
+	s.limiter.release(err)
+	if errors.Is(err, clients.ErrFeeLookupUnsupported) {
+		return nil, ErrFeeLookupUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+	}
+	if fee == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, hash)
+	}
+
+	eta := &models.TransactionETA{
+		Hash:      hash,
+		Confirmed: fee.Confirmed,
+		Fee:       fee.Fee,
+		VSize:     fee.VSize,
+		FeeRate:   fee.FeeRate(),
+		Bracket:   "confirmed",
+	}
+	if fee.Confirmed {
+		return eta, nil
+	}
+
+	fees, err := s.GetFeeEstimates()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+	}
+	eta.CurrentFees = fees
+	eta.Bracket = feeBracket(eta, *fees)
+
+	return eta, nil
+}
+
+// feeBracket fills in the estimate for the first bracket whose rate the transaction pays and returns its name
+func feeBracket(eta *models.TransactionETA, fees models.FeeEstimates) string {
+	for _, bracket := range feeBrackets {
+		if eta.FeeRate >= bracket.rate(fees) {
+			blocks, minutes := bracket.blocks, bracket.blocks*blockMinutes
+			eta.EstimatedBlocks, eta.EstimatedMinutes = &blocks, &minutes
+			return bracket.name
+		}
+	}
+
+	// Nodes may drop transactions paying less than the minimum relay rate
+	if eta.FeeRate >= fees.MinimumFee {
+		return "minimum"
+	}
+	return "below_minimum"
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/address"
+)
+
+// LabelTemplate derives a label for addresses added without one. It may contain the placeholders
+// {address}, {short} (the first and last six characters), {type} and {n} (one more than the
+// number of tracked addresses). An empty template leaves such addresses unlabelled.
+type LabelTemplate string
+
+// labelPlaceholder matches a placeholder in a label template
+var labelPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ParseLabelTemplate checks that a configured template only uses known placeholders
+func ParseLabelTemplate(value string) (LabelTemplate, error) {
+	for _, placeholder := range labelPlaceholder.FindAllString(value, -1) {
+		switch placeholder {
+		case "{address}", "{short}", "{type}", "{n}":
+		default:
+			return "", fmt.Errorf("unknown placeholder %s in label template %q", placeholder, value)
+		}
+	}
+	return LabelTemplate(value), nil
+}
+
+// defaultLabel fills in the label template for an address added without a label
+func (s *BitcoinService) defaultLabel(addr string, addrType address.Type) (string, error) {
+	template := string(s.labelTemplate)
+	if template == "" {
+		return "", nil
+	}
+
+	short := addr
+	if len(addr) > 12 {
+		short = addr[:6] + "…" + addr[len(addr)-6:]
+	}
+	replacements := []string{"{address}", addr, "{short}", short, "{type}", string(addrType)}
+
+	if strings.Contains(template, "{n}") {
+		tracked, err := s.repo.GetAllAddresses()
+		if err != nil {
+			return "", fmt.Errorf("failed to number default label: %w", err)
+		}
+		replacements = append(replacements, "{n}", strconv.Itoa(len(tracked)+1))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template), nil
+}
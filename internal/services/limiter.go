@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/clients"
+)
+
+// defaultRateLimitCooldown is how long calls pause after the provider rate-limits one without a retry hint
+const defaultRateLimitCooldown = 60 * time.Second
+
+// syncLimiter caps upstream provider calls across every sync path: at most
+// cap(slots) calls run at once and new calls start no faster than one per interval.
+// A rate-limited call pauses every caller until the provider's retry hint has passed.
+type syncLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newSyncLimiter creates a limiter; a rate of zero or less disables rate limiting
+func newSyncLimiter(concurrency int, ratePerSecond float64) *syncLimiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var interval time.Duration
+	if ratePerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / ratePerSecond)
+	}
+
+	return &syncLimiter{
+		slots:    make(chan struct{}, concurrency),
+		interval: interval,
+	}
+}
+
+// acquire blocks until a slot is free, any rate limit cooldown is over and the rate allows
+// another call, or ctx ends
+func (l *syncLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	if l.interval > 0 {
+		l.next = start.Add(l.interval)
+	}
+	l.mu.Unlock()
+
+	if !start.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(nil)
+		return ctx.Err()
+	}
+}
+
+// release frees the slot taken by acquire. err is the outcome of the call; when the provider
+// rate-limited it, no call starts again until its retry hint has passed.
+func (l *syncLimiter) release(err error) {
+	var rateLimited *clients.RateLimitError
+	if errors.As(err, &rateLimited) {
+		cooldown := rateLimited.RetryAfter
+		if cooldown <= 0 {
+			cooldown = defaultRateLimitCooldown
+		}
+
+		l.mu.Lock()
+		if until := time.Now().Add(cooldown); until.After(l.next) {
+			l.next = until
+		}
+		l.mu.Unlock()
+	}
+
+	<-l.slots
+}
+
+// concurrency returns the maximum number of concurrent upstream calls
+func (l *syncLimiter) concurrency() int {
+	return cap(l.slots)
+}
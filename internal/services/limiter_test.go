@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testAddresses returns n distinct address strings; sync tests use a fake client, so they need not be valid
+func testAddresses(n int) []string {
+	addresses := make([]string, n)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("addr-%d", i)
+	}
+	return addresses
+}
+
+func TestSyncRunRespectsConcurrencyLimit(t *testing.T) {
+	addresses := testAddresses(6)
+	repo := newTestRepository(t, addresses...)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	}}
+	s := NewBitcoinService(repo, client, WithSyncLimits(2, 0))
+
+	result, err := s.SyncAllAddresses(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAllAddresses failed: %v", err)
+	}
+	if len(result.Synced) != len(addresses) {
+		t.Errorf("Synced %d addresses; want %d", len(result.Synced), len(addresses))
+	}
+	if peak != 2 {
+		t.Errorf("Peak concurrent provider calls = %d; want 2", peak)
+	}
+}
+
+func TestRateLimitCooldownSharedAcrossWorkers(t *testing.T) {
+	const cooldown = 150 * time.Millisecond
+	addresses := testAddresses(4)
+	repo := newTestRepository(t, addresses...)
+
+	// The first call is rate-limited while the second is in flight on the other worker;
+	// every call started after that has to wait out the cooldown, whichever worker makes it
+	var (
+		mu        sync.Mutex
+		calls     int
+		limitedAt time.Time
+		later     []time.Time
+	)
+	second, limited := make(chan struct{}), make(chan struct{})
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		mu.Lock()
+		calls++
+		call := calls
+		if call > 2 {
+			later = append(later, time.Now())
+		}
+		mu.Unlock()
+
+		switch call {
+		case 1:
+			<-second
+			mu.Lock()
+			limitedAt = time.Now()
+			mu.Unlock()
+			close(limited)
+			return &RateLimitError{RetryAfter: cooldown}
+		case 2:
+			close(second)
+			<-limited
+		}
+		return nil
+	}}
+	s := NewBitcoinService(repo, client, WithSyncLimits(2, 0))
+
+	result, err := s.SyncAllAddresses(context.Background())
+	if err == nil || result == nil {
+		t.Fatalf("SyncAllAddresses = %+v, %v; want the rate-limited address reported as an error", result, err)
+	}
+	if len(result.Synced) != 3 || len(result.Failed) != 1 {
+		t.Errorf("Expected 3 synced and 1 rate-limited address, got %+v", result)
+	}
+
+	if len(later) != 2 {
+		t.Fatalf("Expected 2 calls after the rate limit, got %d", len(later))
+	}
+	for i, started := range later {
+		if waited := started.Sub(limitedAt); waited < cooldown {
+			t.Errorf("Call %d started %s after the rate limit; want at least %s", i+3, waited, cooldown)
+		}
+	}
+}
+
+func TestSyncLimiterCancelledWhileWaiting(t *testing.T) {
+	l := newSyncLimiter(1, 0)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	// Waiting for the only slot
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire while the slot is taken = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	// Waiting out a rate limit cooldown
+	l.release(&RateLimitError{RetryAfter: time.Hour})
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if err := l.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire during the cooldown = %v; want %v", err, context.Canceled)
+	}
+
+	// Neither cancelled wait kept the slot
+	if len(l.slots) != 0 {
+		t.Errorf("Expected the slot to be free after cancelled waits, %d taken", len(l.slots))
+	}
+}
+
+func TestSyncLimiterRateLimitWithoutRetryHint(t *testing.T) {
+	l := newSyncLimiter(1, 0)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	before := time.Now()
+	l.release(fmt.Errorf("failed to fetch: %w", &RateLimitError{}))
+
+	if wait := l.next.Sub(before); wait < defaultRateLimitCooldown {
+		t.Errorf("Cooldown without a retry hint = %s; want at least %s", wait, defaultRateLimitCooldown)
+	}
+}
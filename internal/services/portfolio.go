@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// ErrCurrencyUnsupported is returned when a portfolio history is requested in a currency without price data
+var ErrCurrencyUnsupported = errors.New("no price history is available for this currency")
+
+// ErrHistoryRangeTooLarge is returned when a portfolio history would have too many points
+var ErrHistoryRangeTooLarge = errors.New("history range too large")
+
+// maxPortfolioPoints bounds the number of buckets a single portfolio history may return
+const maxPortfolioPoints = 1000
+
+// GetPortfolioHistory returns the total balance across tracked addresses at the end of each day,
+// week or month bucket between from and to. Snapshots are only recorded when a balance changes,
+// so each address contributes its latest snapshot at or before a bucket's end; addresses without
+// one yet, such as those added mid-period, are left out of that bucket. ownedOnly leaves
+// watch-only addresses out. Values are in BTC, the only currency with price data.
+func (s *BitcoinService) GetPortfolioHistory(from, to time.Time, interval, currency string, ownedOnly bool) (*models.PortfolioHistory, error) {
+	if s.snapshots == nil {
+		return nil, ErrHistoryDisabled
+	}
+	if currency = strings.ToUpper(currency); currency == "" {
+		currency = models.CurrencyBTC
+	}
+	if currency != models.CurrencyBTC {
+		return nil, fmt.Errorf("%w: %s", ErrCurrencyUnsupported, currency)
+	}
+
+	from, to = from.UTC(), to.UTC()
+	var ends []time.Time
+	var periods []string
+	for start := bucketStart(from, interval); !start.After(to); start = nextBucket(start, interval) {
+		if len(periods) == maxPortfolioPoints {
+			return nil, fmt.Errorf("%w: more than %d %s buckets", ErrHistoryRangeTooLarge, maxPortfolioPoints, interval)
+		}
+		periods = append(periods, bucketPeriod(start, interval))
+		// Balances are taken just before the next bucket starts, or at to for the last one
+		end := nextBucket(start, interval).Add(-time.Nanosecond)
+		if end.After(to) {
+			end = to
+		}
+		ends = append(ends, end)
+	}
+
+	points := make([]models.PortfolioPoint, len(periods))
+	for i := range points {
+		points[i] = models.PortfolioPoint{Period: periods[i], Timestamp: ends[i]}
+	}
+
+	addresses, err := s.repo.GetAllAddresses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+	for _, addr := range addresses {
+		if ownedOnly && !addr.Owned {
+			continue
+		}
+
+		// Snapshots before from carry the balance into the first bucket
+		snapshots, err := s.snapshots.GetSnapshots(addr.Address, time.Time{}, to)
+		if err != nil {
+			return nil, err
+		}
+
+		next := 0
+		var latest *models.BalanceSnapshot
+		for i := range points {
+			for next < len(snapshots) && !snapshots[next].Timestamp.After(points[i].Timestamp) {
+				latest = &snapshots[next]
+				next++
+			}
+			if latest == nil {
+				continue
+			}
+			points[i].AddressCount++
+			points[i].ConfirmedBalance += latest.ConfirmedBalance
+			points[i].UnconfirmedBalance += latest.UnconfirmedBalance
+			points[i].TotalBalance += latest.TotalBalance
+		}
+	}
+
+	for i := range points {
+		points[i].BalanceBTC = models.SatoshisToBTC(points[i].TotalBalance)
+	}
+
+	return &models.PortfolioHistory{
+		From:      from,
+		To:        to,
+		Interval:  interval,
+		Currency:  currency,
+		OwnedOnly: ownedOnly,
+		Points:    points,
+	}, nil
+}
+
+// bucketStart returns the start of the UTC day, Monday-based week or month containing t
+func bucketStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case models.VolumeBucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case models.VolumeBucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// nextBucket returns the start of the bucket after the one starting at start
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case models.VolumeBucketWeek:
+		return start.AddDate(0, 0, 7)
+	case models.VolumeBucketMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// bucketPeriod names a bucket the way volume reports do
+func bucketPeriod(start time.Time, interval string) string {
+	if interval == models.VolumeBucketMonth {
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/requestid"
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrRecalculationRunning is returned when a recalculation is started while another is still running
+var ErrRecalculationRunning = errors.New("a balance recalculation is already running")
+
+// ErrNoRecalculation is returned when the recalculation status is requested before any was started
+var ErrNoRecalculation = errors.New("no balance recalculation has been started")
+
+// recalculationJob tracks the latest background recalculation
+type recalculationJob struct {
+	mu     sync.Mutex
+	latest *models.RecalculateJob
+}
+
+// StartRecalculation runs RecalculateBalances in the background and returns the job it started.
+// Only one recalculation runs at a time; while one is running, its job is returned with ErrRecalculationRunning.
+func (s *BitcoinService) StartRecalculation(ctx context.Context) (*models.RecalculateJob, error) {
+	s.recalculation.mu.Lock()
+	defer s.recalculation.mu.Unlock()
+
+	if latest := s.recalculation.latest; latest != nil && latest.Status == models.RecalculationRunning {
+		job := *latest
+		return &job, ErrRecalculationRunning
+	}
+
+	job := &models.RecalculateJob{Status: models.RecalculationRunning, StartedAt: time.Now().UTC()}
+	s.recalculation.latest = job
+	started := *job
+
+	s.goBackground(ctx, func(ctx context.Context) {
+		result, err := s.RecalculateBalances(ctx)
+		if err != nil {
+			fmt.Printf("%sWarning: balance recalculation failed: %v\n", requestid.Prefix(ctx), err)
+		}
+
+		finished := time.Now().UTC()
+		s.recalculation.mu.Lock()
+		defer s.recalculation.mu.Unlock()
+		job.FinishedAt = &finished
+		job.Result = result
+		job.Status = models.RecalculationCompleted
+		if err != nil {
+			job.Status = models.RecalculationFailed
+			job.Error = err.Error()
+		}
+	})
+
+	return &started, nil
+}
+
+// GetRecalculation returns the latest recalculation job, running or finished
+func (s *BitcoinService) GetRecalculation() (*models.RecalculateJob, error) {
+	s.recalculation.mu.Lock()
+	defer s.recalculation.mu.Unlock()
+
+	if s.recalculation.latest == nil {
+		return nil, ErrNoRecalculation
+	}
+	job := *s.recalculation.latest
+	return &job, nil
+}
+
+// RecalculateBalances recomputes every tracked address's balance from its stored transactions and
+// compares it with the provider's authoritative balance. Addresses that disagree are resynced and
+// recomputed, and the result reports which balances changed and whether they now match.
+// An error means the run stopped early; the result still covers the addresses checked so far.
+func (s *BitcoinService) RecalculateBalances(ctx context.Context) (result *models.RecalculateResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.RecalculateBalances")
+	defer func() { tracing.End(span, err) }()
+
+	addresses, err := traceRepo(ctx, s.tracer, "GetAllAddresses", s.repo.GetAllAddresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	result = &models.RecalculateResult{
+		Repairs: []models.BalanceRepair{},
+		Failed:  map[string]string{},
+	}
+	for _, addr := range addresses {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		repair, err := s.recalculateBalance(ctx, addr.Address)
+		result.Checked++
+		if err != nil {
+			result.Failed[addr.Address] = err.Error()
+			continue
+		}
+		if repair == nil {
+			continue
+		}
+
+		result.Drifted++
+		if repair.After != repair.Before {
+			result.Changed++
+		}
+		result.Repairs = append(result.Repairs, *repair)
+	}
+
+	return result, nil
+}
+
+// recalculateBalance compares one address's stored balance with the provider and resyncs it when they
+// differ; it returns nil when they already agree
+func (s *BitcoinService) recalculateBalance(ctx context.Context, address string) (*models.BalanceRepair, error) {
+	refresh := func() (*models.Balance, error) { return s.repo.RefreshBalance(address) }
+
+	// Recomputing also repairs a cached balance that drifted from the stored transactions
+	before, err := traceRepo(ctx, s.tracer, "RefreshBalance", refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	_, span := s.tracer.Start(ctx, "provider.GetBalance", trace.WithSpanKind(trace.SpanKindClient))
+	provider, err := s.client.GetBalance(ctx, address)
+	tracing.End(span, err)
+	s.limiter.release(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance from API: %w", err)
+	}
+
+	if before.TotalBalance == provider.TotalBalance {
+		return nil, nil
+	}
+
+	if err := s.SyncAddress(ctx, address); err != nil {
+		return nil, fmt.Errorf("failed to resync drifted balance: %w", err)
+	}
+	// Read back through the primary, which a replica may not have caught up with
+	after, err := traceRepo(ctx, s.tracer, "RefreshBalance", refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BalanceRepair{
+		Address:  address,
+		Before:   before.TotalBalance,
+		After:    after.TotalBalance,
+		Provider: provider.TotalBalance,
+		Resolved: after.TotalBalance == provider.TotalBalance,
+	}, nil
+}
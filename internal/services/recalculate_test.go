@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// providerClient reports the provider balances set by the test and fails the balance lookups of failing
+type providerClient struct {
+	*fakeClient
+	balances map[string]int64
+	failing  string
+	// release, when set, holds every balance lookup until it is closed or the request is cancelled
+	release chan struct{}
+}
+
+func (c *providerClient) GetBalance(ctx context.Context, address string) (*models.Balance, error) {
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if address == c.failing {
+		return nil, errors.New("provider unavailable")
+	}
+	return &models.Balance{Address: address, TotalBalance: c.balances[address]}, nil
+}
+
+func TestRecalculateBalancesRepairsMismatch(t *testing.T) {
+	const drifted = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	const matching = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	repo := newTestRepository(t, drifted, matching)
+	client := &providerClient{fakeClient: &fakeClient{}, balances: map[string]int64{drifted: 150000}}
+	s := NewBitcoinService(repo, client)
+
+	// The provider knows a transaction the stored history is missing
+	now := time.Now().UTC().Truncate(time.Second)
+	client.setTransactions(drifted, minedAt(800010,
+		models.Transaction{Hash: "aa", Address: drifted, Amount: 150000, BlockHeight: 800000, Timestamp: now, Type: "received"}))
+
+	result, err := s.RecalculateBalances(context.Background())
+	if err != nil {
+		t.Fatalf("RecalculateBalances failed: %v", err)
+	}
+	if result.Checked != 2 || result.Drifted != 1 || result.Changed != 1 || len(result.Failed) != 0 {
+		t.Fatalf("Expected 2 checked, 1 drifted and changed, none failed, got %+v", result)
+	}
+	want := models.BalanceRepair{Address: drifted, Before: 0, After: 150000, Provider: 150000, Resolved: true}
+	if len(result.Repairs) != 1 || result.Repairs[0] != want {
+		t.Errorf("Expected repair %+v, got %+v", want, result.Repairs)
+	}
+
+	balance, err := repo.GetBalance(drifted)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance.TotalBalance != 150000 {
+		t.Errorf("Expected the stored balance to match the provider, got %d", balance.TotalBalance)
+	}
+}
+
+func TestRecalculateBalancesContinuesPastProviderFailure(t *testing.T) {
+	addresses := []string{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+		"bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5",
+	}
+	repo := newTestRepository(t, addresses...)
+	client := &providerClient{fakeClient: &fakeClient{}, balances: map[string]int64{}, failing: addresses[1]}
+	s := NewBitcoinService(repo, client)
+
+	// Every other address drifted, so each is repaired around the failure
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, addr := range []string{addresses[0], addresses[2]} {
+		tx := models.Transaction{Hash: string(rune('a' + i)), Address: addr, Amount: 1000, BlockHeight: 800000, Timestamp: now, Type: "received"}
+		client.setTransactions(addr, minedAt(800010, tx))
+		client.balances[addr] = 1000
+	}
+
+	result, err := s.RecalculateBalances(context.Background())
+	if err != nil {
+		t.Fatalf("RecalculateBalances failed: %v", err)
+	}
+	if result.Checked != 3 || result.Drifted != 2 || len(result.Repairs) != 2 {
+		t.Errorf("Expected 3 checked and 2 repaired, got %+v", result)
+	}
+	if len(result.Failed) != 1 || result.Failed[addresses[1]] == "" {
+		t.Errorf("Expected only %s to fail, got %v", addresses[1], result.Failed)
+	}
+	for _, repair := range result.Repairs {
+		if !repair.Resolved {
+			t.Errorf("Expected %s to be resolved, got %+v", repair.Address, repair)
+		}
+	}
+}
+
+// waitForRecalculation polls the latest recalculation until it is no longer running
+func waitForRecalculation(t *testing.T, s *BitcoinService) *models.RecalculateJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := s.GetRecalculation()
+		if err != nil {
+			t.Fatalf("GetRecalculation failed: %v", err)
+		}
+		if job.Status != models.RecalculationRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected the recalculation to finish")
+	return nil
+}
+
+func TestStartRecalculation(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	client := &providerClient{fakeClient: &fakeClient{}, release: make(chan struct{})}
+	s := NewBitcoinService(repo, client)
+
+	if _, err := s.GetRecalculation(); !errors.Is(err, ErrNoRecalculation) {
+		t.Fatalf("Expected ErrNoRecalculation before any run, got %v", err)
+	}
+
+	job, err := s.StartRecalculation(context.Background())
+	if err != nil {
+		t.Fatalf("StartRecalculation failed: %v", err)
+	}
+	if job.Status != models.RecalculationRunning || job.FinishedAt != nil {
+		t.Errorf("Expected a running job, got %+v", job)
+	}
+
+	// The request that started the run has finished; the run has not
+	if _, err := s.StartRecalculation(context.Background()); !errors.Is(err, ErrRecalculationRunning) {
+		t.Errorf("Expected ErrRecalculationRunning while a run is in progress, got %v", err)
+	}
+
+	close(client.release)
+	job = waitForRecalculation(t, s)
+	if job.Status != models.RecalculationCompleted || job.FinishedAt == nil || job.Error != "" {
+		t.Errorf("Expected a completed job, got %+v", job)
+	}
+	if job.Result == nil || job.Result.Checked != 1 {
+		t.Errorf("Expected the result to cover the tracked address, got %+v", job.Result)
+	}
+
+	// A finished run can be followed by another
+	if _, err := s.StartRecalculation(context.Background()); err != nil {
+		t.Errorf("Expected a new run to start after the last finished, got %v", err)
+	}
+	waitForRecalculation(t, s)
+}
+
+func TestRecalculationStopsWithLifecycle(t *testing.T) {
+	addresses := []string{"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"}
+	repo := newTestRepository(t, addresses...)
+	client := &providerClient{fakeClient: &fakeClient{}, release: make(chan struct{})}
+	lifecycle, shutdown := context.WithCancel(context.Background())
+	s := NewBitcoinService(repo, client, WithLifecycle(lifecycle))
+
+	if _, err := s.StartRecalculation(context.Background()); err != nil {
+		t.Fatalf("StartRecalculation failed: %v", err)
+	}
+	shutdown()
+	s.Wait()
+
+	job, err := s.GetRecalculation()
+	if err != nil {
+		t.Fatalf("GetRecalculation failed: %v", err)
+	}
+	if job.Status != models.RecalculationFailed || job.Error != context.Canceled.Error() || job.FinishedAt == nil {
+		t.Errorf("Expected shutdown to fail the run, got %+v", job)
+	}
+	if job.Result == nil || job.Result.Checked == len(addresses) {
+		t.Errorf("Expected the partial result of the interrupted run, got %+v", job.Result)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ihladush/bitcoin/internal/address"
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// transactionHashLength is the length of a transaction hash in hex
+const transactionHashLength = 64
+
+// Search finds stored data matching query by what the query looks like: a transaction hash finds
+// that transaction for every tracked address involved, a valid address on this network finds that
+// address, and anything else finds addresses whose label or address contains it. Archived
+// transactions are searched too; limit bounds the label matches.
+func (s *BitcoinService) Search(query string, limit int) (*models.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	result := &models.SearchResult{Query: query}
+
+	switch {
+	case isTransactionHash(query):
+		result.Kind = models.SearchKindTransaction
+		transactions, err := s.repo.QueryTransactions(models.TransactionFilter{
+			Hash:           strings.ToLower(query),
+			IncludeArchive: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search transactions: %w", err)
+		}
+		result.Transactions = transactions
+	case s.isAddress(query):
+		result.Kind = models.SearchKindAddress
+		addr, err := s.repo.GetAddress(address.Normalize(query))
+		if err != nil && !errors.Is(err, ErrAddressNotFound) {
+			return nil, err
+		}
+		if addr != nil {
+			result.Addresses = []models.Address{*addr}
+		}
+	default:
+		result.Kind = models.SearchKindLabel
+		addresses, err := s.repo.SearchAddresses(query, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.Addresses = addresses
+	}
+
+	return result, nil
+}
+
+// isTransactionHash reports whether query is a transaction hash in hex, in either case
+func isTransactionHash(query string) bool {
+	if len(query) != transactionHashLength {
+		return false
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isAddress reports whether query is a valid address on the service's network
+func (s *BitcoinService) isAddress(query string) bool {
+	_, err := address.Validate(address.Normalize(query), s.network)
+	return err == nil
+}
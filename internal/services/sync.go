@@ -0,0 +1,705 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/clients"
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/requestid"
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultInsertBatchSize is how many transactions sync writes per database transaction by default
+const defaultInsertBatchSize = 500
+
+// defaultSyncConcurrency is the number of concurrent provider calls allowed when no limits are configured
+const defaultSyncConcurrency = 2
+
+// ErrSyncPaused is returned when syncing an address whose syncing is paused
+var ErrSyncPaused = errors.New("sync is paused for address")
+
+// PauseSync stops background and manual syncs of a tracked address until ResumeSync is called
+func (s *BitcoinService) PauseSync(address string) (*models.Address, error) {
+	return s.repo.SetSyncEnabled(address, false)
+}
+
+// ResumeSync lets a paused address be synced again
+func (s *BitcoinService) ResumeSync(address string) (*models.Address, error) {
+	return s.repo.SetSyncEnabled(address, true)
+}
+
+// SyncAddress synchronizes transaction data for a specific address, stopping before any writes once ctx ends.
+// Every sync of a tracked address is recorded in its sync log.
+func (s *BitcoinService) SyncAddress(ctx context.Context, address string) error {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.SyncAddress", addressAttribute(address))
+
+	// Verify address exists in our tracking
+	tracked, err := s.repo.GetAddress(address)
+	if err != nil {
+		err = fmt.Errorf("address not being tracked: %w", err)
+		tracing.End(span, err)
+		return err
+	}
+	if !tracked.SyncEnabled {
+		err = fmt.Errorf("%w: %s", ErrSyncPaused, address)
+		tracing.End(span, err)
+		return err
+	}
+
+	started := time.Now()
+	preview, err := s.syncAddress(ctx, tracked)
+	tracing.End(span, err)
+	s.logSync(ctx, address, started, preview, err)
+	return err
+}
+
+// logSync records the outcome of an address sync and prunes entries past the retention period.
+// The log is best effort, so failures only produce warnings.
+func (s *BitcoinService) logSync(ctx context.Context, address string, started time.Time, preview *models.SyncPreview, syncErr error) {
+	entry := models.SyncLogEntry{
+		Address:    address,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Status:     models.SyncStatusSynced,
+	}
+	if preview != nil {
+		entry.NewTransactions = len(preview.New)
+		entry.UpdatedTransactions = len(preview.Updated)
+	}
+	if syncErr != nil {
+		entry.Status = models.SyncStatusFailed
+		entry.Error = syncErr.Error()
+	}
+
+	if err := s.repo.AddSyncLogEntry(entry); err != nil {
+		fmt.Printf("%sWarning: failed to record sync log for address %s: %v\n", requestid.Prefix(ctx), address, err)
+		return
+	}
+
+	if s.syncLogRetention > 0 {
+		if _, err := s.repo.PruneSyncLog(time.Now().Add(-s.syncLogRetention)); err != nil {
+			fmt.Printf("%sWarning: failed to prune sync log: %v\n", requestid.Prefix(ctx), err)
+		}
+	}
+}
+
+// syncAddress performs the sync of one tracked address, returning the changes it applied.
+// On failure the changes are returned only if they were saved before the error.
+func (s *BitcoinService) syncAddress(ctx context.Context, tracked *models.Address) (*models.SyncPreview, error) {
+	address := tracked.Address
+
+	// Fetch transactions from blockchain API
+	transactions, err := s.fetchTransactions(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work out which transactions are new or changed
+	preview, err := s.planSync(ctx, address, transactions)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Label mining rewards the provider listing could not flag
+	if err := s.detectCoinbase(ctx, preview.New); err != nil {
+		return nil, err
+	}
+
+	// Save new and updated transactions to database in batches
+	if err := s.saveChanges(ctx, preview); err != nil {
+		return nil, err
+	}
+
+	// Enforce the per-address history cap
+	if err := s.pruneHistory(address, len(transactions)); err != nil {
+		return preview, err
+	}
+
+	// Recompute the balance so cached balance reads reflect this sync
+	balance, err := s.repo.RefreshBalance(address)
+	if err != nil {
+		return preview, fmt.Errorf("failed to refresh balance: %w", err)
+	}
+
+	// Update last synced time
+	if err := s.repo.UpdateLastSynced(address, time.Now()); err != nil {
+		return preview, fmt.Errorf("failed to update last synced time: %w", err)
+	}
+
+	// Wake long-polling watchers when the sync changed the balance
+	if len(preview.New) > 0 || len(preview.Updated) > 0 {
+		s.broadcaster.publish(address, *balance)
+	}
+
+	s.confirmations.notify(ctx, preview.Confirmed)
+
+	if err := s.refreshStats(ctx, address); err != nil {
+		// Provider statistics are informational; the sync itself succeeded
+		fmt.Printf("%sWarning: failed to refresh provider stats for address %s: %v\n", requestid.Prefix(ctx), address, err)
+	}
+
+	if err := s.recordSnapshot(address, balance); err != nil {
+		// History is best effort; the sync itself succeeded
+		fmt.Printf("%sWarning: failed to record balance snapshot for address %s: %v\n", requestid.Prefix(ctx), address, err)
+	}
+
+	if err := s.updateBalanceGauge(tracked, balance); err != nil {
+		fmt.Printf("%sWarning: failed to update balance gauge for address %s: %v\n", requestid.Prefix(ctx), address, err)
+	}
+
+	fmt.Printf("%sSynced %d new and %d updated transactions for address %s\n", requestid.Prefix(ctx), len(preview.New), len(preview.Updated), address)
+	return preview, nil
+}
+
+// saveChanges writes the new and updated transactions of a sync plan in batches
+func (s *BitcoinService) saveChanges(ctx context.Context, preview *models.SyncPreview) (err error) {
+	_, span := s.tracer.Start(ctx, "repository.SaveTransactions")
+	defer func() { tracing.End(span, err) }()
+
+	changed := append(append([]models.Transaction{}, preview.New...), preview.Updated...)
+	span.SetAttributes(attribute.Int("bitcoin.transactions", len(changed)))
+	for start := 0; start < len(changed); start += s.insertBatchSize {
+		end := min(start+s.insertBatchSize, len(changed))
+		if err := s.repo.SaveTransactions(changed[start:end]); err != nil {
+			return fmt.Errorf("failed to save transactions: %w", err)
+		}
+	}
+	return nil
+}
+
+// ImportTransactions maps raw provider transaction JSON with the client and stores it as a sync of
+// address would, without contacting the provider. Coinbase detection and provider statistics need
+// live access and are skipped, and the last sync time is left unchanged.
+func (s *BitcoinService) ImportTransactions(address string, raw []byte) (*models.SyncPreview, error) {
+	if _, err := s.repo.GetAddress(address); err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+
+	importer, ok := s.client.(clients.TransactionImporter)
+	if !ok || !s.client.Capabilities().TransactionImport {
+		return nil, ErrImportUnsupported
+	}
+	transactions, err := importer.ParseTransactions(address, raw)
+	if errors.Is(err, clients.ErrImportUnsupported) {
+		return nil, ErrImportUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	for _, tx := range transactions {
+		if err := clients.CheckTransaction(address, tx); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+	}
+
+	preview, err := s.planSync(context.Background(), address, transactions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveChanges(context.Background(), preview); err != nil {
+		return nil, err
+	}
+	if err := s.pruneHistory(address, len(transactions)); err != nil {
+		return nil, err
+	}
+	balance, err := s.repo.RefreshBalance(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh balance: %w", err)
+	}
+
+	if len(preview.New) > 0 || len(preview.Updated) > 0 {
+		s.broadcaster.publish(address, *balance)
+		if err := s.recordSnapshot(address, balance); err != nil {
+			fmt.Printf("Warning: failed to record balance snapshot for address %s: %v\n", address, err)
+		}
+	}
+
+	fmt.Printf("Imported %d new and %d updated transactions for address %s\n", len(preview.New), len(preview.Updated), address)
+	return preview, nil
+}
+
+// pruneHistory archives transactions beyond the history cap and flags the address when history was lost
+func (s *BitcoinService) pruneHistory(address string, fetched int) error {
+	if s.maxHistory <= 0 {
+		return nil
+	}
+
+	pruned, err := s.repo.PruneTransactions(address, s.maxHistory)
+	if err != nil {
+		return err
+	}
+
+	if pruned > 0 || fetched > s.maxHistory {
+		if err := s.repo.SetHistoryTruncated(address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// detectCoinbase flags the coinbase transactions among newly seen mined receipts when the client can detect them
+func (s *BitcoinService) detectCoinbase(ctx context.Context, txs []models.Transaction) error {
+	detector, ok := s.client.(clients.CoinbaseDetector)
+	if !ok || !s.client.Capabilities().CoinbaseDetection {
+		return nil
+	}
+
+	var candidates []string
+	for _, tx := range txs {
+		if tx.Amount > 0 && tx.BlockHeight > 0 && !tx.Coinbase {
+			candidates = append(candidates, tx.Hash)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if err := s.limiter.acquire(ctx); err != nil {
+		return err
+	}
+	coinbase, err := detector.DetectCoinbase(ctx, candidates)
+	s.limiter.release(err)
+	if err != nil {
+		return fmt.Errorf("failed to detect coinbase transactions: %w", err)
+	}
+
+	for i := range txs {
+		if coinbase[txs[i].Hash] {
+			txs[i].Coinbase = true
+		}
+	}
+	return nil
+}
+
+// refreshStats stores the provider's address statistics when the client reports them
+func (s *BitcoinService) refreshStats(ctx context.Context, address string) error {
+	provider, ok := s.client.(clients.AddressStatsProvider)
+	if !ok || !s.client.Capabilities().AddressStats {
+		return nil
+	}
+
+	if err := s.limiter.acquire(ctx); err != nil {
+		return err
+	}
+	stats, err := provider.GetAddressStats(ctx, address)
+	s.limiter.release(err)
+	if err != nil || stats == nil {
+		return err
+	}
+
+	return s.repo.UpdateAddressStats(address, *stats)
+}
+
+// recordSnapshot stores balance, as just refreshed by a sync, in the snapshot store if it differs from the last snapshot
+func (s *BitcoinService) recordSnapshot(address string, balance *models.Balance) error {
+	if s.snapshots == nil {
+		return nil
+	}
+
+	latest, err := s.snapshots.LatestSnapshot(address)
+	if err != nil {
+		return err
+	}
+	if latest != nil &&
+		latest.ConfirmedBalance == balance.ConfirmedBalance &&
+		latest.UnconfirmedBalance == balance.UnconfirmedBalance {
+		return nil
+	}
+
+	return s.snapshots.SaveSnapshot(models.BalanceSnapshot{
+		Address:            address,
+		Timestamp:          time.Now(),
+		ConfirmedBalance:   balance.ConfirmedBalance,
+		UnconfirmedBalance: balance.UnconfirmedBalance,
+		TotalBalance:       balance.TotalBalance,
+	})
+}
+
+// updateBalanceGauge publishes the address's refreshed balance to the per-address gauges when they are enabled
+func (s *BitcoinService) updateBalanceGauge(addr *models.Address, refreshed *models.Balance) error {
+	if s.balanceGauges == nil {
+		return nil
+	}
+
+	balance := *refreshed
+	s.applyMaturity(&balance)
+	s.balanceGauges.Set(addr.Address, addr.Label, balance.TotalBalance)
+	return nil
+}
+
+// PreviewSync fetches transactions from the provider and reports what SyncAddress would change, without writing
+func (s *BitcoinService) PreviewSync(ctx context.Context, address string) (*models.SyncPreview, error) {
+	// Verify address exists in our tracking
+	tracked, err := s.repo.GetAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("address not being tracked: %w", err)
+	}
+	if !tracked.SyncEnabled {
+		return nil, fmt.Errorf("%w: %s", ErrSyncPaused, address)
+	}
+
+	transactions, err := s.fetchTransactions(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.planSync(ctx, address, transactions)
+}
+
+// fetchTransactions retrieves recent transactions from the provider within the shared sync limits
+func (s *BitcoinService) fetchTransactions(ctx context.Context, address string) ([]models.Transaction, error) {
+	if err := s.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	_, span := s.tracer.Start(ctx, "provider.GetTransactions", trace.WithSpanKind(trace.SpanKindClient))
+	transactions, err := s.client.GetTransactions(ctx, address, 100)
+	tracing.End(span, err)
+	s.limiter.release(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions from API: %w", err)
+	}
+
+	// A response can still race the deadline, so drop results that arrive too late
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// planSync diffs fetched transactions against stored ones using two set-based queries
+// rather than a lookup per transaction. With a final depth configured, stored transactions
+// buried at least that deep are never compared or rewritten.
+func (s *BitcoinService) planSync(ctx context.Context, address string, fetched []models.Transaction) (*models.SyncPreview, error) {
+	preview := &models.SyncPreview{
+		Address: address,
+		New:     []models.Transaction{},
+		Updated: []models.Transaction{},
+	}
+
+	_, span := s.tracer.Start(ctx, "repository.GetExistingHashes")
+	existing, err := s.repo.GetExistingHashes(address)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a final depth, or a tip to measure it from, every stored transaction may still change
+	tip := chainTip(fetched)
+	depth := s.finalDepth
+	if depth <= 0 || tip == 0 {
+		depth = math.MaxInt32
+	}
+	stored, err := s.repo.GetNonFinalTransactions(address, depth, tip)
+	if err != nil {
+		return nil, err
+	}
+	nonFinal := make(map[string]*models.Transaction, len(stored))
+	for i := range stored {
+		nonFinal[stored[i].Hash] = &stored[i]
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, tx := range fetched {
+		switch {
+		case !existing[tx.Hash]:
+			preview.New = append(preview.New, tx)
+		case nonFinal[tx.Hash] == nil:
+			// Final transactions are immutable
+			preview.Unchanged++
+		case s.transactionChanged(nonFinal[tx.Hash], &tx):
+			// Coinbase labels come from a separate lookup made when the transaction was first seen
+			tx.Coinbase = tx.Coinbase || nonFinal[tx.Hash].Coinbase
+			preview.Updated = append(preview.Updated, tx)
+			if s.confirmations.reached(nonFinal[tx.Hash], &tx) {
+				preview.Confirmed = append(preview.Confirmed, models.ConfirmationAlert{
+					Address:               address,
+					Target:                s.confirmations.target,
+					PreviousConfirmations: nonFinal[tx.Hash].Confirmations,
+					Transaction:           tx,
+				})
+			}
+		default:
+			preview.Unchanged++
+		}
+	}
+
+	return preview, nil
+}
+
+// chainTip estimates the chain height from the provider's confirmation counts.
+// Providers that under-report confirmations yield a lower tip, which only makes more transactions non-final.
+func chainTip(transactions []models.Transaction) int {
+	tip := 0
+	for _, tx := range transactions {
+		if tx.BlockHeight > 0 && tx.Confirmations > 0 {
+			tip = max(tip, tx.BlockHeight+tx.Confirmations-1)
+		}
+	}
+	return tip
+}
+
+// transactionChanged reports whether the provider's view of a transaction differs from the stored one.
+// Once a mined transaction is past every depth sync acts on, a new block only raises its confirmation
+// count, which is not treated as a change, so each block does not rewrite the whole history.
+func (s *BitcoinService) transactionChanged(stored, fetched *models.Transaction) bool {
+	if stored.BlockHeight != fetched.BlockHeight || stored.Amount != fetched.Amount {
+		return true
+	}
+	if stored.Confirmations == fetched.Confirmations {
+		return false
+	}
+	settled := s.settledDepth(stored)
+	return stored.Confirmations < settled || fetched.Confirmations < settled
+}
+
+// settledDepth returns the confirmation count past which further confirmations of tx change nothing
+// sync depends on: its confirmed status, the confirmation alert, its finality and, for a coinbase
+// reward, its maturity
+func (s *BitcoinService) settledDepth(tx *models.Transaction) int {
+	depth := max(s.confirmedDepth, s.finalDepth, 1)
+	if s.confirmations.target > 0 && s.confirmations.notifier != nil {
+		depth = max(depth, s.confirmations.target)
+	}
+	if tx.Coinbase {
+		depth = max(depth, models.CoinbaseMaturity)
+	}
+	return depth
+}
+
+// ErrSyncBudgetExhausted is returned when a sync run stops early because its time or failure budget ran out
+var ErrSyncBudgetExhausted = errors.New("sync run budget exhausted")
+
+// SyncAllAddresses synchronizes all tracked addresses until done, ctx ends or the run budget is exhausted.
+// The result lists every address as synced, failed or skipped, even when an error is returned.
+// Progress is checkpointed, so a run cut short by an error or a restart is resumed by the next one,
+// which only syncs the addresses not synced since the unfinished run started.
+// Consecutive failed runs are tracked by SyncHealth.
+func (s *BitcoinService) SyncAllAddresses(ctx context.Context) (*models.SyncRunResult, error) {
+	ctx, span := s.tracer.Start(ctx, "BitcoinService.SyncAllAddresses")
+	result, err := s.syncAll(ctx)
+	tracing.End(span, err)
+	s.health.record(err)
+	return result, err
+}
+
+// syncAll performs one checkpointed full sync run
+func (s *BitcoinService) syncAll(ctx context.Context) (*models.SyncRunResult, error) {
+	checkpoint, err := s.repo.GetSyncCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	var resumedFrom *time.Time
+	if checkpoint != nil {
+		resumedFrom = &checkpoint.RunStartedAt
+	} else {
+		checkpoint = &models.SyncCheckpoint{RunStartedAt: time.Now()}
+	}
+	checkpoint.UpdatedAt = time.Now()
+	if err := s.repo.SaveSyncCheckpoint(*checkpoint); err != nil {
+		return nil, err
+	}
+
+	pending, err := s.repo.GetAddressesSyncedBefore(checkpoint.RunStartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses for sync: %w", err)
+	}
+
+	addresses := scheduleSync(pending, time.Now(), s.syncBatchSize)
+
+	result, err := s.runSync(ctx, addresses, func(address string) {
+		checkpoint.Cursor = address
+		checkpoint.UpdatedAt = time.Now()
+		if err := s.repo.SaveSyncCheckpoint(*checkpoint); err != nil {
+			fmt.Printf("Warning: failed to checkpoint sync run: %v\n", err)
+		}
+	})
+	result.ResumedFrom = resumedFrom
+	if err != nil {
+		return result, err
+	}
+
+	// Every address was attempted, so the next run starts afresh
+	if err := s.repo.ClearSyncCheckpoint(); err != nil {
+		return result, err
+	}
+
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("sync completed with %d errors", len(result.Failed))
+	}
+
+	return result, nil
+}
+
+// scheduleSync orders addresses for a sync run by score, highest first, and keeps at most limit of
+// them when limit is positive. The score is the time since the last sync multiplied by one plus the
+// priority, so a priority 2 address is picked as if it were three times as stale. Never-synced
+// addresses come first; ties keep the least recently synced first order of pending. Since staleness
+// keeps growing, low-priority addresses are still reached by repeated runs.
+func scheduleSync(pending []models.Address, now time.Time, limit int) []string {
+	scores := make(map[string]float64, len(pending))
+	for _, addr := range pending {
+		score := math.Inf(1)
+		if addr.LastSynced != nil {
+			score = now.Sub(*addr.LastSynced).Seconds() * float64(1+addr.Priority)
+		}
+		scores[addr.Address] = score
+	}
+
+	ranked := slices.Clone(pending)
+	slices.SortStableFunc(ranked, func(a, b models.Address) int {
+		return cmp.Compare(scores[b.Address], scores[a.Address])
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	addresses := make([]string, len(ranked))
+	for i, addr := range ranked {
+		addresses[i] = addr.Address
+	}
+	return addresses
+}
+
+// SyncAddresses synchronizes the given addresses with the same workers and budget as a full run.
+// Untracked addresses fail individually; an error is returned only when the run itself stops early.
+func (s *BitcoinService) SyncAddresses(ctx context.Context, addresses []string) ([]models.SyncResult, error) {
+	var unique []string
+	seen := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if !seen[address] {
+			seen[address] = true
+			unique = append(unique, address)
+		}
+	}
+
+	run, runErr := s.runSync(ctx, unique, nil)
+
+	skipped := make(map[string]bool, len(run.Skipped))
+	for _, address := range run.Skipped {
+		skipped[address] = true
+	}
+
+	results := make([]models.SyncResult, len(unique))
+	for i, address := range unique {
+		result := models.SyncResult{Address: address, Status: models.SyncStatusSynced}
+		if msg, failed := run.Failed[address]; failed {
+			result.Status, result.Error = models.SyncStatusFailed, msg
+		} else if skipped[address] {
+			result.Status = models.SyncStatusSkipped
+		}
+		results[i] = result
+	}
+
+	return results, runErr
+}
+
+// runSync syncs addresses on a worker pool bounded by the limiter and the run budget, calling
+// onSynced (if set) after each successful sync. It returns an error only when the run is cut short;
+// per-address failures are in the result.
+func (s *BitcoinService) runSync(ctx context.Context, addresses []string, onSynced func(address string)) (*models.SyncRunResult, error) {
+	// Batched notifications go out once every worker has finished
+	ctx, flushAlerts := s.confirmations.collect(ctx)
+	defer flushAlerts()
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if s.syncRunTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		runCtx, cancelTimeout = context.WithTimeoutCause(runCtx, s.syncRunTimeout,
+			fmt.Errorf("%w: run exceeded %s", ErrSyncBudgetExhausted, s.syncRunTimeout))
+		defer cancelTimeout()
+	}
+
+	result := &models.SyncRunResult{
+		Synced:  []string{},
+		Failed:  map[string]string{},
+		Skipped: []string{},
+	}
+
+	// Feed addresses to as many workers as the limiter allows concurrent calls
+	jobs := make(chan string)
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		failedTime time.Duration
+	)
+	for i := 0; i < s.limiter.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range jobs {
+				// A job handed over just as the run ended is never started
+				if runCtx.Err() != nil {
+					mu.Lock()
+					result.Skipped = append(result.Skipped, address)
+					mu.Unlock()
+					continue
+				}
+
+				start := time.Now()
+				err := s.SyncAddress(runCtx, address)
+
+				mu.Lock()
+				if err != nil && runCtx.Err() != nil {
+					// Interrupted by the run ending rather than by the provider
+					result.Failed[address] = context.Cause(runCtx).Error()
+				} else if err != nil {
+					result.Failed[address] = err.Error()
+					// Stop spending time on a provider that keeps failing
+					failedTime += time.Since(start)
+					if s.syncFailureBudget > 0 && failedTime > s.syncFailureBudget {
+						cancel(fmt.Errorf("%w: failed attempts took %s", ErrSyncBudgetExhausted, failedTime.Round(time.Millisecond)))
+					}
+				} else {
+					result.Synced = append(result.Synced, address)
+					if onSynced != nil {
+						onSynced(address)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, address := range addresses {
+		select {
+		case jobs <- address:
+			continue
+		case <-runCtx.Done():
+		}
+
+		result.Skipped = append(result.Skipped, addresses[i:]...)
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	if runCtx.Err() != nil {
+		cause := context.Cause(runCtx)
+		if errors.Is(cause, ErrSyncBudgetExhausted) {
+			return result, fmt.Errorf("sync aborted with %d addresses not attempted: %w", len(result.Skipped), cause)
+		}
+		return result, fmt.Errorf("sync interrupted: %w", cause)
+	}
+
+	return result, nil
+}
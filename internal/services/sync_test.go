@@ -0,0 +1,566 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ihladush/bitcoin/internal/models"
+	"github.com/ihladush/bitcoin/internal/repository"
+)
+
+// fakeClient serves transactions set by the test, with no network access
+type fakeClient struct {
+	mu           sync.Mutex
+	transactions map[string][]models.Transaction
+	// fetch, when set, runs on every GetTransactions call and its error is returned
+	fetch func(ctx context.Context, address string) error
+}
+
+func (c *fakeClient) GetBalance(ctx context.Context, address string) (*models.Balance, error) {
+	return &models.Balance{Address: address}, nil
+}
+
+func (c *fakeClient) GetTransactions(ctx context.Context, address string, limit int) ([]models.Transaction, error) {
+	if c.fetch != nil {
+		if err := c.fetch(ctx, address); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.Transaction{}, c.transactions[address]...), nil
+}
+
+func (c *fakeClient) IsValidAddress(ctx context.Context, address string) bool {
+	return true
+}
+
+func (c *fakeClient) Capabilities() models.ClientCapabilities {
+	return models.ClientCapabilities{}
+}
+
+// setTransactions replaces the transactions served for address
+func (c *fakeClient) setTransactions(address string, txs []models.Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.transactions == nil {
+		c.transactions = make(map[string][]models.Transaction)
+	}
+	c.transactions[address] = txs
+}
+
+// newTestRepository opens a repository on a temporary database tracking addresses
+func newTestRepository(t *testing.T, addresses ...string) *repository.SQLiteRepository {
+	t.Helper()
+
+	repo, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	for _, address := range addresses {
+		if _, err := repo.AddAddress(models.Address{Address: address, SyncEnabled: true}); err != nil {
+			t.Fatalf("AddAddress failed: %v", err)
+		}
+	}
+	return repo
+}
+
+// minedAt returns the transactions as seen with the chain tip at height tip
+func minedAt(tip int, txs ...models.Transaction) []models.Transaction {
+	seen := make([]models.Transaction, len(txs))
+	for i, tx := range txs {
+		if tx.BlockHeight > 0 {
+			tx.Confirmations = tip - tx.BlockHeight + 1
+		}
+		seen[i] = tx
+	}
+	return seen
+}
+
+func TestSyncIgnoresNewBlocksPastConfirmedDepth(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	snapshots, err := repository.NewSQLiteSnapshotStore(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotStore failed: %v", err)
+	}
+	defer snapshots.Close()
+	client := &fakeClient{}
+	s := NewBitcoinService(repo, client, WithSnapshotStore(snapshots))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	deep := models.Transaction{Hash: "aa", Address: addr, Amount: 50000, BlockHeight: 800000, Timestamp: now, Type: "received"}
+	recent := models.Transaction{Hash: "bb", Address: addr, Amount: 20000, BlockHeight: 800008, Timestamp: now, Type: "received"}
+
+	client.setTransactions(addr, minedAt(800010, deep, recent))
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+
+	changed, unsubscribe := s.broadcaster.subscribe(addr)
+	defer unsubscribe()
+
+	// One more block: the deep transaction is past the confirmed depth, the recent one is not
+	client.setTransactions(addr, minedAt(800011, deep, recent))
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+
+	log, err := repo.GetSyncLog(addr, 1)
+	if err != nil || len(log) != 1 {
+		t.Fatalf("GetSyncLog = %+v, %v", log, err)
+	}
+	if log[0].NewTransactions != 0 || log[0].UpdatedTransactions != 1 {
+		t.Errorf("Sync after a new block logged %d new and %d updated; want 0 and 1", log[0].NewTransactions, log[0].UpdatedTransactions)
+	}
+
+	stored, err := repo.GetTransaction("aa", addr)
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if stored.Confirmations != 11 {
+		t.Errorf("Expected the deep transaction to be left as stored with 11 confirmations, got %d", stored.Confirmations)
+	}
+
+	select {
+	case <-changed:
+		t.Error("Expected watchers to stay asleep when the balance did not change")
+	default:
+	}
+
+	history, err := snapshots.GetSnapshots(addr, now.Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetSnapshots failed: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("Expected only the first sync to record a snapshot, got %d", len(history))
+	}
+
+	// A new transaction changes the balance and wakes watchers
+	client.setTransactions(addr, minedAt(800012, deep, recent, models.Transaction{Hash: "cc", Address: addr, Amount: 1000, Timestamp: now, Type: "received"}))
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected a balance change to wake watchers")
+	}
+}
+
+func TestFailureBudgetCancelsSyncRun(t *testing.T) {
+	addresses := []string{"addr-0", "addr-1", "addr-2", "addr-3", "addr-4"}
+	repo := newTestRepository(t, addresses...)
+
+	var (
+		mu      sync.Mutex
+		started = map[string]bool{}
+	)
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		mu.Lock()
+		started[address] = true
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		return errors.New("provider unavailable")
+	}}
+	// One worker, so the second failure takes the failed time past the budget
+	s := NewBitcoinService(repo, client, WithSyncLimits(1, 0), WithSyncBudget(0, 75*time.Millisecond))
+
+	result, err := s.SyncAllAddresses(context.Background())
+	if !errors.Is(err, ErrSyncBudgetExhausted) {
+		t.Fatalf("SyncAllAddresses error = %v; want %v", err, ErrSyncBudgetExhausted)
+	}
+	if result == nil {
+		t.Fatal("Expected the partial result of the cancelled run")
+	}
+
+	if len(result.Synced) != 0 || len(result.Failed) != 2 || len(result.Skipped) != 3 {
+		t.Errorf("Expected 2 failed and 3 skipped addresses, got %+v", result)
+	}
+	for _, address := range result.Skipped {
+		if started[address] {
+			t.Errorf("Skipped address %s was started", address)
+		}
+	}
+	if len(result.Failed)+len(result.Skipped) != len(addresses) {
+		t.Errorf("Expected every address reported once, got %+v", result)
+	}
+}
+
+func TestTimeBudgetCancelsSyncRun(t *testing.T) {
+	addresses := []string{"addr-0", "addr-1", "addr-2", "addr-3", "addr-4"}
+	repo := newTestRepository(t, addresses...)
+
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+	s := NewBitcoinService(repo, client, WithSyncLimits(1, 0), WithSyncBudget(80*time.Millisecond, 0))
+
+	result, err := s.SyncAllAddresses(context.Background())
+	if !errors.Is(err, ErrSyncBudgetExhausted) {
+		t.Fatalf("SyncAllAddresses error = %v; want %v", err, ErrSyncBudgetExhausted)
+	}
+	if result == nil {
+		t.Fatal("Expected the partial result of the cancelled run")
+	}
+
+	// The first address syncs, the second is cut off by the deadline and the rest never start
+	if len(result.Synced) != 1 || len(result.Failed) != 1 || len(result.Skipped) != 3 {
+		t.Errorf("Expected 1 synced, 1 failed and 3 skipped addresses, got %+v", result)
+	}
+	for address, reason := range result.Failed {
+		if !strings.Contains(reason, ErrSyncBudgetExhausted.Error()) {
+			t.Errorf("Failure of %s = %q; want the budget as the cause", address, reason)
+		}
+	}
+}
+
+func TestScheduleSync(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	syncedAgo := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	testCases := []struct {
+		name    string
+		pending []models.Address
+		limit   int
+		want    []string
+	}{
+		{
+			name: "high priority stale before low priority fresher",
+			pending: []models.Address{
+				{Address: "low", Priority: 0, LastSynced: syncedAgo(time.Hour)},
+				{Address: "high", Priority: 2, LastSynced: syncedAgo(2 * time.Hour)},
+			},
+			want: []string{"high", "low"},
+		},
+		{
+			// 2h x 3 outweighs 3h x 1
+			name: "priority outweighs staleness",
+			pending: []models.Address{
+				{Address: "stalest", Priority: 0, LastSynced: syncedAgo(3 * time.Hour)},
+				{Address: "high", Priority: 2, LastSynced: syncedAgo(2 * time.Hour)},
+			},
+			want: []string{"high", "stalest"},
+		},
+		{
+			name: "never synced first",
+			pending: []models.Address{
+				{Address: "high", Priority: 10, LastSynced: syncedAgo(24 * time.Hour)},
+				{Address: "new", Priority: 0},
+			},
+			want: []string{"new", "high"},
+		},
+		{
+			name: "ties keep pending order",
+			pending: []models.Address{
+				{Address: "first", LastSynced: syncedAgo(time.Hour)},
+				{Address: "second", LastSynced: syncedAgo(time.Hour)},
+			},
+			want: []string{"first", "second"},
+		},
+		{
+			name: "limit keeps the highest scores",
+			pending: []models.Address{
+				{Address: "fresh", LastSynced: syncedAgo(time.Minute)},
+				{Address: "stale", LastSynced: syncedAgo(time.Hour)},
+				{Address: "high", Priority: 1, LastSynced: syncedAgo(time.Hour)},
+			},
+			limit: 2,
+			want:  []string{"high", "stale"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scheduleSync(tc.pending, now, tc.limit); !slices.Equal(got, tc.want) {
+				t.Errorf("scheduleSync() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyncRunResumesFromCheckpoint(t *testing.T) {
+	addresses := []string{"addr-0", "addr-1", "addr-2"}
+	repo := newTestRepository(t, addresses...)
+
+	// The first run syncs addr-0 and is then interrupted, as by a shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupted := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		if address == addresses[0] {
+			return nil
+		}
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	first, err := NewBitcoinService(repo, interrupted, WithSyncLimits(1, 0)).SyncAllAddresses(ctx)
+	if err == nil {
+		t.Fatal("Expected the interrupted run to return an error")
+	}
+	if !slices.Equal(first.Synced, addresses[:1]) {
+		t.Fatalf("Interrupted run synced %v; want %v", first.Synced, addresses[:1])
+	}
+
+	// After a restart the next run picks up only what the interrupted one did not sync
+	var (
+		mu      sync.Mutex
+		fetched []string
+	)
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		mu.Lock()
+		fetched = append(fetched, address)
+		mu.Unlock()
+		return nil
+	}}
+	s := NewBitcoinService(repo, client, WithSyncLimits(1, 0))
+
+	resumed, err := s.SyncAllAddresses(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAllAddresses failed: %v", err)
+	}
+	if resumed.ResumedFrom == nil {
+		t.Error("Expected the run to report the checkpoint it resumed from")
+	}
+	if !slices.Equal(fetched, addresses[1:]) {
+		t.Errorf("Resumed run fetched %v; want %v", fetched, addresses[1:])
+	}
+
+	// The finished run cleared the checkpoint, so the next one starts afresh
+	fetched = nil
+	fresh, err := s.SyncAllAddresses(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAllAddresses failed: %v", err)
+	}
+	if fresh.ResumedFrom != nil || len(fetched) != len(addresses) {
+		t.Errorf("Expected a fresh run over all %d addresses, resumed from %v and fetched %v", len(addresses), fresh.ResumedFrom, fetched)
+	}
+}
+
+func TestHistoryCapKeepsBalanceAcrossSyncs(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	client := &fakeClient{}
+	s := NewBitcoinService(repo, client, WithMaxHistory(1))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	received := models.Transaction{Hash: "aa", Address: addr, Amount: 150000, BlockHeight: 800000, Timestamp: now.Add(-time.Hour), Type: "received"}
+	sent := models.Transaction{Hash: "bb", Address: addr, Amount: -50000, Timestamp: now, Type: "sent"}
+	client.setTransactions(addr, minedAt(800010, received, sent))
+
+	for run := 1; run <= 2; run++ {
+		if err := s.SyncAddress(context.Background(), addr); err != nil {
+			t.Fatalf("SyncAddress run %d failed: %v", run, err)
+		}
+		balance, err := repo.GetBalance(addr)
+		if err != nil {
+			t.Fatalf("GetBalance failed: %v", err)
+		}
+		if balance.TotalBalance != 100000 {
+			t.Errorf("Run %d: total balance %d; want 100000", run, balance.TotalBalance)
+		}
+	}
+
+	// The pruned receipt is archived, not fetched again as new
+	log, err := repo.GetSyncLog(addr, 1)
+	if err != nil || len(log) != 1 {
+		t.Fatalf("GetSyncLog = %+v, %v", log, err)
+	}
+	if log[0].NewTransactions != 0 {
+		t.Errorf("Second sync stored %d new transactions; want 0", log[0].NewTransactions)
+	}
+	if count, err := repo.CountTransactions(models.TransactionFilter{Address: addr}); err != nil || count != 1 {
+		t.Errorf("CountTransactions = %d (err %v); want the cap of 1", count, err)
+	}
+	stored, err := repo.GetAddress(addr)
+	if err != nil || !stored.HistoryTruncated {
+		t.Errorf("Expected the address to report truncated history, got %+v (err %v)", stored, err)
+	}
+}
+
+func TestBackgroundInitialSyncStopsWithLifecycle(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t)
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	client := &fakeClient{fetch: func(ctx context.Context, address string) error {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return ctx.Err()
+	}}
+	lifecycle, shutdown := context.WithCancel(context.Background())
+	s := NewBitcoinService(repo, client, WithInitialSyncMode(InitialSyncBackground), WithLifecycle(lifecycle))
+
+	// The request ends as soon as the address is added; its sync keeps running
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := s.AddAddress(ctx, models.AddAddressRequest{Address: addr}); err != nil {
+		t.Fatalf("AddAddress failed: %v", err)
+	}
+	cancel()
+	<-started
+	select {
+	case err := <-stopped:
+		t.Fatalf("Expected the initial sync to outlive its request, it stopped with %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	shutdown()
+	s.Wait()
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected shutdown to cancel the initial sync, got %v", err)
+	}
+}
+
+func TestSyncRecordsSnapshotsOnBalanceChange(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	store, err := repository.NewSQLiteSnapshotStore(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotStore failed: %v", err)
+	}
+	defer store.Close()
+	client := &fakeClient{}
+	s := NewBitcoinService(repo, client, WithSnapshotStore(store))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	received := models.Transaction{Hash: "aa", Address: addr, Amount: 150000, BlockHeight: 800000, Timestamp: now, Type: "received"}
+	sent := models.Transaction{Hash: "bb", Address: addr, Amount: -50000, BlockHeight: 800001, Timestamp: now, Type: "sent"}
+
+	// The second sync finds nothing new, so only the two changes are recorded
+	for _, txs := range [][]models.Transaction{{received}, {received}, {received, sent}} {
+		client.setTransactions(addr, minedAt(800010, txs...))
+		if err := s.SyncAddress(context.Background(), addr); err != nil {
+			t.Fatalf("SyncAddress failed: %v", err)
+		}
+	}
+
+	history, err := s.GetBalanceHistory(addr, now.Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetBalanceHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].TotalBalance != 150000 || history[1].TotalBalance != 100000 {
+		t.Errorf("Expected snapshots of 150000 then 100000, got %+v", history)
+	}
+
+	if _, err := s.GetBalanceHistory("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", now, now); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for an untracked address, got %v", err)
+	}
+}
+
+// hashes returns the hashes of txs, in order
+func hashes(txs []models.Transaction) []string {
+	out := []string{}
+	for _, tx := range txs {
+		out = append(out, tx.Hash)
+	}
+	return out
+}
+
+func TestPreviewSyncMatchesSync(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	client := &fakeClient{}
+	s := NewBitcoinService(repo, client, WithFinalDepth(6))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	deep := models.Transaction{Hash: "aa", Address: addr, Amount: 50000, BlockHeight: 800000, Timestamp: now, Type: "received"}
+	pending := models.Transaction{Hash: "bb", Address: addr, Amount: 20000, Timestamp: now, Type: "received"}
+	client.setTransactions(addr, minedAt(800010, deep, pending))
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+
+	// The pending transaction is mined and a new one arrives; the final one is never compared again,
+	// even when the provider reports it differently
+	changedDeep := deep
+	changedDeep.Amount = 1
+	mined := pending
+	mined.BlockHeight = 800011
+	fresh := models.Transaction{Hash: "cc", Address: addr, Amount: 1000, Timestamp: now, Type: "received"}
+	client.setTransactions(addr, minedAt(800011, changedDeep, mined, fresh))
+
+	preview, err := s.PreviewSync(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("PreviewSync failed: %v", err)
+	}
+	if got := hashes(preview.New); !slices.Equal(got, []string{"cc"}) {
+		t.Errorf("Expected cc to be new, got %v", got)
+	}
+	if got := hashes(preview.Updated); !slices.Equal(got, []string{"bb"}) {
+		t.Errorf("Expected bb to be updated, got %v", got)
+	}
+	if preview.Unchanged != 1 {
+		t.Errorf("Expected the final transaction to be unchanged, got %d", preview.Unchanged)
+	}
+
+	// The preview wrote nothing
+	existing, err := repo.GetExistingHashes(addr)
+	if err != nil {
+		t.Fatalf("GetExistingHashes failed: %v", err)
+	}
+	if existing["cc"] {
+		t.Error("Expected a preview not to store new transactions")
+	}
+	if stored, err := repo.GetTransaction("bb", addr); err != nil || stored.BlockHeight != 0 {
+		t.Errorf("Expected a preview not to update stored transactions, got %+v (err %v)", stored, err)
+	}
+
+	// The sync applies exactly what the preview reported
+	if err := s.SyncAddress(context.Background(), addr); err != nil {
+		t.Fatalf("SyncAddress failed: %v", err)
+	}
+	log, err := repo.GetSyncLog(addr, 1)
+	if err != nil || len(log) != 1 {
+		t.Fatalf("GetSyncLog = %+v, %v", log, err)
+	}
+	if log[0].NewTransactions != len(preview.New) || log[0].UpdatedTransactions != len(preview.Updated) {
+		t.Errorf("Expected the sync to apply %d new and %d updated, got %+v", len(preview.New), len(preview.Updated), log[0])
+	}
+	if stored, err := repo.GetTransaction("aa", addr); err != nil || stored.Amount != deep.Amount {
+		t.Errorf("Expected the final transaction to keep its amount, got %+v (err %v)", stored, err)
+	}
+
+	preview, err = s.PreviewSync(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("PreviewSync failed: %v", err)
+	}
+	if len(preview.New) != 0 || len(preview.Updated) != 0 || preview.Unchanged != 3 {
+		t.Errorf("Expected nothing left to sync, got %+v", preview)
+	}
+}
+
+func TestPreviewSyncRejectsUntrackedAndPaused(t *testing.T) {
+	const addr = "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	repo := newTestRepository(t, addr)
+	s := NewBitcoinService(repo, &fakeClient{})
+
+	if _, err := s.PreviewSync(context.Background(), "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for an untracked address, got %v", err)
+	}
+
+	if _, err := s.PauseSync(addr); err != nil {
+		t.Fatalf("PauseSync failed: %v", err)
+	}
+	if _, err := s.PreviewSync(context.Background(), addr); !errors.Is(err, ErrSyncPaused) {
+		t.Errorf("Expected ErrSyncPaused for a paused address, got %v", err)
+	}
+}
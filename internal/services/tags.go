@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ihladush/bitcoin/internal/models"
+)
+
+// ErrInvalidTag is returned for tag names that are empty, too long or contain unsupported characters
+var ErrInvalidTag = errors.New("invalid tag")
+
+// maxTagLength caps the length of a tag name
+const maxTagLength = 64
+
+// validateTag checks that a tag is 1 to maxTagLength letters, digits, '-', '_', '.' or ':'
+func validateTag(tag string) error {
+	if tag == "" || len(tag) > maxTagLength {
+		return fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidTag, maxTagLength)
+	}
+	for _, c := range tag {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return fmt.Errorf("%w: %q may only contain letters, digits, '-', '_', '.' and ':'", ErrInvalidTag, tag)
+		}
+	}
+	return nil
+}
+
+// TagAddresses adds tag to several addresses at once and reports which were tagged, already
+// carried the tag, or are not tracked
+func (s *BitcoinService) TagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error) {
+	if err := validateTag(tag); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.TagAddresses(tag, uniqueAddresses(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to tag addresses: %w", err)
+	}
+	return result, nil
+}
+
+// UntagAddresses removes tag from several addresses at once and reports which were untagged,
+// did not carry the tag, or are not tracked
+func (s *BitcoinService) UntagAddresses(tag string, addresses []string) (*models.TagAssignmentResult, error) {
+	if err := validateTag(tag); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.UntagAddresses(tag, uniqueAddresses(addresses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to untag addresses: %w", err)
+	}
+	return result, nil
+}
+
+// GetTaggedAddresses returns the tracked addresses carrying tag
+func (s *BitcoinService) GetTaggedAddresses(tag string) ([]models.Address, error) {
+	if err := validateTag(tag); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTaggedAddresses(tag)
+}
+
+// uniqueAddresses drops empty and repeated addresses so each is reported once
+func uniqueAddresses(addresses []string) []string {
+	seen := make(map[string]bool, len(addresses))
+	var unique []string
+	for _, addr := range addresses {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			unique = append(unique, addr)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// addressAttribute labels a span with the address it works on
+func addressAttribute(address string) trace.SpanStartEventOption {
+	return trace.WithAttributes(attribute.String("bitcoin.address", address))
+}
+
+// traceRepo records a repository.<name> span, a child of the span in ctx, around a repository call
+func traceRepo[T any](ctx context.Context, tracer trace.Tracer, name string, call func() (T, error)) (T, error) {
+	_, span := tracer.Start(ctx, "repository."+name)
+	result, err := call()
+	tracing.End(span, err)
+	return result, err
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ihladush/bitcoin/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanNames returns the names of the ended spans that are children of the span named parent
+func spanNames(spans []sdktrace.ReadOnlySpan, parent string) []string {
+	var parentID string
+	for _, span := range spans {
+		if span.Name() == parent {
+			parentID = span.SpanContext().SpanID().String()
+		}
+	}
+
+	var names []string
+	for _, span := range spans {
+		if span.Parent().SpanID().String() == parentID {
+			names = append(names, span.Name())
+		}
+	}
+	return names
+}
+
+func TestServiceSpans(t *testing.T) {
+	address := "bc1q0sg9rdst255gtldsmcf8rk0764avqy2h2ksqs5"
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(tracing.InstrumentationName)
+	service := NewBitcoinService(newTestRepository(t, address), &fakeClient{}, WithTracer(tracer))
+	ctx := context.Background()
+
+	testCases := []struct {
+		name      string
+		call      func() error
+		wantSpans []string
+	}{
+		{"BitcoinService.GetBalance", func() error {
+			_, err := service.GetBalance(ctx, address)
+			return err
+		}, []string{"repository.GetAddress", "repository.GetBalance"}},
+		{"BitcoinService.GetAllAddresses", func() error {
+			_, err := service.GetAllAddresses(ctx)
+			return err
+		}, []string{"repository.GetAllAddresses", "repository.GetBalances"}},
+		{"BitcoinService.GetTransactions", func() error {
+			_, err := service.GetTransactions(ctx, address, 10, 0)
+			return err
+		}, []string{"repository.GetAddress", "repository.GetTransactionsByAddress"}},
+		{"BitcoinService.RecalculateBalances", func() error {
+			_, err := service.RecalculateBalances(ctx)
+			return err
+		}, []string{"repository.GetAllAddresses", "repository.RefreshBalance", "provider.GetBalance"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.Reset()
+			if err := tc.call(); err != nil {
+				t.Fatalf("%s failed: %v", tc.name, err)
+			}
+			if got := spanNames(recorder.Ended(), tc.name); !slices.Equal(got, tc.wantSpans) {
+				t.Errorf("child spans of %s = %v; want %v", tc.name, got, tc.wantSpans)
+			}
+		})
+	}
+}
+
+func TestServiceSpanRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(tracing.InstrumentationName)
+	service := NewBitcoinService(newTestRepository(t), &fakeClient{}, WithTracer(tracer))
+
+	if _, err := service.GetBalance(context.Background(), "untracked"); !errors.Is(err, ErrAddressNotFound) {
+		t.Fatalf("GetBalance(untracked) = %v; want ErrAddressNotFound", err)
+	}
+
+	for _, span := range recorder.Ended() {
+		if span.Status().Code != codes.Error {
+			t.Errorf("span %s status = %+v; want an error", span.Name(), span.Status())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package tracing sets up OpenTelemetry tracing exported to an OTLP/HTTP collector.
+// Code is instrumented with the OpenTelemetry API directly; a no-op tracer records nothing,
+// so call sites need no configuration checks.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName names the tracer the application's spans are recorded with
+const InstrumentationName = "github.com/ihladush/bitcoin"
+
+// NewProvider creates a tracer provider that batches spans to the collector at endpoint, such as
+// http://localhost:4318, and reports them as coming from serviceName. Shut it down to flush queued spans.
+func NewProvider(ctx context.Context, endpoint, serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	), nil
+}
+
+// NoopTracer returns a tracer that records nothing
+func NoopTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(InstrumentationName)
+}
+
+// End ends span, marking it failed with err when err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
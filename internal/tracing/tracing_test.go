@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(InstrumentationName)
+
+	ctx, root := tracer.Start(context.Background(), "GET /addresses")
+	_, child := tracer.Start(ctx, "repository.GetAllAddresses")
+	End(child, errors.New("boom"))
+	End(root, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended %d spans; want 2", len(spans))
+	}
+	failed, ok := spans[0], spans[1]
+	if failed.Status().Code != codes.Error || failed.Status().Description != "boom" || len(failed.Events()) != 1 {
+		t.Errorf("failed span status %+v with %d events; want the error recorded", failed.Status(), len(failed.Events()))
+	}
+	if ok.Status().Code != codes.Unset {
+		t.Errorf("successful span status = %+v; want unset", ok.Status())
+	}
+	if failed.Parent().SpanID() != ok.SpanContext().SpanID() {
+		t.Error("child span is not parented to the root span")
+	}
+}
+
+func TestNoopTracer(t *testing.T) {
+	_, span := NoopTracer().Start(context.Background(), "noop")
+	End(span, errors.New("ignored"))
+	if span.SpanContext().IsValid() {
+		t.Error("no-op tracer recorded a span")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(context.Background(), server.URL+"/", "bitcoin-tracker")
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	_, span := provider.Tracer(InstrumentationName).Start(context.Background(), "GET /health")
+	End(span, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	select {
+	case r := <-received:
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("unexpected export request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+	default:
+		t.Fatal("Shutdown returned before the span was exported")
+	}
+}